@@ -0,0 +1,335 @@
+package abi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/crypto"
+	"github.com/tn606024/ethwallet/utils"
+	"io/ioutil"
+	"strings"
+)
+
+type Argument struct {
+	Name         string     `json:"name"`
+	Type         string     `json:"type"`
+	InternalType string     `json:"internalType,omitempty"`
+	Components   []Argument `json:"components,omitempty"`
+	Indexed      bool       `json:"indexed,omitempty"`
+}
+
+type Arguments []Argument
+
+func (args Arguments) Types() ([]*Type, error) {
+	types := make([]*Type, len(args))
+	for i, arg := range args {
+		t, err := NewType(arg.Type, arg.Components)
+		if err != nil {
+			return nil, fmt.Errorf("argument %s: %s", arg.Name, err)
+		}
+		types[i] = t
+	}
+	return types, nil
+}
+
+func (args Arguments) Pack(values ...interface{}) ([]byte, error) {
+	types, err := args.Types()
+	if err != nil {
+		return nil, err
+	}
+	return Encode(types, values)
+}
+
+func (args Arguments) Unpack(data []byte) ([]interface{}, error) {
+	types, err := args.Types()
+	if err != nil {
+		return nil, err
+	}
+	return Decode(types, data)
+}
+
+// Format converts unpacked values to json friendly values, see Format
+func (args Arguments) Format(values []interface{}) ([]interface{}, error) {
+	types, err := args.Types()
+	if err != nil {
+		return nil, err
+	}
+	if len(types) != len(values) {
+		return nil, fmt.Errorf("expects %d values, got %d", len(types), len(values))
+	}
+	res := make([]interface{}, len(values))
+	for i, t := range types {
+		res[i] = Format(t, values[i])
+	}
+	return res, nil
+}
+
+func (args Arguments) signature() (string, error) {
+	types, err := args.Types()
+	if err != nil {
+		return "", err
+	}
+	return "(" + typesString(types) + ")", nil
+}
+
+// Method is a function or the constructor of json abi
+type Method struct {
+	Name            string
+	Type            string
+	Inputs          Arguments
+	Outputs         Arguments
+	StateMutability string
+	Signature       string
+	MethodId        []byte
+}
+
+func (m *Method) IsConstant() bool {
+	return m.StateMutability == "view" || m.StateMutability == "pure"
+}
+
+func (m *Method) IsPayable() bool {
+	return m.StateMutability == "payable"
+}
+
+// Pack returns method id followed by the encoded args, the constructor has no method id
+func (m *Method) Pack(args ...interface{}) ([]byte, error) {
+	data, err := m.Inputs.Pack(args...)
+	if err != nil {
+		return nil, fmt.Errorf("pack %s occured error: %s", m.Signature, err)
+	}
+	return append(append([]byte{}, m.MethodId...), data...), nil
+}
+
+func (m *Method) Unpack(data []byte) ([]interface{}, error) {
+	values, err := m.Outputs.Unpack(data)
+	if err != nil {
+		return nil, fmt.Errorf("unpack %s occured error: %s", m.Signature, err)
+	}
+	return values, nil
+}
+
+// UnpackInput decodes the input of a transaction calling m
+func (m *Method) UnpackInput(data []byte) ([]interface{}, error) {
+	if len(data) < len(m.MethodId) || !bytes.Equal(data[:len(m.MethodId)], m.MethodId) {
+		return nil, fmt.Errorf("data doesn't call %s", m.Signature)
+	}
+	return m.Inputs.Unpack(data[len(m.MethodId):])
+}
+
+type Event struct {
+	Name      string
+	Inputs    Arguments
+	Anonymous bool
+	Signature string
+	Topic     common.Hash
+}
+
+// UnpackLog decodes indexed inputs from topics and the others from data. Indexed dynamic
+// inputs are stored in topics as keccak256 hash, so they are returned as common.Hash.
+func (e *Event) UnpackLog(topics []common.Hash, data []byte) (map[string]interface{}, error) {
+	if !e.Anonymous {
+		if len(topics) == 0 || topics[0] != e.Topic {
+			return nil, fmt.Errorf("log is not %s", e.Signature)
+		}
+		topics = topics[1:]
+	}
+	var indexed, nonIndexed Arguments
+	for _, input := range e.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		} else {
+			nonIndexed = append(nonIndexed, input)
+		}
+	}
+	if len(topics) != len(indexed) {
+		return nil, fmt.Errorf("%s expects %d indexed topics, got %d", e.Signature, len(indexed), len(topics))
+	}
+	res := make(map[string]interface{}, len(e.Inputs))
+	types, err := indexed.Types()
+	if err != nil {
+		return nil, err
+	}
+	for i, t := range types {
+		if t.IsDynamic() || t.Kind == ArrayKind || t.Kind == TupleKind {
+			res[indexed[i].Name] = topics[i]
+			continue
+		}
+		value, err := t.decode(topics[i].Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("topic %s: %s", indexed[i].Name, err)
+		}
+		res[indexed[i].Name] = value
+	}
+	values, err := nonIndexed.Unpack(data)
+	if err != nil {
+		return nil, err
+	}
+	for i, value := range values {
+		res[nonIndexed[i].Name] = value
+	}
+	return res, nil
+}
+
+type ABI struct {
+	Constructor *Method
+	Methods     []*Method
+	Events      []*Event
+}
+
+type entry struct {
+	Type            string    `json:"type"`
+	Name            string    `json:"name"`
+	Inputs          Arguments `json:"inputs"`
+	Outputs         Arguments `json:"outputs"`
+	StateMutability string    `json:"stateMutability"`
+	Constant        bool      `json:"constant"`
+	Payable         bool      `json:"payable"`
+	Anonymous       bool      `json:"anonymous"`
+}
+
+// JSON parses a json abi, a compiler artifact which has an "abi" field(truffle, hardhat) is also accepted
+func JSON(b []byte) (*ABI, error) {
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '{' {
+		var artifact struct {
+			Abi json.RawMessage `json:"abi"`
+		}
+		if err := json.Unmarshal(b, &artifact); err != nil {
+			return nil, fmt.Errorf("abi unmarshal error: %s", err)
+		}
+		if artifact.Abi == nil {
+			return nil, fmt.Errorf("abi field is not found")
+		}
+		b = artifact.Abi
+	}
+	var entries []entry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, fmt.Errorf("abi unmarshal error: %s", err)
+	}
+	abi := &ABI{}
+	for _, e := range entries {
+		switch e.Type {
+		case "function", "", "constructor":
+			method, err := newMethod(e)
+			if err != nil {
+				return nil, err
+			}
+			if e.Type == "constructor" {
+				abi.Constructor = method
+			} else {
+				abi.Methods = append(abi.Methods, method)
+			}
+		case "event":
+			sig, err := e.Inputs.signature()
+			if err != nil {
+				return nil, fmt.Errorf("event %s: %s", e.Name, err)
+			}
+			signature := e.Name + sig
+			abi.Events = append(abi.Events, &Event{
+				Name:      e.Name,
+				Inputs:    e.Inputs,
+				Anonymous: e.Anonymous,
+				Signature: signature,
+				Topic:     common.BytesToHash(crypto.Keccak256([]byte(signature))),
+			})
+		}
+	}
+	return abi, nil
+}
+
+func newMethod(e entry) (*Method, error) {
+	sig, err := e.Inputs.signature()
+	if err != nil {
+		return nil, fmt.Errorf("function %s: %s", e.Name, err)
+	}
+	if _, err := e.Outputs.Types(); err != nil {
+		return nil, fmt.Errorf("function %s: %s", e.Name, err)
+	}
+	// abi generated before solidity 0.4.16 has constant and payable instead of stateMutability
+	mutability := e.StateMutability
+	if mutability == "" {
+		switch {
+		case e.Constant:
+			mutability = "view"
+		case e.Payable:
+			mutability = "payable"
+		default:
+			mutability = "nonpayable"
+		}
+	}
+	method := &Method{
+		Name:            e.Name,
+		Type:            "function",
+		Inputs:          e.Inputs,
+		Outputs:         e.Outputs,
+		StateMutability: mutability,
+		Signature:       e.Name + sig,
+	}
+	if e.Type == "constructor" {
+		method.Type = "constructor"
+		method.Signature = "constructor" + sig
+		method.MethodId = []byte{}
+	} else {
+		method.MethodId = utils.ToMethodID(method.Signature)
+	}
+	return method, nil
+}
+
+func LoadFile(path string) (*ABI, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read abi file error: %s", err)
+	}
+	return JSON(b)
+}
+
+// Method finds a method by name, overloaded methods must be given by signature, e.g. safeTransferFrom(address,address,uint256)
+func (abi *ABI) Method(name string) (*Method, error) {
+	name = strings.Join(strings.Fields(name), "")
+	var found []*Method
+	for _, method := range abi.Methods {
+		if method.Signature == name || (!strings.Contains(name, "(") && method.Name == name) {
+			found = append(found, method)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("method %s is not found in abi", name)
+	case 1:
+		return found[0], nil
+	}
+	sigs := make([]string, len(found))
+	for i, method := range found {
+		sigs[i] = method.Signature
+	}
+	return nil, fmt.Errorf("method %s is overloaded, please use one of %s", name, strings.Join(sigs, ", "))
+}
+
+func (abi *ABI) MethodById(id []byte) (*Method, error) {
+	for _, method := range abi.Methods {
+		if bytes.Equal(method.MethodId, id) {
+			return method, nil
+		}
+	}
+	return nil, fmt.Errorf("method id %s is not found in abi", utils.BytesToHexStr(id))
+}
+
+func (abi *ABI) Event(name string) (*Event, error) {
+	name = strings.Join(strings.Fields(name), "")
+	for _, event := range abi.Events {
+		if event.Signature == name || event.Name == name {
+			return event, nil
+		}
+	}
+	return nil, fmt.Errorf("event %s is not found in abi", name)
+}
+
+func (abi *ABI) EventByTopic(topic common.Hash) (*Event, error) {
+	for _, event := range abi.Events {
+		if !event.Anonymous && event.Topic == topic {
+			return event, nil
+		}
+	}
+	return nil, fmt.Errorf("event %s is not found in abi", topic.String())
+}
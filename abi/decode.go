@@ -0,0 +1,179 @@
+package abi
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"math/big"
+	"unicode/utf8"
+)
+
+// Decode decodes data as a tuple of types. Integers are returned as *big.Int, address as common.Address,
+// bytes, bytesN and function as []byte, arrays and tuples as []interface{}.
+func Decode(types []*Type, data []byte) ([]interface{}, error) {
+	return decodeTuple(types, data)
+}
+
+func decodeTuple(types []*Type, data []byte) ([]interface{}, error) {
+	values := make([]interface{}, len(types))
+	offset := 0
+	for i, t := range types {
+		var value interface{}
+		var err error
+		if t.IsDynamic() {
+			var start int
+			start, err = readSize(data, offset)
+			if err == nil {
+				if start > len(data) {
+					err = fmt.Errorf("offset %d is out of range", start)
+				} else {
+					value, err = t.decode(data[start:])
+				}
+			}
+		} else {
+			if offset+t.headSize() > len(data) {
+				err = fmt.Errorf("data is too short")
+			} else {
+				value, err = t.decode(data[offset:])
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("value %d(%s): %s", i, t.String(), err)
+		}
+		values[i] = value
+		offset += t.headSize()
+	}
+	return values, nil
+}
+
+func (t *Type) decode(data []byte) (interface{}, error) {
+	switch t.Kind {
+	case SliceKind:
+		n, err := readSize(data, 0)
+		if err != nil {
+			return nil, err
+		}
+		if n*t.Elem.headSize() > len(data)-32 {
+			return nil, fmt.Errorf("array length %d is out of range", n)
+		}
+		return decodeTuple(repeatType(t.Elem, n), data[32:])
+	case ArrayKind:
+		return decodeTuple(repeatType(t.Elem, t.Size), data)
+	case TupleKind:
+		return decodeTuple(t.Components, data)
+	case StringKind, BytesKind:
+		n, err := readSize(data, 0)
+		if err != nil {
+			return nil, err
+		}
+		if n > len(data)-32 {
+			return nil, fmt.Errorf("length %d is out of range", n)
+		}
+		b := make([]byte, n)
+		copy(b, data[32:32+n])
+		if t.Kind == StringKind {
+			if !utf8.Valid(b) {
+				return nil, fmt.Errorf("string is not valid utf8")
+			}
+			return string(b), nil
+		}
+		return b, nil
+	}
+	if len(data) < 32 {
+		return nil, fmt.Errorf("data is too short")
+	}
+	word := data[:32]
+	switch t.Kind {
+	case UintKind:
+		i := new(big.Int).SetBytes(word)
+		if i.BitLen() > t.Size {
+			return nil, fmt.Errorf("value is out of range for uint%d", t.Size)
+		}
+		return i, nil
+	case IntKind:
+		i := new(big.Int).SetBytes(word)
+		if word[0]&0x80 != 0 {
+			i.Sub(i, new(big.Int).Lsh(big.NewInt(1), 256))
+		}
+		if _, err := encodeInteger(i, t.Size, false); err != nil {
+			return nil, err
+		}
+		return i, nil
+	case BoolKind:
+		i := new(big.Int).SetBytes(word)
+		if i.Cmp(big.NewInt(1)) > 0 {
+			return nil, fmt.Errorf("bool must be 0 or 1")
+		}
+		return i.Sign() == 1, nil
+	case AddressKind:
+		if new(big.Int).SetBytes(word[:12]).Sign() != 0 {
+			return nil, fmt.Errorf("address has dirty high bytes")
+		}
+		return common.BytesToAddress(word[12:]), nil
+	case FixedBytesKind, FunctionKind:
+		for _, b := range word[t.Size:] {
+			if b != 0 {
+				return nil, fmt.Errorf("bytes%d has dirty low bytes", t.Size)
+			}
+		}
+		b := make([]byte, t.Size)
+		copy(b, word)
+		return b, nil
+	}
+	return nil, fmt.Errorf("type %s is not supported", t.String())
+}
+
+// readSize reads an offset or length word and makes sure it fits in int
+func readSize(data []byte, offset int) (int, error) {
+	if offset+32 > len(data) {
+		return 0, fmt.Errorf("data is too short")
+	}
+	i := new(big.Int).SetBytes(data[offset : offset+32])
+	if !i.IsInt64() || i.Int64() > int64(len(data)) {
+		return 0, fmt.Errorf("offset or length %s is out of range", i.String())
+	}
+	return int(i.Int64()), nil
+}
+
+// Format converts a decoded value to a json friendly value, integers are decimal strings,
+// bytes are 0x hex strings and tuples with named components become objects.
+func Format(t *Type, v interface{}) interface{} {
+	switch t.Kind {
+	case IntKind, UintKind:
+		return v.(*big.Int).String()
+	case AddressKind:
+		return v.(common.Address).String()
+	case BytesKind, FixedBytesKind, FunctionKind:
+		return "0x" + common.Bytes2Hex(v.([]byte))
+	case SliceKind, ArrayKind:
+		items := v.([]interface{})
+		res := make([]interface{}, len(items))
+		for i, item := range items {
+			res[i] = Format(t.Elem, item)
+		}
+		return res
+	case TupleKind:
+		return formatTuple(t.Components, t.Names, v.([]interface{}))
+	}
+	return v
+}
+
+func formatTuple(types []*Type, names []string, values []interface{}) interface{} {
+	named := len(names) == len(types)
+	for _, name := range names {
+		if name == "" {
+			named = false
+		}
+	}
+	if named {
+		res := make(map[string]interface{}, len(types))
+		for i, t := range types {
+			res[names[i]] = Format(t, values[i])
+		}
+		return res
+	}
+	res := make([]interface{}, len(types))
+	for i, t := range types {
+		res[i] = Format(t, values[i])
+	}
+	return res
+}
@@ -0,0 +1,265 @@
+package abi
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/utils"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Encode encodes values as a tuple of types. Values can be go values(*big.Int, common.Address, []byte...)
+// or human readable json values: integers as numbers or decimal/hex strings, bytes as 0x hex strings,
+// arrays as json arrays and tuples as json arrays or objects keyed by component name.
+func Encode(types []*Type, values []interface{}) ([]byte, error) {
+	if len(types) != len(values) {
+		return nil, fmt.Errorf("expects %d arguments, got %d", len(types), len(values))
+	}
+	return encodeTuple(types, values)
+}
+
+func encodeTuple(types []*Type, values []interface{}) ([]byte, error) {
+	offset := 0
+	for _, t := range types {
+		offset += t.headSize()
+	}
+	var head, tail []byte
+	for i, t := range types {
+		enc, err := t.encode(values[i])
+		if err != nil {
+			return nil, fmt.Errorf("argument %d(%s): %s", i, t.String(), err)
+		}
+		if t.IsDynamic() {
+			head = append(head, encodeUint(big.NewInt(int64(offset+len(tail))))...)
+			tail = append(tail, enc...)
+		} else {
+			head = append(head, enc...)
+		}
+	}
+	return append(head, tail...), nil
+}
+
+func (t *Type) encode(v interface{}) ([]byte, error) {
+	switch t.Kind {
+	case IntKind, UintKind:
+		i, err := toBigInt(v)
+		if err != nil {
+			return nil, err
+		}
+		return encodeInteger(i, t.Size, t.Kind == UintKind)
+	case BoolKind:
+		b, err := toBool(v)
+		if err != nil {
+			return nil, err
+		}
+		if b {
+			return encodeUint(big.NewInt(1)), nil
+		}
+		return encodeUint(big.NewInt(0)), nil
+	case AddressKind:
+		address, err := toAddress(v)
+		if err != nil {
+			return nil, err
+		}
+		return common.LeftPadBytes(address.Bytes(), 32), nil
+	case StringKind:
+		str, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expects a string, got %T", v)
+		}
+		return encodeBytes([]byte(str)), nil
+	case BytesKind:
+		b, err := toBytes(v)
+		if err != nil {
+			return nil, err
+		}
+		return encodeBytes(b), nil
+	case FixedBytesKind, FunctionKind:
+		b, err := toBytes(v)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) > t.Size || (t.Kind == FunctionKind && len(b) != t.Size) {
+			return nil, fmt.Errorf("expects %d bytes, got %d", t.Size, len(b))
+		}
+		return common.RightPadBytes(b, 32), nil
+	case SliceKind:
+		items, err := toList(v)
+		if err != nil {
+			return nil, err
+		}
+		enc, err := encodeTuple(repeatType(t.Elem, len(items)), items)
+		if err != nil {
+			return nil, err
+		}
+		return append(encodeUint(big.NewInt(int64(len(items)))), enc...), nil
+	case ArrayKind:
+		items, err := toList(v)
+		if err != nil {
+			return nil, err
+		}
+		if len(items) != t.Size {
+			return nil, fmt.Errorf("expects %d items, got %d", t.Size, len(items))
+		}
+		return encodeTuple(repeatType(t.Elem, len(items)), items)
+	case TupleKind:
+		items, err := t.tupleValues(v)
+		if err != nil {
+			return nil, err
+		}
+		return encodeTuple(t.Components, items)
+	}
+	return nil, fmt.Errorf("type %s is not supported", t.String())
+}
+
+// tupleValues accepts values in order or an object keyed by component name
+func (t *Type) tupleValues(v interface{}) ([]interface{}, error) {
+	if m, ok := v.(map[string]interface{}); ok {
+		items := make([]interface{}, len(t.Components))
+		for i, name := range t.Names {
+			item, ok := m[name]
+			if !ok {
+				return nil, fmt.Errorf("component %s is missing", name)
+			}
+			items[i] = item
+		}
+		if len(m) != len(t.Names) {
+			return nil, fmt.Errorf("expects %d components, got %d", len(t.Names), len(m))
+		}
+		return items, nil
+	}
+	items, err := toList(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) != len(t.Components) {
+		return nil, fmt.Errorf("expects %d components, got %d", len(t.Components), len(items))
+	}
+	return items, nil
+}
+
+func encodeUint(i *big.Int) []byte {
+	return common.LeftPadBytes(i.Bytes(), 32)
+}
+
+// encodeInteger checks the range of intN/uintN and returns the 32 bytes two's complement
+func encodeInteger(i *big.Int, bits int, unsigned bool) ([]byte, error) {
+	var min, max *big.Int
+	if unsigned {
+		min = big.NewInt(0)
+		max = new(big.Int).Lsh(big.NewInt(1), uint(bits))
+	} else {
+		max = new(big.Int).Lsh(big.NewInt(1), uint(bits-1))
+		min = new(big.Int).Neg(max)
+	}
+	if i.Cmp(min) < 0 || i.Cmp(max) >= 0 {
+		return nil, fmt.Errorf("%s is out of range for %d bits", i.String(), bits)
+	}
+	if i.Sign() < 0 {
+		i = new(big.Int).Add(i, new(big.Int).Lsh(big.NewInt(1), 256))
+	}
+	return encodeUint(i), nil
+}
+
+func encodeBytes(b []byte) []byte {
+	res := encodeUint(big.NewInt(int64(len(b))))
+	if len(b) == 0 {
+		return res
+	}
+	return append(res, common.RightPadBytes(b, (len(b)+31)/32*32)...)
+}
+
+func toBigInt(v interface{}) (*big.Int, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return big.NewInt(rv.Int()), nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint:
+		return new(big.Int).SetUint64(rv.Uint()), nil
+	}
+	return utils.ToBigInt(v)
+}
+
+func toBool(v interface{}) (bool, error) {
+	switch b := v.(type) {
+	case bool:
+		return b, nil
+	case string:
+		res, err := strconv.ParseBool(b)
+		if err != nil {
+			return false, fmt.Errorf("expects true or false, got %s", b)
+		}
+		return res, nil
+	}
+	return false, fmt.Errorf("expects a boolean, got %T", v)
+}
+
+func toAddress(v interface{}) (common.Address, error) {
+	switch address := v.(type) {
+	case common.Address:
+		return address, nil
+	case *common.Address:
+		return *address, nil
+	case string:
+		if !common.IsHexAddress(address) {
+			return common.Address{}, fmt.Errorf("%s is not a hex address", address)
+		}
+		return common.HexToAddress(address), nil
+	}
+	return common.Address{}, fmt.Errorf("expects an address, got %T", v)
+}
+
+func toBytes(v interface{}) ([]byte, error) {
+	switch b := v.(type) {
+	case []byte:
+		return b, nil
+	case string:
+		if !strings.HasPrefix(b, "0x") || !utils.IsHexStr(b[2:]) {
+			return nil, fmt.Errorf("expects a 0x prefixed hex string, got %s", b)
+		}
+		return utils.HexStrToBytes(b), nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Array && rv.Type().Elem().Kind() == reflect.Uint8 {
+		b := make([]byte, rv.Len())
+		reflect.Copy(reflect.ValueOf(b), rv)
+		return b, nil
+	}
+	return nil, fmt.Errorf("expects bytes, got %T", v)
+}
+
+// toList accepts any slice or array, and a json array string for convenience on the command line
+func toList(v interface{}) ([]interface{}, error) {
+	if items, ok := v.([]interface{}); ok {
+		return items, nil
+	}
+	if str, ok := v.(string); ok && strings.HasPrefix(strings.TrimSpace(str), "[") {
+		return ParseArgs(str)
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expects an array, got %T", v)
+	}
+	items := make([]interface{}, rv.Len())
+	for i := range items {
+		items[i] = rv.Index(i).Interface()
+	}
+	return items, nil
+}
+
+// ParseArgs parses a json array of human readable arguments, numbers are kept as json.Number
+func ParseArgs(str string) ([]interface{}, error) {
+	if strings.TrimSpace(str) == "" {
+		return []interface{}{}, nil
+	}
+	var args []interface{}
+	decoder := json.NewDecoder(strings.NewReader(str))
+	decoder.UseNumber()
+	if err := decoder.Decode(&args); err != nil {
+		return nil, fmt.Errorf("args must be a json array: %s", err)
+	}
+	return args, nil
+}
@@ -0,0 +1,184 @@
+package abi
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type Kind int
+
+const (
+	IntKind Kind = iota
+	UintKind
+	BoolKind
+	AddressKind
+	StringKind
+	BytesKind
+	FixedBytesKind
+	FunctionKind
+	SliceKind
+	ArrayKind
+	TupleKind
+)
+
+var typeRegexp = regexp.MustCompile(`^(u?int|bytes)([0-9]*)$`)
+
+// Type is a parsed solidity abi type, Size is the bits of intN/uintN, N of bytesN and length of T[N]
+type Type struct {
+	Kind       Kind
+	Size       int
+	Elem       *Type
+	Components []*Type
+	Names      []string
+}
+
+// NewType parses a type of json abi, components are only used by tuple and tuple arrays
+func NewType(typ string, components []Argument) (*Type, error) {
+	typ = strings.TrimSpace(typ)
+	if strings.HasSuffix(typ, "]") {
+		i := strings.LastIndex(typ, "[")
+		if i <= 0 {
+			return nil, fmt.Errorf("type %s is illegal", typ)
+		}
+		elem, err := NewType(typ[:i], components)
+		if err != nil {
+			return nil, err
+		}
+		size := typ[i+1 : len(typ)-1]
+		if size == "" {
+			return &Type{Kind: SliceKind, Elem: elem}, nil
+		}
+		n, err := strconv.Atoi(size)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("type %s has illegal array size", typ)
+		}
+		return &Type{Kind: ArrayKind, Size: n, Elem: elem}, nil
+	}
+	switch typ {
+	case "bool":
+		return &Type{Kind: BoolKind}, nil
+	case "address":
+		return &Type{Kind: AddressKind}, nil
+	case "string":
+		return &Type{Kind: StringKind}, nil
+	case "bytes":
+		return &Type{Kind: BytesKind}, nil
+	case "function":
+		return &Type{Kind: FunctionKind, Size: 24}, nil
+	case "tuple":
+		t := &Type{Kind: TupleKind}
+		for _, component := range components {
+			ct, err := NewType(component.Type, component.Components)
+			if err != nil {
+				return nil, err
+			}
+			t.Components = append(t.Components, ct)
+			t.Names = append(t.Names, component.Name)
+		}
+		return t, nil
+	}
+	match := typeRegexp.FindStringSubmatch(typ)
+	if match == nil {
+		return nil, fmt.Errorf("type %s is not supported", typ)
+	}
+	if match[1] == "bytes" {
+		n, _ := strconv.Atoi(match[2])
+		if n < 1 || n > 32 {
+			return nil, fmt.Errorf("type %s is illegal", typ)
+		}
+		return &Type{Kind: FixedBytesKind, Size: n}, nil
+	}
+	bits := 256
+	if match[2] != "" {
+		bits, _ = strconv.Atoi(match[2])
+	}
+	if bits < 8 || bits > 256 || bits%8 != 0 {
+		return nil, fmt.Errorf("type %s is illegal", typ)
+	}
+	if match[1] == "uint" {
+		return &Type{Kind: UintKind, Size: bits}, nil
+	}
+	return &Type{Kind: IntKind, Size: bits}, nil
+}
+
+// String returns the canonical type used in function signatures, e.g. (address,uint256)[]
+func (t *Type) String() string {
+	switch t.Kind {
+	case IntKind:
+		return fmt.Sprintf("int%d", t.Size)
+	case UintKind:
+		return fmt.Sprintf("uint%d", t.Size)
+	case BoolKind:
+		return "bool"
+	case AddressKind:
+		return "address"
+	case StringKind:
+		return "string"
+	case BytesKind:
+		return "bytes"
+	case FixedBytesKind:
+		return fmt.Sprintf("bytes%d", t.Size)
+	case FunctionKind:
+		return "function"
+	case SliceKind:
+		return t.Elem.String() + "[]"
+	case ArrayKind:
+		return fmt.Sprintf("%s[%d]", t.Elem.String(), t.Size)
+	case TupleKind:
+		return "(" + typesString(t.Components) + ")"
+	}
+	return ""
+}
+
+func typesString(types []*Type) string {
+	strs := make([]string, len(types))
+	for i, t := range types {
+		strs[i] = t.String()
+	}
+	return strings.Join(strs, ",")
+}
+
+// IsDynamic reports whether the encoding of t is stored in the tail and referenced by an offset
+func (t *Type) IsDynamic() bool {
+	switch t.Kind {
+	case StringKind, BytesKind, SliceKind:
+		return true
+	case ArrayKind:
+		return t.Elem.IsDynamic()
+	case TupleKind:
+		for _, c := range t.Components {
+			if c.IsDynamic() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// headSize is the bytes t occupies in the head of its enclosing tuple
+func (t *Type) headSize() int {
+	if t.IsDynamic() {
+		return 32
+	}
+	switch t.Kind {
+	case ArrayKind:
+		return t.Size * t.Elem.headSize()
+	case TupleKind:
+		size := 0
+		for _, c := range t.Components {
+			size += c.headSize()
+		}
+		return size
+	}
+	return 32
+}
+
+func repeatType(t *Type, n int) []*Type {
+	types := make([]*Type, n)
+	for i := range types {
+		types[i] = t
+	}
+	return types
+}
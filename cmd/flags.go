@@ -1,6 +1,9 @@
 package cmd
 
-import 	"github.com/urfave/cli/v2"
+import (
+	"github.com/urfave/cli/v2"
+	"time"
+)
 
 
 var (
@@ -18,7 +21,12 @@ var (
 	addressFlag = &cli.StringFlag{
 		Name:	"address",
 		Value: 	 "",
-		Usage: 	"ethereum address",
+		Usage: 	"ethereum address or ens name",
+	}
+	ensNameFlag = &cli.StringFlag{
+		Name:	"name",
+		Value: 	 "",
+		Usage: 	"ens name like vitalik.eth",
 	}
 	signatureFlag = &cli.StringFlag{
 		Name:	"signature",
@@ -32,6 +40,14 @@ var (
 		Name: 	 "msgfile",
 		Usage:	 "message file",
 	}
+	typedDataFlag = &cli.StringFlag{
+		Name:	"typeddata",
+		Usage:	"eip712 typed data in json format",
+	}
+	typedDataFileFlag = &cli.StringFlag{
+		Name:	"typeddatafile",
+		Usage:	"eip712 typed data json file",
+	}
 	transactionFlag = &cli.StringFlag{
 		Name:	"transaction",
 		Usage: 	"transaction in json format",
@@ -60,12 +76,200 @@ var (
 	}
 	valueFlag = &cli.StringFlag{
 		Name:	"value",
-		Usage:	"send value",
+		Usage:	"send value, ether is in wei unless it has a unit like 1.5ether or 30gwei, erc20 is in token's unit like 0.5",
+		Required: true,
+	}
+	optionalValueFlag = &cli.StringFlag{
+		Name:	"value",
+		Usage:	"send value, it's in wei unless it has a unit like 1.5ether or 30gwei",
+		Value:	"0",
+	}
+	bytecodeFlag = &cli.StringFlag{
+		Name:	"bytecode",
+		Usage:	"contract creation bytecode in hex",
+	}
+	bytecodeFileFlag = &cli.StringFlag{
+		Name:	"bytecodefile",
+		Usage:	"contract creation bytecode in file",
+	}
+	constructorArgsFlag = &cli.StringFlag{
+		Name:	"constructorargs",
+		Usage:	"abi encoded constructor args in hex",
+		Value:	"",
+	}
+	contractFlag = &cli.StringFlag{
+		Name:	"contract",
+		Usage:	"contract address",
+		Required: true,
+	}
+	abiFlag = &cli.StringFlag{
+		Name:	"abi",
+		Usage:	"contract json abi file(or compiler artifact with abi field)",
+	}
+	methodFlag = &cli.StringFlag{
+		Name:	"method",
+		Usage:	"method name, overloaded method needs signature, e.g. transfer(address,uint256)",
+		Required: true,
+	}
+	argsFlag = &cli.StringFlag{
+		Name:	"args",
+		Usage:	"method args in json array, e.g. [\"0x51bf0b41Ba5B034f158CF1233f16bA5450F9355B\", \"1000\"]",
+		Value:	"",
+	}
+	watchTypeFlag = &cli.StringFlag{
+		Name:	"type",
+		Usage:	"subscription type: heads, logs or pending",
+		Value:	"heads",
+	}
+	contractsFlag = &cli.StringFlag{
+		Name:	"contracts",
+		Usage:	"comma separated contract addresses of logs",
+		Value:	"",
+	}
+	eventFlag = &cli.StringFlag{
+		Name:	"event",
+		Usage:	"event signature of logs, used as the first topic, e.g. Transfer(address,address,uint256)",
+		Value:	"",
+	}
+	topicsFlag = &cli.StringFlag{
+		Name:	"topics",
+		Usage:	"topics of logs in json array, null matches any topic and an array matches one of topics, e.g. [null, \"0x00...01\"]",
+		Value:	"",
+	}
+	offlineTxFlag = &cli.StringFlag{
+		Name:	"offlinetx",
+		Usage:	"offline transaction in json or base64 payload",
+	}
+	offlineTxFileFlag = &cli.StringFlag{
+		Name:	"offlinetxfile",
+		Usage:	"offline transaction file in json or base64 payload",
+	}
+	outFlag = &cli.StringFlag{
+		Name:	"out",
+		Usage:	"output file, print to terminal if it's not set",
+		Value:	"",
+	}
+	base64Flag = &cli.BoolFlag{
+		Name:	"base64",
+		Usage:	"output base64 payload in one line instead of json",
+	}
+	yesFlag = &cli.BoolFlag{
+		Name:	"yes",
+		Usage:	"don't ask for confirmation",
+	}
+	optionalSymbolFlag = &cli.StringFlag{
+		Name:	"symbol",
+		Usage: 	"erc20 symbol, value is in token's unit if it's set",
+		Value:	"",
+	}
+	txidFlag = &cli.StringFlag{
+		Name:	"txid",
+		Usage:	"transaction hash",
+		Required: true,
+	}
+	spenderFlag = &cli.StringFlag{
+		Name:	"spender",
+		Usage:	"spender address of the allowance",
 		Required: true,
 	}
+	tokenFlag = &cli.StringFlag{
+		Name:	"token",
+		Usage:	"erc20 contract address, used instead of symbol for a token not in erc20_list",
+		Value:	"",
+	}
+	approveValueFlag = &cli.StringFlag{
+		Name:	"value",
+		Usage:	"allowance in token's unit like 0.5, or unlimited",
+		Required: true,
+	}
+	deadlineFlag = &cli.StringFlag{
+		Name:	"deadline",
+		Usage:	"unix timestamp the permit expires at, or a duration from now like 30m",
+		Value:	"1h",
+	}
+	discoverFlag = &cli.BoolFlag{
+		Name:	"discover",
+		Usage:	"find tokens from transfers to the address instead of erc20_list",
+		Value:	false,
+	}
+	sourceFlag = &cli.StringFlag{
+		Name:	"source",
+		Usage:	"where transfers are found, etherscan(tokentx) or logs(node's eth_getLogs)",
+		Value:	"etherscan",
+	}
+	fromBlockFlag = &cli.Uint64Flag{
+		Name:	"fromblock",
+		Usage:	"block to start scanning logs from",
+		Value:	0,
+	}
+	tokenIdFlag = &cli.StringFlag{
+		Name:	"tokenid",
+		Usage:	"nft token ids separated by comma, decimal or 0x hex",
+		Required: true,
+	}
+	nftAmountFlag = &cli.StringFlag{
+		Name:	"amount",
+		Usage:	"erc1155 amounts of each token id separated by comma, default is 1 of each",
+		Value:	"",
+	}
+	revokeFlag = &cli.BoolFlag{
+		Name:	"revoke",
+		Usage:	"ask to revoke every allowance, keyfile is needed",
+		Value:	false,
+	}
+	csvFlag = &cli.StringFlag{
+		Name:	"csv",
+		Usage:	"payout csv file path, every line is recipient, symbol(ETH, symbol or address of a token in erc20_list or token_lists) and amount",
+		Required: true,
+	}
+	resultsFlag = &cli.StringFlag{
+		Name:	"results",
+		Usage:	"results csv file path, default is the payout csv path with .results.csv, an existing one is resumed",
+		Value:	"",
+	}
+	concurrencyFlag = &cli.UintFlag{
+		Name:	"concurrency",
+		Usage:	"how many transactions are sent at the same time",
+		Value:	4,
+	}
+	waitFlag = &cli.UintFlag{
+		Name:	"wait",
+		Usage:	"wait until the transaction has n confirmations, 0 doesn't wait",
+		Value:	0,
+	}
+	addressFileFlag = &cli.StringFlag{
+		Name:	"file",
+		Usage:	"file of addresses or ens names, one per line, blank lines and lines starting with # are skipped",
+		Required: true,
+	}
+	assetsFlag = &cli.StringFlag{
+		Name:	"assets",
+		Usage:	"assets separated by comma, ETH, symbols or addresses of erc20 tokens",
+		Value:	"ETH",
+	}
+	blockFlag = &cli.StringFlag{
+		Name:	"block",
+		Usage:	"block number in decimal or 0x hex, latest, earliest or pending",
+		Value:	"latest",
+	}
+	timeoutFlag = &cli.DurationFlag{
+		Name:	"timeout",
+		Usage:	"how long to wait for the transaction receipt or confirmations",
+		Value:	5 * time.Minute,
+	}
 	gaspriceFlag = &cli.StringFlag{
 		Name:	"gasprice",
-		Usage:	"gasprice",
+		Usage:	"gasprice(wei), a unit like 30gwei is accepted",
+		Value:	 "",
+	}
+	maxfeeFlag = &cli.StringFlag{
+		Name:	"maxfee",
+		Usage:	"eip1559 max fee per gas(wei), a unit like 30gwei is accepted",
+		Value:	 "",
+	}
+	tipFlag = &cli.StringFlag{
+		Name:	"tip",
+		Usage:	"eip1559 max priority fee per gas(wei), a unit like 2gwei is accepted",
 		Value:	 "",
 	}
 	gaslimitFlag = &cli.StringFlag{
@@ -73,19 +277,34 @@ var (
 		Usage:	"gaslimit",
 		Value:	 "",
 	}
-	symbolFlag = &cli.StringFlag{
-		Name:	"symbol",
-		Usage: 	"erc20 symbol",
-		Required: true,
-	}
 	portFlag = &cli.IntFlag{
 		Name:	"port",
 		Usage:	"port",
 		Value:	8080,
 	}
+	wordsFlag = &cli.IntFlag{
+		Name:	"words",
+		Usage:	"number of mnemonic words: 12, 15, 18, 21, 24",
+		Value:	12,
+	}
+	bip39PassphraseFlag = &cli.StringFlag{
+		Name:	"bip39passphrase",
+		Usage:	"optional bip39 passphrase of mnemonic",
+		Value:	"",
+	}
+	indexFlag = &cli.UintFlag{
+		Name:	"index",
+		Usage:	"account index of derivation path m/44'/60'/0'/0/index",
+		Value:	0,
+	}
+	countFlag = &cli.UintFlag{
+		Name:	"count",
+		Usage:	"number of accounts",
+		Value:	10,
+	}
 	networkFlag = &cli.StringFlag{
 		Name:	"network",
-		Usage:  "specify ethereum network: mainet, ropsten, rinkery",
+		Usage:  "specify ethereum network: mainnet, ropsten, rinkeby, goerli, sepolia, holesky, polygon, arbitrum, optimism or a network in config.json's networks",
 		Value:	"",
 	}
 )
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/tn606024/ethwallet/types"
+	"github.com/tn606024/ethwallet/utils"
+	"github.com/tn606024/ethwallet/wallet"
+	"github.com/urfave/cli/v2"
+	"os"
+	"strings"
+)
+
+var (
+	hdCreateSubcommand = &cli.Command{
+		Name:        "create",
+		Usage:       "create a hd wallet",
+		Description: "create a bip39 mnemonic, store account m/44'/60'/0'/0/0 as a keystore file and the encrypted seed next to it(<keyfile>.seed)",
+		ArgsUsage:   "<keystorepath> <words> <bip39passphrase>",
+		Flags: []cli.Flag{
+			keystorepathFlag,
+			wordsFlag,
+			bip39PassphraseFlag,
+		},
+		Action: func(c *cli.Context) error {
+			config := loadConfig()
+			mnemonic, err := wallet.NewMnemonic(c.Int("words"))
+			if err != nil {
+				fmt.Printf("create mnemonic occured error: %s\n", err)
+				os.Exit(1)
+			}
+			storeHDWallet(c, config, mnemonic)
+			fmt.Printf("mnemonic: %s\n", mnemonic)
+			fmt.Println("please write down the mnemonic and keep it safe, it's the only way to restore your accounts")
+			return nil
+		},
+	}
+	hdRestoreSubcommand = &cli.Command{
+		Name:        "restore",
+		Usage:       "restore a hd wallet from mnemonic",
+		Description: "restore a hd wallet from bip39 mnemonic, store account m/44'/60'/0'/0/0 as a keystore file and the encrypted seed next to it(<keyfile>.seed). " +
+					 "the mnemonic is prompted for, it isn't a flag so it doesn't end up in shell history or the process list",
+		ArgsUsage:   "<keystorepath> <bip39passphrase>",
+		Flags: []cli.Flag{
+			keystorepathFlag,
+			bip39PassphraseFlag,
+		},
+		Action: func(c *cli.Context) error {
+			config := loadConfig()
+			fmt.Print("Please input mnemonic: ")
+			mnemonic, _ := stdinReader.ReadString('\n')
+			storeHDWallet(c, config, strings.Join(strings.Fields(mnemonic), " "))
+			return nil
+		},
+	}
+	hdAccountsSubcommand = &cli.Command{
+		Name:        "accounts",
+		Usage:       "list hd wallet's accounts",
+		Description: "list addresses derived from hd wallet's seed along m/44'/60'/0'/0/i",
+		ArgsUsage:   "<keyfile> <index> <count>",
+		Flags: []cli.Flag{
+			keyfileFlag,
+			indexFlag,
+			countFlag,
+		},
+		Action: func(c *cli.Context) error {
+			config := loadConfig()
+			hd := unlockHDWallet(c, config)
+			start := uint32(c.Uint("index"))
+			for i := start; i < start+uint32(c.Uint("count")); i++ {
+				account, err := hd.Derive(i)
+				if err != nil {
+					fmt.Printf("derive account occured error: %s\n", err)
+					os.Exit(1)
+				}
+				fmt.Printf("%d: %s\n", i, account.Key.Address.String())
+			}
+			return nil
+		},
+	}
+	hdExportSubcommand = &cli.Command{
+		Name:        "export",
+		Usage:       "export a hd wallet's account to keystore file",
+		Description: "export account m/44'/60'/0'/0/index to a keystore file, you can use keystore file to do wallet and nodewallet command",
+		ArgsUsage:   "<keyfile> <index> <keystorepath>",
+		Flags: []cli.Flag{
+			keyfileFlag,
+			indexFlag,
+			keystorepathFlag,
+		},
+		Action: func(c *cli.Context) error {
+			config := loadConfig()
+			hd := unlockHDWallet(c, config)
+			account, err := hd.Derive(uint32(c.Uint("index")))
+			if err != nil {
+				fmt.Printf("derive account occured error: %s\n", err)
+				os.Exit(1)
+			}
+			path := promptKeystorePath(c)
+			passPhrase := promptPassphrase(true)
+			path, err = wallet.StoreKey(path, account.Key, passPhrase)
+			if err != nil {
+				fmt.Printf("store key occured error: %s\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("address: %s\n", account.Key.Address.String())
+			fmt.Printf("Wallet is create at : %s\n", path)
+			return nil
+		},
+	}
+	hdSubcommand = &cli.Command{
+		Name:        "hd",
+		Usage:       "hd wallet commands",
+		Description: "create or restore bip39 mnemonic and derive bip44 accounts along m/44'/60'/0'/0/i",
+		Subcommands: []*cli.Command{
+			hdCreateSubcommand,
+			hdRestoreSubcommand,
+			hdAccountsSubcommand,
+			hdExportSubcommand,
+		},
+	}
+)
+
+func storeHDWallet(c *cli.Context, config types.Config, mnemonic string) {
+	path := promptKeystorePath(c)
+	passPhrase := promptPassphrase(true)
+	hd, account, err := wallet.CreateHDWallet(mnemonic, c.String("bip39passphrase"), passPhrase, path, config)
+	if err != nil {
+		fmt.Printf("Create HD Wallet Failed: %s\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("address: %s\n", account.Key.Address.String())
+	fmt.Printf("Wallet is create at : %s\n", account.Path)
+	fmt.Printf("Seed is create at : %s\n", hd.Path)
+}
+
+func unlockHDWallet(c *cli.Context, config types.Config) *wallet.HDWallet {
+	keyfile, passPhrase := getKeyfileAndPassPhrase(c, config)
+	hd, err := wallet.ImportHDWallet(passPhrase, keyfile, config)
+	if err != nil {
+		fmt.Printf("Import hd wallet error: %s\n", err)
+		os.Exit(1)
+	}
+	return hd
+}
+
+func promptKeystorePath(c *cli.Context) string {
+	keystorepath := defaultFileDir
+	if c.String("keystorepath") != "" {
+		keystorepath = c.String("keystorepath")
+	}
+	if utils.FileExists(keystorepath) != true {
+		os.Mkdir(keystorepath, 0755)
+	}
+	fmt.Print("Please give keystore a name: ")
+	name, _ := stdinReader.ReadString('\n')
+	return fmt.Sprintf("%s/%s", keystorepath, strings.TrimSpace(name))
+}
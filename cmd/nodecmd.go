@@ -1,12 +1,21 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"github.com/tn606024/ethwallet/ethclient"
 	"github.com/tn606024/ethwallet/types"
 	"github.com/tn606024/ethwallet/wallet"
 	"github.com/urfave/cli/v2"
+	"io/ioutil"
+	"math/big"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 )
 
 var (
@@ -26,7 +35,7 @@ var (
 				os.Exit(1)
 			}
 			fmt.Printf("wei: %s\n", balance.String())
-			fmt.Printf("ether: %s\n", weiToEther(balance))
+			fmt.Printf("ether: %s\n", types.EtherString(balance))
 			return nil
 		},
 	}
@@ -52,13 +61,29 @@ var (
 	erc20balanceCmd = &cli.Command{
 		Name:        "erc20balance",
 		Usage:       "get erc20 balance by an address",
-		Description: "get erc20 balance by an address, you need to set erc20_list.json in config.json",
-		ArgsUsage:   "<address>",
+		Description: "get erc20 balance by an address, you need to set erc20_list.json in config.json. with discover, every token the address received " +
+					 "a transfer of since fromblock is found from source instead and the held ones are shown, found tokens are kept in token_cache_file " +
+					 "so later runs only scan new blocks. a first logs scan without fromblock only covers the last 100000 blocks",
+		ArgsUsage:   "<address> <discover> <source> <fromblock>",
 		Flags: []cli.Flag{
 			addressFlag,
+			discoverFlag,
+			sourceFlag,
+			fromBlockFlag,
 		},
 		Action: func(c *cli.Context) error {
 			wallet := getLookupEthereumWallet(c)
+			if c.Bool("discover") {
+				balances, err := wallet.GetDiscoveredErc20Balance(c.String("source"), c.Uint64("fromblock"))
+				if err != nil {
+					fmt.Printf("getDiscoveredErc20Balance error: %s", err)
+					os.Exit(1)
+				}
+				for _, b := range balances {
+					fmt.Printf("%s: %s (%s)\n", b.Token.Symbol, b.Balance.String(), b.Token.Address.String())
+				}
+				return nil
+			}
 			listbalance, err := wallet.GetErc20ListBalance()
 			if err != nil {
 				fmt.Printf("getErc20ListBalance error: %s", err)
@@ -214,7 +239,378 @@ var (
 		},
 	}
 
+	callCmd = &cli.Command{
+		Name:			"call",
+		Usage:			"call a contract method from node",
+		Description: 	"call a contract method with eth_call and decode the outputs by json abi, args is a json array of human readable values, " +
+						"integers can be numbers or decimal/hex strings, bytes are 0x hex strings, tuples are arrays or objects. address is optional and used as from",
+		ArgsUsage: 		"<contract> <abi> <method> <args> <address>",
+		Flags: []cli.Flag{
+			contractFlag,
+			abiFlag,
+			methodFlag,
+			argsFlag,
+			addressFlag,
+		},
+		Action: func(c *cli.Context) error {
+			config := loadConfig()
+			resolver := newAddressResolver(config)
+			var ew *wallet.EthereumWallet
+			if c.String("address") != "" {
+				ew = wallet.ImportLookupEthereumWallet(getAddressFlag(c, resolver, "address"), config)
+			} else {
+				ew = wallet.ImportEmptyEthereumWallet(config)
+			}
+			method, args := loadAbiMethod(c)
+			contract := getAddressFlag(c, resolver, "contract")
+			values, err := ew.CallContract(&contract, method, args)
+			if err != nil {
+				fmt.Printf("call contract occured error: %s\n", err)
+				os.Exit(1)
+			}
+			formatted, err := method.Outputs.Format(values)
+			if err != nil {
+				fmt.Printf("format outputs occured error: %s\n", err)
+				os.Exit(1)
+			}
+			for i, value := range formatted {
+				name := method.Outputs[i].Name
+				if name == "" {
+					name = strconv.Itoa(i)
+				}
+				b, err := json.Marshal(value)
+				if err != nil {
+					fmt.Printf("json marshal occured error: %s\n", err)
+					os.Exit(1)
+				}
+				fmt.Printf("%s(%s): %s\n", name, method.Outputs[i].Type, string(b))
+			}
+			return nil
+		},
+	}
 
+	broadcastCmd = &cli.Command{
+		Name:			"broadcast",
+		Usage:			"broadcast a signed offline transaction",
+		Description: 	"send an offline transaction signed by wallet sign, in json or base64 payload in string(offlinetx) or file(offlinetxfile). " +
+						"raw is checked against the prepared transaction, its network and nonce before sending",
+		ArgsUsage: 		"<offlinetx> <offlinetxfile> <wait> <timeout>",
+		Flags: []cli.Flag{
+			offlineTxFlag,
+			offlineTxFileFlag,
+			waitFlag,
+			timeoutFlag,
+		},
+		Action: func(c *cli.Context) error {
+			config := loadConfig()
+			wallet := wallet.ImportEmptyEthereumWallet(config)
+			o := loadOfflineTx(c)
+			fmt.Print(o.Summary())
+			txid, err := wallet.BroadcastOfflineTx(o)
+			if err != nil {
+				fmt.Printf("broadcast occured error: %s\n", err)
+				os.Exit(1)
+			}
+			printSentTx(c, config, wallet, txid, uint64(c.Uint("wait")))
+			return nil
+		},
+	}
+	allowanceCmd = &cli.Command{
+		Name:        "allowance",
+		Usage:       "get erc20 allowance an address gave a spender",
+		Description: "get how much erc20 spender can transfer from address, the token is symbol in erc20_list of config.json or its contract address(token), " +
+					 "the allowance of a token not in erc20_list is in its smallest unit",
+		ArgsUsage:   "<address> <symbol> <token> <spender>",
+		Flags: []cli.Flag{
+			addressFlag,
+			optionalSymbolFlag,
+			tokenFlag,
+			spenderFlag,
+		},
+		Action: func(c *cli.Context) error {
+			config := loadConfig()
+			resolver := newAddressResolver(config)
+			token := getErc20Token(c, config, resolver)
+			spender := getAddressFlag(c, resolver, "spender")
+			wallet := wallet.ImportLookupEthereumWallet(getAddress(c, config, resolver), config)
+			allowance, err := wallet.GetAllowance(token, &spender)
+			if err != nil {
+				fmt.Printf("get allowance occured error: %s\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("%s %s\n", token.FormatAllowance(allowance), token.Symbol)
+			return nil
+		},
+	}
+	approvalsCmd = &cli.Command{
+		Name:        "approvals",
+		Usage:       "list live erc20 allowances of an address",
+		Description: "scan Approval logs of address since fromblock and list every allowance which is not 0 yet. with revoke, address is keyfile's " +
+					 "address and you are asked to revoke each allowance.",
+		ArgsUsage:   "<address> <fromblock> <revoke> <keyfile> <gasprice> <maxfee> <tip> <gaslimit>",
+		Flags: []cli.Flag{
+			addressFlag,
+			fromBlockFlag,
+			revokeFlag,
+			keyfileFlag,
+			gaspriceFlag,
+			maxfeeFlag,
+			tipFlag,
+			gaslimitFlag,
+		},
+		Action: func(c *cli.Context) error {
+			config := loadConfig()
+			var ew *wallet.EthereumWallet
+			if c.Bool("revoke") {
+				ew = unlockEthereumWallet(c, config)
+			} else {
+				ew = getLookupEthereumWallet(c)
+			}
+			approvals, err := ew.GetApprovals(c.Uint64("fromblock"))
+			if err != nil {
+				fmt.Printf("get approvals occured error: %s\n", err)
+				os.Exit(1)
+			}
+			if len(approvals) == 0 {
+				fmt.Println("no live allowance is found")
+				return nil
+			}
+			for i, approval := range approvals {
+				token := approval.Token
+				allowance := (*big.Int)(approval.Allowance)
+				fmt.Printf("%d. token: %s %s\n", i+1, token.Symbol, token.Address.String())
+				fmt.Printf("   spender: %s\n", approval.Spender.String())
+				fmt.Printf("   allowance: %s\n", token.FormatAllowance(allowance))
+				fmt.Printf("   last approval: block %d, tx %s\n", approval.BlockNumber, approval.TransactionHash)
+				if !c.Bool("revoke") || !confirm("   revoke it?") {
+					continue
+				}
+				txid, err := ew.ApproveErc20(token, &approval.Spender, big.NewInt(0), getBigIntFlag(c, "gasprice"), getBigIntFlag(c, "maxfee"), getBigIntFlag(c, "tip"), getGasLimitFlag(c))
+				if err != nil {
+					fmt.Printf("   revoke allowance occured error: %s\n", err)
+					continue
+				}
+				fmt.Printf("   revoke txid: %s\n", txid)
+			}
+			return nil
+		},
+	}
+	nftsCmd = &cli.Command{
+		Name:        "nfts",
+		Usage:       "list erc721 and erc1155 tokens of an address",
+		Description: "scan erc721 Transfer and erc1155 TransferSingle, TransferBatch logs to address since fromblock and list tokens " +
+					 "address still owns with their uri.",
+		ArgsUsage:   "<address> <fromblock>",
+		Flags: []cli.Flag{
+			addressFlag,
+			fromBlockFlag,
+		},
+		Action: func(c *cli.Context) error {
+			ew := getLookupEthereumWallet(c)
+			nfts, err := ew.GetNfts(c.Uint64("fromblock"))
+			if err != nil {
+				fmt.Printf("get nfts occured error: %s\n", err)
+				os.Exit(1)
+			}
+			if len(nfts) == 0 {
+				fmt.Println("no nft is found")
+				return nil
+			}
+			for _, nft := range nfts {
+				str, err := nft.String()
+				if err != nil {
+					fmt.Printf("nft transfer to string occured error: %s\n", err)
+					os.Exit(1)
+				}
+				fmt.Print(str)
+			}
+			return nil
+		},
+	}
+	balancesCmd = &cli.Command{
+		Name:        "balances",
+		Usage:       "get balances of many addresses",
+		Description: "get balances of assets of every address in file at block in a few requests, the node is read with batched " +
+					 "requests and multicall. the result is a csv of address and the balance of every asset in file's order.",
+		ArgsUsage:   "<file> <assets> <block> <out>",
+		Flags: []cli.Flag{
+			addressFileFlag,
+			assetsFlag,
+			blockFlag,
+			outFlag,
+		},
+		Action: func(c *cli.Context) error {
+			config := loadConfig()
+			ew := wallet.ImportEmptyEthereumWallet(config)
+			addresses := readAddressFile(c.String("file"), newAddressResolver(config))
+			var assets []string
+			for _, asset := range strings.Split(c.String("assets"), ",") {
+				if asset = strings.TrimSpace(asset); asset != "" {
+					assets = append(assets, asset)
+				}
+			}
+			if len(assets) == 0 {
+				fmt.Println("you need to specify at least one asset")
+				os.Exit(1)
+			}
+			balances, err := ew.GetBalances(addresses, assets, c.String("block"))
+			if err != nil {
+				fmt.Printf("get balances occured error: %s\n", err)
+				os.Exit(1)
+			}
+			var b bytes.Buffer
+			writer := csv.NewWriter(&b)
+			writer.Write(append([]string{"address"}, assets...))
+			for _, address := range addresses {
+				record := []string{address.String()}
+				for _, asset := range assets {
+					record = append(record, balances[address.String()][asset].String())
+				}
+				writer.Write(record)
+			}
+			writer.Flush()
+			if c.String("out") == "" {
+				fmt.Print(b.String())
+				return nil
+			}
+			err = ioutil.WriteFile(c.String("out"), b.Bytes(), 0644)
+			if err != nil {
+				fmt.Printf("write balances occured error: %s\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("balances of %d addresses are written to %s\n", len(addresses), c.String("out"))
+			return nil
+		},
+	}
+	ensCmd = &cli.Command{
+		Name:        "ens",
+		Usage:       "resolve an ens name or look up an address's primary name",
+		Description: "with name, resolve it to an address through its resolver. with address, find its primary name by reverse resolution, " +
+					 "the name is only shown when it resolves back to the address.",
+		ArgsUsage:   "<name> <address>",
+		Flags: []cli.Flag{
+			ensNameFlag,
+			addressFlag,
+		},
+		Action: func(c *cli.Context) error {
+			config := loadConfig()
+			resolver := newAddressResolver(config)
+			ew := wallet.ImportEmptyEthereumWallet(config)
+			if c.String("name") != "" {
+				address, err := ew.ResolveEnsName(c.String("name"))
+				if err != nil {
+					fmt.Printf("resolve ens name occured error: %s\n", err)
+					os.Exit(1)
+				}
+				fmt.Println(address.String())
+				return nil
+			}
+			if c.String("address") == "" {
+				fmt.Println("you need to specify --name or --address")
+				os.Exit(1)
+			}
+			name, err := ew.LookupEnsAddress(getAddressFlag(c, resolver, "address"))
+			if err != nil {
+				fmt.Printf("lookup ens name occured error: %s\n", err)
+				os.Exit(1)
+			}
+			if name == "" {
+				fmt.Println("address has no primary name")
+				return nil
+			}
+			fmt.Println(name)
+			return nil
+		},
+	}
+	watchCmd = &cli.Command{
+		Name:			"watch",
+		Usage:			"watch new heads, logs or pending transactions from node",
+		Description: 	"subscribe new heads, logs or pending transaction hashes through node's websocket and print them in json lines until ctrl-c, " +
+						"you need to set ws_url of the network in config.json. logs can be filtered by contracts, event and topics",
+		ArgsUsage: 		"<type> <contracts> <event> <topics>",
+		Flags: []cli.Flag{
+			watchTypeFlag,
+			contractsFlag,
+			eventFlag,
+			topicsFlag,
+			networkFlag,
+		},
+		Action: func(c *cli.Context) error {
+			config := loadConfig()
+			resolver := newAddressResolver(config)
+			network := getNetwork(c, config)
+			networkUrl, err := config.GetNetworkUrl(network.Name)
+			if err != nil {
+				fmt.Printf("%s\n", err)
+				os.Exit(1)
+			}
+			client, err := ethclient.DialWebsocket(networkUrl.WsUrl)
+			if err != nil {
+				fmt.Printf("connect websocket occured error: %s\n", err)
+				os.Exit(1)
+			}
+			defer client.Close()
+			events := make(chan interface{})
+			var sub *ethclient.Subscription
+			switch c.String("type") {
+			case "heads":
+				var heads <-chan types.NodeBlock
+				heads, sub, err = client.SubscribeNewHeads()
+				go func() {
+					for head := range heads {
+						events <- head
+					}
+					close(events)
+				}()
+			case "logs":
+				var logs <-chan types.NodeLog
+				logs, sub, err = client.SubscribeLogs(loadLogFilter(c, resolver))
+				go func() {
+					for log := range logs {
+						events <- log
+					}
+					close(events)
+				}()
+			case "pending":
+				var hashes <-chan string
+				hashes, sub, err = client.SubscribePendingTransactions()
+				go func() {
+					for hash := range hashes {
+						events <- hash
+					}
+					close(events)
+				}()
+			default:
+				fmt.Printf("type must be heads, logs or pending\n")
+				os.Exit(1)
+			}
+			if err != nil {
+				fmt.Printf("subscribe occured error: %s\n", err)
+				os.Exit(1)
+			}
+			interrupt := make(chan os.Signal, 1)
+			signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+			for {
+				select {
+				case event, ok := <-events:
+					if !ok {
+						return nil
+					}
+					b, err := json.Marshal(event)
+					if err != nil {
+						fmt.Printf("json marshal occured error: %s\n", err)
+						os.Exit(1)
+					}
+					fmt.Println(string(b))
+				case err := <-sub.Err():
+					fmt.Printf("%s\n", err)
+				case <-interrupt:
+					sub.Unsubscribe()
+					return nil
+				}
+			}
+		},
+	}
 
 	NodeCommand = &cli.Command{
 		Name:	"node",
@@ -234,6 +630,14 @@ var (
 			gaspriceCmd,
 			gaslimitCmd,
 			sendrawtxCmd,
+			callCmd,
+			broadcastCmd,
+			allowanceCmd,
+			approvalsCmd,
+			nftsCmd,
+			ensCmd,
+			balancesCmd,
+			watchCmd,
 		},
 	}
 )
@@ -2,130 +2,458 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/tn606024/ethwallet/types"
 	"github.com/tn606024/ethwallet/utils"
+	"github.com/tn606024/ethwallet/wallet"
 	"github.com/urfave/cli/v2"
 	"math/big"
 	"os"
-	"strconv"
+	"strings"
 )
 
 var(
 	sendetherSubcommand = &cli.Command{
 		Name:		 "sendether",
 		Usage: 		 "send ether to other address",
-		Description: "send ether to other address, you must set keyfile, to, value(wei), gasprice and gaslimit is optional, if you don't set, " +
-					 "system will auto calculate suitable value.",
-		ArgsUsage: 	 "<keyfile> <to> <value> <gasprice> <gaslimit>",
+		Description: "send ether to other address, you must set keyfile, to, value(wei, or with a unit like 1.5ether), gasprice, maxfee, tip and gaslimit is optional, if you don't set, " +
+					 "system will auto calculate suitable value. if you set gasprice, a legacy transaction is sent, otherwise an eip1559 transaction is sent " +
+					 "when the node reports a base fee. set wait to wait for n confirmations and show the result.",
+		ArgsUsage: 	 "<keyfile> <to> <value> <gasprice> <maxfee> <tip> <gaslimit> <wait> <timeout>",
 		Flags: []cli.Flag{
 			keyfileFlag,
 			toFlag,
 			valueFlag,
 			gaspriceFlag,
+			maxfeeFlag,
+			tipFlag,
 			gaslimitFlag,
+			waitFlag,
+			timeoutFlag,
 		},
 		Action: func(c *cli.Context) error {
-			var err error
-			gasprice := big.NewInt(0)
-			gaslimit := uint64(0)
 			config := loadConfig()
+			resolver := newAddressResolver(config)
 			wallet := unlockEthereumWallet(c, config)
 			sto := c.String("to")
-			svalue := c.String("value")
-			sgasprice := c.String("gasprice")
-			sgaslimit := c.String("gaslimit")
-			to := utils.HexToAddress(sto)
-			value, ok := new(big.Int).SetString(svalue, 10)
-			if !ok {
-				fmt.Printf("valie transfer to int occured error\n")
-				os.Exit(1)
-			}
-			if sgasprice != ""{
-				gasprice, ok = new(big.Int).SetString(sgasprice, 10)
-				if !ok {
-					fmt.Printf("gasprice transfer to int occured error\n")
-					os.Exit(1)
-
-				}
-			}
-			if sgaslimit != ""{
-				gaslimit, err = strconv.ParseUint(sgaslimit, 10, 64)
-				if err != nil {
-					fmt.Printf("gaslimt transfer to int occured error: %s\n", sgaslimit)
-					os.Exit(1)
-				}
-			}
-			txid, err:= wallet.TransferEther(&to, value,[]byte{}, gasprice, gaslimit)
+			to := resolver.resolve("to", sto)
+			value := getBigIntFlag(c, "value")
+			gasprice := getBigIntFlag(c, "gasprice")
+			gaslimit := getGasLimitFlag(c)
+			maxfee := getBigIntFlag(c, "maxfee")
+			tip := getBigIntFlag(c, "tip")
+			txid, err:= wallet.TransferEther(&to, value,[]byte{}, gasprice, maxfee, tip, gaslimit)
 			if err != nil{
 				fmt.Printf("transfer ether occured error: %s\n", err)
 				os.Exit(1)
 			}
 			fmt.Printf("transaction send success")
-			fmt.Printf("txid: %s\n",txid)
-			fmt.Printf("you can check tx on ethersacn: %s\n", ConstructEtherscanUrl(*config.Network, txid))
+			printSentTx(c, config, wallet, txid, uint64(c.Uint("wait")))
 			return nil
 		},
 	}
 	sendErc20Subcommand = &cli.Command{
 		Name:		 "senderc20",
 		Usage: 		 "send erc20token to other address",
-		Description: "send erc20token to other address, you must set keyfile, symbol(you set in erc20_list.json in config.json), to, value(wei), gasprice and gaslimit is optional," +
-			   		 "if you don't set, system will auto calculate suitable value.",
-		ArgsUsage: 	 "<keyfile> <symbol> <to> <value> <gasprice> <gaslimit>",
+		Description: "send erc20token to other address, you must set keyfile, symbol(you set in erc20_list.json or token_lists in config.json) or token(its address, needed if " +
+					 "several tokens have the symbol), to, value(in token's unit, e.g. 0.5), gasprice, maxfee, tip and gaslimit is optional," +
+			   		 "if you don't set, system will auto calculate suitable value. set wait to wait for n confirmations and show the result.",
+		ArgsUsage: 	 "<keyfile> <symbol> <token> <to> <value> <gasprice> <maxfee> <tip> <gaslimit> <wait> <timeout>",
 		Flags: []cli.Flag{
 			keyfileFlag,
-			symbolFlag,
+			optionalSymbolFlag,
+			tokenFlag,
 			toFlag,
 			valueFlag,
 			gaspriceFlag,
+			maxfeeFlag,
+			tipFlag,
 			gaslimitFlag,
+			waitFlag,
+			timeoutFlag,
 		},
 		Action: func(c *cli.Context) error {
-			var err error
 			config := loadConfig()
+			resolver := newAddressResolver(config)
 			wallet := unlockEthereumWallet(c, config)
-			gasprice := big.NewInt(0)
-			gaslimit := uint64(0)
-			symbol := c.String("symbol")
-			token, ok := UseSymbolFindErc20Token(config.Erc20List, symbol)
-			if !ok {
-				fmt.Printf("erc20 symbol %s can't find\n", symbol)
+			token := getListedErc20Token(c, config, resolver)
+			sto := c.String("to")
+			to := resolver.resolve("to", sto)
+			value := getTokenAmountFlag(c, "value", token)
+			gasprice := getBigIntFlag(c, "gasprice")
+			gaslimit := getGasLimitFlag(c)
+			maxfee := getBigIntFlag(c, "maxfee")
+			tip := getBigIntFlag(c, "tip")
+			txid, err := wallet.TransferErc20(token, value,&to, gasprice, maxfee, tip, gaslimit)
+			if err != nil {
+				fmt.Printf("transferErc20 occured error: %s\n", err)
 				os.Exit(1)
 			}
-			sto := c.String("to")
-			to := utils.HexToAddress(sto)
-			svalue := c.String("value")
-			sgasprice := c.String("gasprice")
-			sgaslimit := c.String("gaslimit")
-			value, ok := new(big.Int).SetString(svalue, 10)
-			if !ok {
-				fmt.Printf("value transfer to int occured error\n")
+			printSentTx(c, config, wallet, txid, uint64(c.Uint("wait")))
+			return nil
+		},
+	}
+	deploySubcommand = &cli.Command{
+		Name:		 "deploy",
+		Usage: 		 "deploy a contract",
+		Description: "deploy a contract, you must set keyfile, bytecode(hex string) or bytecodefile, constructorargs(abi encoded hex) and value(wei) is optional, " +
+					 "constructor args can also be given as json array(args) with abi, " +
+					 "the contract address is predicted from your address and nonce, then the command waits for the receipt(or wait confirmations) to confirm it.",
+		ArgsUsage: 	 "<keyfile> <bytecode> <bytecodefile> <constructorargs> <abi> <args> <value> <gasprice> <maxfee> <tip> <gaslimit> <wait> <timeout>",
+		Flags: []cli.Flag{
+			keyfileFlag,
+			bytecodeFlag,
+			bytecodeFileFlag,
+			constructorArgsFlag,
+			abiFlag,
+			argsFlag,
+			optionalValueFlag,
+			gaspriceFlag,
+			maxfeeFlag,
+			tipFlag,
+			gaslimitFlag,
+			waitFlag,
+			timeoutFlag,
+		},
+		Action: func(c *cli.Context) error {
+			config := loadConfig()
+			wallet := unlockEthereumWallet(c, config)
+			bytecode := utils.HexStrToBytes(strings.TrimSpace(string(loadStringOrFilePath(c, "bytecode", "bytecodefile"))))
+			args := utils.HexStrToBytes(c.String("constructorargs"))
+			if c.String("abi") != "" {
+				args = packConstructorArgs(c)
+			}
+			value := getBigIntFlag(c, "value")
+			if value == nil {
+				value = big.NewInt(0)
+			}
+			txid, contract, err := wallet.DeployContract(bytecode, args, value, getBigIntFlag(c, "gasprice"), getBigIntFlag(c, "maxfee"), getBigIntFlag(c, "tip"), getGasLimitFlag(c))
+			if err != nil {
+				fmt.Printf("deploy contract occured error: %s\n", err)
 				os.Exit(1)
 			}
-			if sgasprice != ""{
-				gasprice, ok = new(big.Int).SetString(sgasprice, 10)
-				if !ok {
-					fmt.Printf("gasprice transfer to int occured error\n")
-					os.Exit(1)
-
-				}
+			fmt.Printf("predicted contract address: %s\n", contract.String())
+			confirmations := uint64(c.Uint("wait"))
+			if confirmations == 0 {
+				confirmations = 1
+			}
+			receipt := printSentTx(c, config, wallet, txid, confirmations)
+			fmt.Printf("contract deployed at %s in block %d\n", receipt.ContractAddress, receipt.BlockNumber)
+			if !strings.EqualFold(receipt.ContractAddress, contract.String()) {
+				fmt.Printf("warning: deployed address is different from predicted address\n")
+			}
+			return nil
+		},
+	}
+	execSubcommand = &cli.Command{
+		Name:		 "exec",
+		Usage: 		 "send a transaction calling a contract method",
+		Description: "send a transaction calling a contract method encoded by json abi, you must set keyfile, contract, abi and method, args is a json array of " +
+					 "human readable values, value(wei) is only allowed for payable method, gasprice, maxfee, tip and gaslimit is optional. " +
+					 "set wait to wait for n confirmations and show the result.",
+		ArgsUsage: 	 "<keyfile> <contract> <abi> <method> <args> <value> <gasprice> <maxfee> <tip> <gaslimit> <wait> <timeout>",
+		Flags: []cli.Flag{
+			keyfileFlag,
+			contractFlag,
+			abiFlag,
+			methodFlag,
+			argsFlag,
+			optionalValueFlag,
+			gaspriceFlag,
+			maxfeeFlag,
+			tipFlag,
+			gaslimitFlag,
+			waitFlag,
+			timeoutFlag,
+		},
+		Action: func(c *cli.Context) error {
+			config := loadConfig()
+			resolver := newAddressResolver(config)
+			method, args := loadAbiMethod(c)
+			contract := getAddressFlag(c, resolver, "contract")
+			value := getBigIntFlag(c, "value")
+			if value == nil {
+				value = big.NewInt(0)
+			}
+			gaslimit := getGasLimitFlag(c)
+			wallet := unlockEthereumWallet(c, config)
+			txid, err := wallet.ExecContract(&contract, method, args, value, getBigIntFlag(c, "gasprice"), getBigIntFlag(c, "maxfee"), getBigIntFlag(c, "tip"), gaslimit)
+			if err != nil {
+				fmt.Printf("exec contract occured error: %s\n", err)
+				os.Exit(1)
+			}
+			printSentTx(c, config, wallet, txid, uint64(c.Uint("wait")))
+			return nil
+		},
+	}
+	prepareSubcommand = &cli.Command{
+		Name:		 "prepare",
+		Usage: 		 "prepare a transaction for offline signing",
+		Description: "fill nonce, fees and gas limit of a transaction from node without keyfile and output the offline tx, sign it with wallet sign " +
+					 "on the offline machine and send it with node broadcast. address(from) is optional if address is set in config.json, value is wei, " +
+					 "or token's unit when symbol or token(its address) is set.",
+		ArgsUsage: 	 "<address> <to> <value> <symbol> <token> <gasprice> <maxfee> <tip> <gaslimit> <out> <base64>",
+		Flags: []cli.Flag{
+			addressFlag,
+			toFlag,
+			valueFlag,
+			optionalSymbolFlag,
+			tokenFlag,
+			gaspriceFlag,
+			maxfeeFlag,
+			tipFlag,
+			gaslimitFlag,
+			outFlag,
+			base64Flag,
+		},
+		Action: func(c *cli.Context) error {
+			var token *types.Erc20Token
+			config := loadConfig()
+			resolver := newAddressResolver(config)
+			wallet := wallet.ImportLookupEthereumWallet(getAddress(c, config, resolver), config)
+			if c.String("symbol") != "" || c.String("token") != "" {
+				token = getListedErc20Token(c, config, resolver)
+			}
+			to := getAddressFlag(c, resolver, "to")
+			var value *big.Int
+			if token != nil {
+				value = getTokenAmountFlag(c, "value", token)
+			} else {
+				value = getBigIntFlag(c, "value")
+			}
+			o, err := wallet.PrepareTransaction(&to, value, token, getBigIntFlag(c, "gasprice"), getBigIntFlag(c, "maxfee"), getBigIntFlag(c, "tip"), getGasLimitFlag(c))
+			if err != nil {
+				fmt.Printf("prepare transaction occured error: %s\n", err)
+				os.Exit(1)
+			}
+			if c.String("out") != "" {
+				fmt.Print(o.Summary())
 			}
-			if sgaslimit != ""{
-				gaslimit, err = strconv.ParseUint(sgaslimit, 10, 64)
-				if err != nil {
-					fmt.Printf("gaslimt transfer to int occured error: %s\n", err)
-					os.Exit(1)
+			writeOfflineTx(c, o)
+			return nil
+		},
+	}
+	batchSubcommand = &cli.Command{
+		Name:		 "batch",
+		Usage: 		 "send ether and erc20 payouts from a csv file",
+		Description: "send payouts from a csv file of recipient, symbol and amount(in ether or token's unit, e.g. 1.5). all lines and the total balance " +
+					 "are checked before anything is sent, transactions get sequential nonces and are sent concurrently. txids and statuses are written " +
+					 "to the results csv, run it again with the same csv to resume, sent payouts are skipped and failed ones are sent again.",
+		ArgsUsage: 	 "<keyfile> <csv> <results> <concurrency> <gasprice> <maxfee> <tip>",
+		Flags: []cli.Flag{
+			keyfileFlag,
+			csvFlag,
+			resultsFlag,
+			concurrencyFlag,
+			gaspriceFlag,
+			maxfeeFlag,
+			tipFlag,
+		},
+		Action: func(c *cli.Context) error {
+			config := loadConfig()
+			payouts, results := loadPayouts(c, config)
+			wallet := unlockEthereumWallet(c, config)
+			save := func() error {
+				return savePayoutResults(results, payouts)
+			}
+			err := wallet.SendPayouts(payouts, int(c.Uint("concurrency")), getBigIntFlag(c, "gasprice"), getBigIntFlag(c, "maxfee"), getBigIntFlag(c, "tip"), save)
+			if err != nil {
+				fmt.Printf("send payouts occured error: %s\n", err)
+				os.Exit(1)
+			}
+			failed := 0
+			for _, p := range payouts {
+				if p.Status == types.PayoutFailed {
+					failed++
+					fmt.Printf("line %d to %s failed: %s\n", p.Line, p.Recipient.String(), p.Error)
 				}
 			}
-			txid, err := wallet.TransferErc20(token, value,&to, gasprice, gaslimit)
+			fmt.Printf("%d payouts sent, %d failed, results are written to %s\n", len(payouts)-failed, failed, results)
+			if failed > 0 {
+				fmt.Printf("run the same command again to resend failed payouts\n")
+				os.Exit(1)
+			}
+			return nil
+		},
+	}
+	approveSubcommand = &cli.Command{
+		Name:		 "approve",
+		Usage: 		 "approve a spender to transfer your erc20",
+		Description: "let spender transfer value(in token's unit, or unlimited) of erc20 from your address, symbol is set in erc20_list or token_lists " +
+					 "of config.json, token(its address) is needed if several tokens have the symbol. some tokens need the allowance set to 0 before changing it to another value.",
+		ArgsUsage: 	 "<keyfile> <symbol> <token> <spender> <value> <gasprice> <maxfee> <tip> <gaslimit> <wait> <timeout>",
+		Flags: []cli.Flag{
+			keyfileFlag,
+			optionalSymbolFlag,
+			tokenFlag,
+			spenderFlag,
+			approveValueFlag,
+			gaspriceFlag,
+			maxfeeFlag,
+			tipFlag,
+			gaslimitFlag,
+			waitFlag,
+			timeoutFlag,
+		},
+		Action: func(c *cli.Context) error {
+			config := loadConfig()
+			resolver := newAddressResolver(config)
+			token := getListedErc20Token(c, config, resolver)
+			spender := getAddressFlag(c, resolver, "spender")
+			value := getApproveValue(c, token)
+			wallet := unlockEthereumWallet(c, config)
+			txid, err := wallet.ApproveErc20(token, &spender, value, getBigIntFlag(c, "gasprice"), getBigIntFlag(c, "maxfee"), getBigIntFlag(c, "tip"), getGasLimitFlag(c))
 			if err != nil {
-				fmt.Printf("transferErc20 occured error: %s\n", err)
+				fmt.Printf("approve erc20 occured error: %s\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("approve %s %s to %s\n", token.FormatAllowance(value), token.Symbol, spender.String())
+			printSentTx(c, config, wallet, txid, uint64(c.Uint("wait")))
+			return nil
+		},
+	}
+	revokeSubcommand = &cli.Command{
+		Name:		 "revoke",
+		Usage: 		 "revoke a spender's allowance of your erc20",
+		Description: "set the allowance of spender to 0, the token is symbol in erc20_list of config.json or its contract address(token).",
+		ArgsUsage: 	 "<keyfile> <symbol> <token> <spender> <gasprice> <maxfee> <tip> <gaslimit> <wait> <timeout>",
+		Flags: []cli.Flag{
+			keyfileFlag,
+			optionalSymbolFlag,
+			tokenFlag,
+			spenderFlag,
+			gaspriceFlag,
+			maxfeeFlag,
+			tipFlag,
+			gaslimitFlag,
+			waitFlag,
+			timeoutFlag,
+		},
+		Action: func(c *cli.Context) error {
+			config := loadConfig()
+			resolver := newAddressResolver(config)
+			token := getErc20Token(c, config, resolver)
+			spender := getAddressFlag(c, resolver, "spender")
+			wallet := unlockEthereumWallet(c, config)
+			txid, err := wallet.ApproveErc20(token, &spender, big.NewInt(0), getBigIntFlag(c, "gasprice"), getBigIntFlag(c, "maxfee"), getBigIntFlag(c, "tip"), getGasLimitFlag(c))
+			if err != nil {
+				fmt.Printf("revoke allowance occured error: %s\n", err)
 				os.Exit(1)
 			}
-			fmt.Printf("txid: %s\n",txid)
-			fmt.Printf("you can check tx on ethersacn: %s\n", ConstructEtherscanUrl(*config.Network, txid))
+			printSentTx(c, config, wallet, txid, uint64(c.Uint("wait")))
 			return nil
 		},
 	}
+	transferFromSubcommand = &cli.Command{
+		Name:		 "transferfrom",
+		Usage: 		 "transfer erc20 from an address which approved you",
+		Description: "transfer value(in token's unit) of erc20 from from to to with the allowance from gave your address, symbol is set in erc20_list " +
+					 "or token_lists of config.json, token(its address) is needed if several tokens have the symbol.",
+		ArgsUsage: 	 "<keyfile> <symbol> <token> <from> <to> <value> <gasprice> <maxfee> <tip> <gaslimit> <wait> <timeout>",
+		Flags: []cli.Flag{
+			keyfileFlag,
+			optionalSymbolFlag,
+			tokenFlag,
+			fromFlag,
+			toFlag,
+			valueFlag,
+			gaspriceFlag,
+			maxfeeFlag,
+			tipFlag,
+			gaslimitFlag,
+			waitFlag,
+			timeoutFlag,
+		},
+		Action: func(c *cli.Context) error {
+			config := loadConfig()
+			resolver := newAddressResolver(config)
+			token := getListedErc20Token(c, config, resolver)
+			from := getAddressFlag(c, resolver, "from")
+			to := getAddressFlag(c, resolver, "to")
+			value := getTokenAmountFlag(c, "value", token)
+			wallet := unlockEthereumWallet(c, config)
+			txid, err := wallet.TransferFromErc20(token, &from, &to, value, getBigIntFlag(c, "gasprice"), getBigIntFlag(c, "maxfee"), getBigIntFlag(c, "tip"), getGasLimitFlag(c))
+			if err != nil {
+				fmt.Printf("transferFrom erc20 occured error: %s\n", err)
+				os.Exit(1)
+			}
+			printSentTx(c, config, wallet, txid, uint64(c.Uint("wait")))
+			return nil
+		},
+	}
+	sendNftSubcommand = &cli.Command{
+		Name:		 "sendnft",
+		Usage: 		 "send erc721 or erc1155 tokens",
+		Description: "send tokenid of contract to to with safeTransferFrom, the standard is detected by supportsInterface. erc721 sends one " +
+					 "tokenid, erc1155 sends amount of each tokenid and several tokenids are sent in one safeBatchTransferFrom.",
+		ArgsUsage: 	 "<keyfile> <contract> <to> <tokenid> <amount> <gasprice> <maxfee> <tip> <gaslimit> <wait> <timeout>",
+		Flags: []cli.Flag{
+			keyfileFlag,
+			contractFlag,
+			toFlag,
+			tokenIdFlag,
+			nftAmountFlag,
+			gaspriceFlag,
+			maxfeeFlag,
+			tipFlag,
+			gaslimitFlag,
+			waitFlag,
+			timeoutFlag,
+		},
+		Action: func(c *cli.Context) error {
+			config := loadConfig()
+			resolver := newAddressResolver(config)
+			contract := getAddressFlag(c, resolver, "contract")
+			to := getAddressFlag(c, resolver, "to")
+			tokenIds := getBigIntListFlag(c, "tokenid")
+			amounts := getBigIntListFlag(c, "amount")
+			wallet := unlockEthereumWallet(c, config)
+			txid, err := wallet.SendNft(&contract, &to, tokenIds, amounts, getBigIntFlag(c, "gasprice"), getBigIntFlag(c, "maxfee"), getBigIntFlag(c, "tip"), getGasLimitFlag(c))
+			if err != nil {
+				fmt.Printf("send nft occured error: %s\n", err)
+				os.Exit(1)
+			}
+			printSentTx(c, config, wallet, txid, uint64(c.Uint("wait")))
+			return nil
+		},
+	}
+	speedupSubcommand = &cli.Command{
+		Name:		 "speedup",
+		Usage: 		 "speed up a pending transaction",
+		Description: "send a pending transaction again with the same nonce and fees bumped by at least 10%, which nodes need to replace it. " +
+					 "gasprice(legacy) or maxfee and tip(eip1559) is optional, by default the bigger one of the bumped fee and node's suggestion is used.",
+		ArgsUsage: 	 "<keyfile> <txid> <gasprice> <maxfee> <tip> <wait> <timeout>",
+		Flags: []cli.Flag{
+			keyfileFlag,
+			txidFlag,
+			gaspriceFlag,
+			maxfeeFlag,
+			tipFlag,
+			waitFlag,
+			timeoutFlag,
+		},
+		Action: func(c *cli.Context) error {
+			return replaceTransaction(c, false)
+		},
+	}
+	cancelSubcommand = &cli.Command{
+		Name:		 "cancel",
+		Usage: 		 "cancel a pending transaction",
+		Description: "replace a pending transaction with a 0 ether transfer to yourself which has the same nonce and fees bumped by at least 10%. " +
+					 "gasprice(legacy) or maxfee and tip(eip1559) is optional, by default the bigger one of the bumped fee and node's suggestion is used.",
+		ArgsUsage: 	 "<keyfile> <txid> <gasprice> <maxfee> <tip> <wait> <timeout>",
+		Flags: []cli.Flag{
+			keyfileFlag,
+			txidFlag,
+			gaspriceFlag,
+			maxfeeFlag,
+			tipFlag,
+			waitFlag,
+			timeoutFlag,
+		},
+		Action: func(c *cli.Context) error {
+			return replaceTransaction(c, true)
+		},
+	}
 	NodewalletCommand = &cli.Command{
 		Name:	"nodewallet",
 		Usage:	"Ethereum nodewallet commands",
@@ -136,9 +464,45 @@ var(
 		Subcommands: []*cli.Command{
 			sendetherSubcommand,
 			sendErc20Subcommand,
+			deploySubcommand,
+			execSubcommand,
+			prepareSubcommand,
+			batchSubcommand,
+			approveSubcommand,
+			revokeSubcommand,
+			transferFromSubcommand,
+			sendNftSubcommand,
+			speedupSubcommand,
+			cancelSubcommand,
 		},
 	}
 )
 
 
 
+
+func replaceTransaction(c *cli.Context, cancel bool) error {
+	config := loadConfig()
+	wallet := unlockEthereumWallet(c, config)
+	var txid string
+	var tx *types.Transaction
+	var err error
+	if cancel {
+		txid, tx, err = wallet.CancelTransaction(c.String("txid"), getBigIntFlag(c, "gasprice"), getBigIntFlag(c, "maxfee"), getBigIntFlag(c, "tip"))
+	} else {
+		txid, tx, err = wallet.SpeedUpTransaction(c.String("txid"), getBigIntFlag(c, "gasprice"), getBigIntFlag(c, "maxfee"), getBigIntFlag(c, "tip"))
+	}
+	if err != nil {
+		fmt.Printf("replace transaction occured error: %s\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("nonce: %d\n", tx.Nonce)
+	if tx.Type == types.DynamicFeeTxType {
+		fmt.Printf("maxfee(wei): %s\n", tx.MaxFeePerGas.String())
+		fmt.Printf("tip(wei): %s\n", tx.MaxPriorityFeePerGas.String())
+	} else {
+		fmt.Printf("gasprice(wei): %s\n", tx.GasPrice.String())
+	}
+	printSentTx(c, config, wallet, txid, uint64(c.Uint("wait")))
+	return nil
+}
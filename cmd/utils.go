@@ -1,18 +1,26 @@
 package cmd
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/abi"
+	"github.com/tn606024/ethwallet/crypto"
 	"github.com/tn606024/ethwallet/types"
 	"github.com/tn606024/ethwallet/utils"
 	"github.com/tn606024/ethwallet/wallet"
 	"github.com/urfave/cli/v2"
 	"golang.org/x/crypto/ssh/terminal"
 	"io/ioutil"
-	"math"
 	"math/big"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 )
 
 func unlockEthereumWallet(c *cli.Context, config types.Config) *wallet.EthereumWallet {
@@ -29,7 +37,7 @@ func promptPassphrase(confirmation bool) string {
 	fmt.Println("please input password:")
 	bytePassword, err := terminal.ReadPassword(int(syscall.Stdin))
 	if err != nil {
-		fmt.Println("Failed to read password: %v\n", err)
+		fmt.Printf("Failed to read password: %v\n", err)
 	}
 	passphrase := string(bytePassword)
 
@@ -37,7 +45,7 @@ func promptPassphrase(confirmation bool) string {
 		fmt.Println("please input password again:")
 		bytePassword, err := terminal.ReadPassword(int(syscall.Stdin))
 		if err != nil {
-			fmt.Println("Failed to read password: %v\n", err)
+			fmt.Printf("Failed to read password: %v\n", err)
 		}
 		confirm := string(bytePassword)
 		if passphrase != confirm {
@@ -77,6 +85,128 @@ func getKeyfileAndPassPhrase(c *cli.Context,  config types.Config) (keyfile stri
 	return
 }
 
+func loadTypedData(c *cli.Context) *types.TypedData {
+	b := loadStringOrFilePath(c, "typeddata", "typeddatafile")
+	typedData, err := types.ParseTypedData(b)
+	if err != nil {
+		fmt.Printf("parse typed data occured error: %s\n", err)
+		os.Exit(1)
+	}
+	return typedData
+}
+
+func loadAbiMethod(c *cli.Context) (*abi.Method, []interface{}) {
+	if c.String("abi") == "" {
+		fmt.Printf("need provide abi\n")
+		os.Exit(1)
+	}
+	contract, err := abi.LoadFile(c.String("abi"))
+	if err != nil {
+		fmt.Printf("load abi occured error: %s\n", err)
+		os.Exit(1)
+	}
+	method, err := contract.Method(c.String("method"))
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+	args, err := abi.ParseArgs(c.String("args"))
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+	return method, args
+}
+
+func packConstructorArgs(c *cli.Context) []byte {
+	contract, err := abi.LoadFile(c.String("abi"))
+	if err != nil {
+		fmt.Printf("load abi occured error: %s\n", err)
+		os.Exit(1)
+	}
+	args, err := abi.ParseArgs(c.String("args"))
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+	if contract.Constructor == nil {
+		if len(args) == 0 {
+			return []byte{}
+		}
+		fmt.Printf("abi has no constructor but args is given\n")
+		os.Exit(1)
+	}
+	data, err := contract.Constructor.Pack(args...)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+	return data
+}
+
+// loadLogFilter builds filter from --contracts, --event and --topics, --event takes the first topic
+func loadLogFilter(c *cli.Context, resolver *addressResolver) types.LogFilter {
+	var filter types.LogFilter
+	if c.String("contracts") != "" {
+		for _, address := range strings.Split(c.String("contracts"), ",") {
+			filter.Address = append(filter.Address, resolver.resolve("contract", address))
+		}
+	}
+	if c.String("topics") != "" {
+		var topics []interface{}
+		err := json.Unmarshal([]byte(c.String("topics")), &topics)
+		if err != nil {
+			fmt.Printf("topics must be a json array: %s\n", err)
+			os.Exit(1)
+		}
+		for _, topic := range topics {
+			hashes, err := parseTopic(topic)
+			if err != nil {
+				fmt.Printf("%s\n", err)
+				os.Exit(1)
+			}
+			filter.Topics = append(filter.Topics, hashes)
+		}
+	}
+	if c.String("event") != "" {
+		signature := strings.Join(strings.Fields(c.String("event")), "")
+		topic0 := []common.Hash{common.BytesToHash(crypto.Keccak256([]byte(signature)))}
+		if len(filter.Topics) == 0 {
+			filter.Topics = [][]common.Hash{topic0}
+		} else {
+			filter.Topics[0] = topic0
+		}
+	}
+	return filter
+}
+
+func parseTopic(topic interface{}) ([]common.Hash, error) {
+	switch t := topic.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if !utils.IsHexStr(strings.TrimPrefix(t, "0x")) || len(strings.TrimPrefix(t, "0x")) != 64 {
+			return nil, fmt.Errorf("%s is not a 32 bytes hex topic", t)
+		}
+		return []common.Hash{common.HexToHash(t)}, nil
+	case []interface{}:
+		var hashes []common.Hash
+		for _, item := range t {
+			str, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("topic must be a hex string, got %v", item)
+			}
+			hash, err := parseTopic(str)
+			if err != nil {
+				return nil, err
+			}
+			hashes = append(hashes, hash...)
+		}
+		return hashes, nil
+	}
+	return nil, fmt.Errorf("topic must be null, a hex string or an array, got %v", topic)
+}
+
 func loadStringOrFilePath(c *cli.Context,  inputFlagName string,  inputFilePathFlagName string) []byte{
 	var out []byte
 	var err error
@@ -99,14 +229,59 @@ func loadStringOrFilePath(c *cli.Context,  inputFlagName string,  inputFilePathF
 }
 
 
+// getBigIntFlag returns wei of an ether amount flag or nil if the flag is not set, the amount is in wei
+// unless it has a unit like 30gwei or 1.5ether
+func getBigIntFlag(c *cli.Context, name string) *big.Int {
+	str := c.String(name)
+	if str == "" {
+		return nil
+	}
+	amount, err := types.ParseEtherAmount(str, "wei")
+	if err != nil {
+		fmt.Printf("%s transfer to int occured error: %s\n", name, err)
+		os.Exit(1)
+	}
+	return amount.Value
+}
+
+// getBigIntListFlag returns integers of a comma separated flag, decimal or 0x hex
+func getBigIntListFlag(c *cli.Context, name string) []*big.Int {
+	str := c.String(name)
+	if str == "" {
+		return nil
+	}
+	var list []*big.Int
+	for _, item := range strings.Split(str, ",") {
+		// only decimal or 0x hex, base 0 would read 010 as octal 8
+		item = strings.TrimSpace(item)
+		n, err := utils.ToBigInt(item)
+		if err != nil || n.Sign() < 0 {
+			fmt.Printf("%s %s is not an integer\n", name, item)
+			os.Exit(1)
+		}
+		list = append(list, n)
+	}
+	return list
+}
+
+// getTokenAmountFlag returns token's smallest unit of an amount flag in token's unit like 0.5
+func getTokenAmountFlag(c *cli.Context, name string, token *types.Erc20Token) *big.Int {
+	amount, err := token.ParseAmount(c.String(name))
+	if err != nil {
+		fmt.Printf("%s transfer to %s amount occured error: %s\n", name, token.Symbol, err)
+		os.Exit(1)
+	}
+	return amount.Value
+}
+
 func getLookupEthereumWallet(c *cli.Context) *wallet.EthereumWallet {
 	config := loadConfig()
-	address := getAddress(c, config)
+	address := getAddress(c, config, newAddressResolver(config))
 	wallet := wallet.ImportLookupEthereumWallet(address, config)
 	return wallet
 }
 
-func getAddress(c *cli.Context, config types.Config) (address common.Address){
+func getAddress(c *cli.Context, config types.Config, resolver *addressResolver) (address common.Address){
 	var saddr string
 	if c.String("address") == ""{
 		if config.Address == "" {
@@ -118,10 +293,86 @@ func getAddress(c *cli.Context, config types.Config) (address common.Address){
 	}else {
 		saddr = c.String("address")
 	}
-	address = utils.HexToAddress(saddr)
+	address = resolver.resolve("address", saddr)
 	return
 }
 
+// getAddressFlag reads an address or ens name flag, see addressResolver
+func getAddressFlag(c *cli.Context, resolver *addressResolver, name string) common.Address {
+	return resolver.resolve(name, c.String(name))
+}
+
+// addressResolver reads address arguments of a command, it's made once in the command's Action from its config.
+// A hex address must have a valid checksum, eip1191 of config's chain or eip55, and an ens name like vitalik.eth is
+// resolved by config's server.
+type addressResolver struct {
+	chainId *big.Int
+	ew      *wallet.EthereumWallet
+}
+
+func newAddressResolver(config types.Config) *addressResolver {
+	resolver := &addressResolver{}
+	if config.Network != nil {
+		resolver.chainId = config.Network.ChainId
+	}
+	if config.ServerUrl != "" {
+		resolver.ew = wallet.ImportEmptyEthereumWallet(config)
+	}
+	return resolver
+}
+
+// resolve returns the address of s, the address of an ens name is printed to stderr so it can be checked.
+// Any other string exits instead of becoming some address.
+func (r *addressResolver) resolve(name string, s string) common.Address {
+	s = strings.TrimSpace(s)
+	if types.IsEnsName(s) {
+		if r.ew == nil {
+			fmt.Printf("resolve %s %s needs server_url in config.json\n", name, s)
+			os.Exit(1)
+		}
+		address, err := r.ew.ResolveEnsName(s)
+		if err != nil {
+			fmt.Printf("resolve %s %s occured error: %s\n", name, s, err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "%s %s is %s\n", name, s, address.String())
+		return address
+	}
+	address, err := utils.ParseAddress(s, r.chainId)
+	if err != nil {
+		fmt.Printf("%s is illegal: %s\n", name, err)
+		os.Exit(1)
+	}
+	return address
+}
+
+// readAddressFile reads an address or ens name of every line, duplicates are kept once in the first line's place
+func readAddressFile(path string, resolver *addressResolver) []common.Address {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Printf("read address file occured error: %s\n", err)
+		os.Exit(1)
+	}
+	var addresses []common.Address
+	seen := make(map[common.Address]bool)
+	for i, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		address := resolver.resolve(fmt.Sprintf("address on line %d", i+1), line)
+		if !seen[address] {
+			seen[address] = true
+			addresses = append(addresses, address)
+		}
+	}
+	if len(addresses) == 0 {
+		fmt.Printf("address file %s has no address\n", path)
+		os.Exit(1)
+	}
+	return addresses
+}
+
 func getNetwork(c *cli.Context, config types.Config) (network *types.Network){
 	var snet string
 	var err error
@@ -136,7 +387,7 @@ func getNetwork(c *cli.Context, config types.Config) (network *types.Network){
 		snet = c.String("network")
 		network, err = types.NewNetwork(snet)
 		if err != nil{
-			fmt.Printf("network is not vaild:%s\n", network.Name)
+			fmt.Printf("network is not vaild:%s\n", snet)
 			os.Exit(1)		}
 		}
 	return
@@ -151,15 +402,42 @@ func loadConfig() types.Config {
 	return config
 }
 
+// loadErc20List adds tokens of token_lists to erc20_list, only commands which resolve tokens load them since a
+// token list may be fetched. A token list which can't be loaded is warned and skipped.
+func loadErc20List(config *types.Config) {
+	if err := config.LoadErc20List(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: load token lists occured error, their tokens are skipped: %s\n", err)
+	}
+}
+
+// loadOptionalConfig is loadConfig of commands which work without a config, the config is empty if
+// ETHEREUM_WALLET_CONFIG_PATH isn't set and there is no config.json. A config which can't be read is still an error.
+func loadOptionalConfig() types.Config {
+	if os.Getenv("ETHEREUM_WALLET_CONFIG_PATH") == "" && !utils.FileExists(defaultConfigPath()) {
+		return types.Config{}
+	}
+	path := loadConfigPath()
+	config, err := types.ImportConfig(path)
+	if err != nil {
+		fmt.Printf("import config %s occured error: %s\n", path, err)
+		os.Exit(1)
+	}
+	return config
+}
+
+func defaultConfigPath() string {
+	currentPath, err := os.Getwd()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	return currentPath + "/config.json"
+}
+
 func loadConfigPath() string{
 	path := os.Getenv("ETHEREUM_WALLET_CONFIG_PATH")
 	if path == "" {
-		currentPath, err := os.Getwd()
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
-		}
-		path = currentPath + "/config.json"
+		path = defaultConfigPath()
 		if utils.FileExists(path) == false {
 			fmt.Printf("doesn't set config\n")
 			os.Exit(1)
@@ -168,25 +446,232 @@ func loadConfigPath() string{
 	return path
 }
 
-func UseSymbolFindErc20Token(tokens []*types.Erc20Token, symbol string) (*types.Erc20Token, bool){
-	for _, token := range tokens {
-		if token.Symbol == symbol {
-			return token, true
-		}
+func loadOfflineTx(c *cli.Context) *types.OfflineTx {
+	b := loadStringOrFilePath(c, "offlinetx", "offlinetxfile")
+	o, err := types.ParseOfflineTx(b)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
 	}
-	return nil, false
+	return o
 }
-func ConstructEtherscanUrl(network types.Network, txid string) string{
-	if network.Name == types.EthereumNet.Name {
-		return fmt.Sprintf("https://etherscan.io/tx/%s\n",txid)
-	}else {
+
+// writeOfflineTx writes json or base64 payload to out file or terminal
+func writeOfflineTx(c *cli.Context, o *types.OfflineTx) {
+	var b []byte
+	var err error
+	if c.Bool("base64") {
+		var payload string
+		payload, err = o.Base64()
+		b = []byte(payload + "\n")
+	} else {
+		b, err = o.JSON()
+		b = append(b, '\n')
+	}
+	if err != nil {
+		fmt.Printf("encode offline tx occured error: %s\n", err)
+		os.Exit(1)
+	}
+	if c.String("out") == "" {
+		fmt.Print(string(b))
+		return
+	}
+	err = ioutil.WriteFile(c.String("out"), b, 0644)
+	if err != nil {
+		fmt.Printf("write offline tx occured error: %s\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("offline tx is written to %s\n", c.String("out"))
+}
+
+// waitForConfirmations waits txid for confirmations blocks and prints the result, it exits if the transaction failed
+func waitForConfirmations(c *cli.Context, ew *wallet.EthereumWallet, txid string, confirmations uint64) *types.NodeReceipt {
+	fmt.Printf("waiting for %d confirmations...\n", confirmations)
+	receipt, err := ew.WaitForConfirmations(txid, confirmations, 5*time.Second, c.Duration("timeout"), func(old *types.NodeReceipt) {
+		fmt.Printf("transaction was in block %d(%s) but dropped by a reorg, waiting again...\n", old.BlockNumber, old.BlockHash)
+	})
+	if err != nil {
+		fmt.Printf("wait for confirmations occured error: %s\n", err)
+		os.Exit(1)
+	}
+	status := "success"
+	if receipt.Status != 1 {
+		status = "failed"
+	}
+	fmt.Printf("status: %s\n", status)
+	fmt.Printf("block: %d(%s)\n", receipt.BlockNumber, receipt.BlockHash)
+	fmt.Printf("gas used: %d\n", receipt.GasUsed)
+	if receipt.EffectiveGasPrice != nil {
+		gasPrice := (*big.Int)(receipt.EffectiveGasPrice)
+		fee := new(big.Int).Mul(gasPrice, big.NewInt(int64(receipt.GasUsed)))
+		fmt.Printf("effective gas price(wei): %s\n", gasPrice.String())
+		fmt.Printf("fee(ether): %s\n", types.EtherString(fee))
+	}
+	if receipt.Status != 1 {
+		os.Exit(1)
+	}
+	return receipt
+}
+
+func ConstructEtherscanUrl(config types.Config, txid string) string{
+	network := *config.Network
+	explorerUrl := network.ExplorerUrl
+	networkUrl, err := config.GetNetworkUrl(network.Name)
+	if err == nil && networkUrl.ExplorerUrl != "" {
+		explorerUrl = networkUrl.ExplorerUrl
+	}
+	if explorerUrl == "" {
 		return fmt.Sprintf("https://%s.etherscan.io/tx/%s\n",network.Name,txid)
 	}
+	return fmt.Sprintf("%s/tx/%s\n", strings.TrimRight(explorerUrl, "/"), txid)
 }
 
-func weiToEther(wei *big.Int) string{
-	fwei := new(big.Float)
-	fwei.SetString(wei.String())
-	ethValue := new(big.Float).Quo(fwei, big.NewFloat(math.Pow10(18)))
-	return ethValue.String()
-}
\ No newline at end of file
+// loadPayouts reads the payout csv and the results of a previous run if there is one
+func loadPayouts(c *cli.Context, config types.Config) ([]*types.Payout, string) {
+	f, err := os.Open(c.String("csv"))
+	if err != nil {
+		fmt.Printf("open payout csv occured error: %s\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+	loadErc20List(&config)
+	var chainId *big.Int
+	if config.Network != nil {
+		chainId = config.Network.ChainId
+	}
+	payouts, err := types.ReadPayoutCsv(f, config.Erc20List, chainId)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+	results := c.String("results")
+	if results == "" {
+		results = strings.TrimSuffix(c.String("csv"), filepath.Ext(c.String("csv"))) + ".results.csv"
+	}
+	if !utils.FileExists(results) {
+		return payouts, results
+	}
+	rf, err := os.Open(results)
+	if err != nil {
+		fmt.Printf("open payout results occured error: %s\n", err)
+		os.Exit(1)
+	}
+	defer rf.Close()
+	if err := types.ReadPayoutResults(rf, payouts); err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("resume from %s\n", results)
+	return payouts, results
+}
+
+// savePayoutResults replaces results file at once, so it's never left half written
+func savePayoutResults(path string, payouts []*types.Payout) error {
+	var buf bytes.Buffer
+	if err := types.WritePayoutResults(&buf, payouts); err != nil {
+		return fmt.Errorf("write payout results occured error: %s", err)
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("write payout results occured error: %s", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// getErc20Token finds the token by symbol in erc20_list and token_lists, or by the token flag's address which is
+// needed when several tokens have the symbol. A token address not in the lists only has the address.
+func getErc20Token(c *cli.Context, config types.Config, resolver *addressResolver) *types.Erc20Token {
+	loadErc20List(&config)
+	symbol, stoken := c.String("symbol"), c.String("token")
+	if stoken != "" {
+		address := resolver.resolve("token", stoken)
+		token := types.Erc20TokenByAddress(config.Erc20List, address)
+		if token == nil {
+			return &types.Erc20Token{Address: &address}
+		}
+		if symbol != "" && token.Symbol != symbol {
+			fmt.Printf("token %s is %s, not %s\n", stoken, token.Symbol, symbol)
+			os.Exit(1)
+		}
+		return token
+	}
+	if symbol == "" {
+		fmt.Printf("you need to specify --symbol or --token\n")
+		os.Exit(1)
+	}
+	token, err := types.FindErc20Token(config.Erc20List, symbol)
+	if err != nil {
+		if len(types.Erc20TokensBySymbol(config.Erc20List, symbol)) > 1 {
+			fmt.Printf("%s with --token\n", err)
+		} else {
+			fmt.Printf("%s\n", err)
+		}
+		os.Exit(1)
+	}
+	return token
+}
+
+// getListedErc20Token is getErc20Token for amounts in token's unit, the token must be in erc20_list or token_lists
+func getListedErc20Token(c *cli.Context, config types.Config, resolver *addressResolver) *types.Erc20Token {
+	token := getErc20Token(c, config, resolver)
+	if token.Symbol == "" {
+		fmt.Printf("token %s is not in erc20_list or token_lists of config.json\n", token.Address.String())
+		os.Exit(1)
+	}
+	return token
+}
+
+// getApproveValue returns the allowance in token's smallest unit, unlimited is MaxAllowance
+func getApproveValue(c *cli.Context, token *types.Erc20Token) *big.Int {
+	if strings.ToLower(c.String("value")) == "unlimited" {
+		return new(big.Int).Set(types.MaxAllowance)
+	}
+	return getTokenAmountFlag(c, "value", token)
+}
+
+// getDeadline returns the deadline flag in unix seconds, a duration is counted from now
+func getDeadline(c *cli.Context) *big.Int {
+	sdeadline := c.String("deadline")
+	if timestamp, ok := new(big.Int).SetString(sdeadline, 10); ok {
+		return timestamp
+	}
+	duration, err := time.ParseDuration(sdeadline)
+	if err != nil || duration <= 0 {
+		fmt.Printf("deadline must be a unix timestamp or a positive duration, got %s\n", sdeadline)
+		os.Exit(1)
+	}
+	return big.NewInt(time.Now().Add(duration).Unix())
+}
+
+func getGasLimitFlag(c *cli.Context) uint64 {
+	sgaslimit := c.String("gaslimit")
+	if sgaslimit == "" {
+		return 0
+	}
+	gaslimit, err := strconv.ParseUint(sgaslimit, 10, 64)
+	if err != nil {
+		fmt.Printf("gaslimt transfer to int occured error: %s\n", sgaslimit)
+		os.Exit(1)
+	}
+	return gaslimit
+}
+
+// printSentTx shows txid and its explorer url, then waits for confirmations if it's not 0 and returns the receipt
+func printSentTx(c *cli.Context, config types.Config, wallet *wallet.EthereumWallet, txid string, confirmations uint64) *types.NodeReceipt {
+	fmt.Printf("txid: %s\n", txid)
+	fmt.Printf("you can check tx on ethersacn: %s\n", ConstructEtherscanUrl(config, txid))
+	if confirmations == 0 {
+		return nil
+	}
+	return waitForConfirmations(c, wallet, txid, confirmations)
+}
+
+// stdinReader is shared by prompts, a new reader for every prompt would buffer away answers piped in for the later ones
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// confirm asks question and returns true if the answer is y
+func confirm(question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N]: ", question)
+	answer, _ := stdinReader.ReadString('\n')
+	return strings.ToLower(strings.TrimSpace(answer)) == "y"
+}
@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"bufio"
 	"encoding/json"
 	"fmt"
 	"github.com/ethereum/go-ethereum/common"
@@ -9,7 +8,9 @@ import (
 	"github.com/tn606024/ethwallet/utils"
 	"github.com/tn606024/ethwallet/wallet"
 	"github.com/urfave/cli/v2"
+	"io/ioutil"
 	"os"
+	"strings"
 )
 
 var (
@@ -40,23 +41,11 @@ var (
 		},
 		Action: func(c *cli.Context) error {
 			config := loadConfig()
-			var keystorepath string
-			if c.String("keystorepath") != ""{
-				keystorepath = c.String("keystorepath")
-			}else {
-				keystorepath = defaultFileDir
-			}
-			if utils.FileExists(keystorepath) != true {
-				os.Mkdir(keystorepath,0755)
-			}
 			passPhrase := promptPassphrase(true)
-			reader := bufio.NewReader(os.Stdin)
-			fmt.Print("Please give keystore a name: ")
-			name, _ := reader.ReadString('\n')
-			path := fmt.Sprintf("%s/%s", keystorepath, name)
+			path := promptKeystorePath(c)
 			wallet, err := wallet.CreateNewWallet(passPhrase, path, config)
 			if err != nil {
-				fmt.Errorf("Create Wallet Failed: %s", err)
+				fmt.Printf("Create Wallet Failed: %s\n", err)
 				os.Exit(1)
 			}
 			fmt.Printf("Wallet is create at : %s\n", wallet.Path)
@@ -80,7 +69,7 @@ var (
 			addressStr := c.String("address")
 			signatureStr := c.String("signature")
 			msg := loadStringOrFilePath(c, "message", "msgfile")
-			address = utils.HexToAddress(addressStr)
+			address = newAddressResolver(loadOptionalConfig()).resolve("address", addressStr)
 			if err != nil {
 				fmt.Printf("string to address occured error: %s", err)
 				os.Exit(1)
@@ -114,6 +103,100 @@ var (
 			return nil
 		},
 	}
+	signTypedDataSubcommand = &cli.Command{
+		Name:			"signtypeddata",
+		Usage:			"sign eip712 typed data",
+		Description:	"sign eip712 typed data like eth_signTypedData_v4, typed data can be a json string(typeddata) or file(typeddatafile)",
+		ArgsUsage:		"<keyfile> <typeddata> <typeddatafile>",
+		Flags: []cli.Flag{
+			keyfileFlag,
+			typedDataFlag,
+			typedDataFileFlag,
+		},
+		Action: func(c *cli.Context) error {
+			config := loadConfig()
+			typedData := loadTypedData(c)
+			wallet := unlockWallet(c, config)
+			sig, err := wallet.SignTypedData(typedData)
+			if err != nil {
+				fmt.Printf("SignTypedData error: %s\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(sig)
+			return nil
+		},
+	}
+	signPermitSubcommand = &cli.Command{
+		Name:			"signpermit",
+		Usage:			"sign an eip2612 permit for a gasless erc20 approval",
+		Description:	"sign a permit letting spender transfer value(in token's unit, or unlimited) of erc20 until deadline, the token is symbol in erc20_list " +
+						"of config.json or its contract address(token). the token's domain and your nonce are read from node, the output json has v, r, s " +
+						"and the permit calldata a relayer can submit.",
+		ArgsUsage:		"<keyfile> <symbol> <token> <spender> <value> <deadline> <out>",
+		Flags: []cli.Flag{
+			keyfileFlag,
+			optionalSymbolFlag,
+			tokenFlag,
+			spenderFlag,
+			approveValueFlag,
+			deadlineFlag,
+			outFlag,
+		},
+		Action: func(c *cli.Context) error {
+			config := loadConfig()
+			resolver := newAddressResolver(config)
+			token := getErc20Token(c, config, resolver)
+			spender := getAddressFlag(c, resolver, "spender")
+			value := getApproveValue(c, token)
+			deadline := getDeadline(c)
+			wallet := unlockEthereumWallet(c, config)
+			permit, err := wallet.SignPermit(token, spender, value, deadline)
+			if err != nil {
+				fmt.Printf("sign permit occured error: %s\n", err)
+				os.Exit(1)
+			}
+			res, err := permit.String()
+			if err != nil {
+				fmt.Printf("signed permit to json occured error: %s\n", err)
+				os.Exit(1)
+			}
+			if c.String("out") == "" {
+				fmt.Print(res)
+				return nil
+			}
+			err = ioutil.WriteFile(c.String("out"), []byte(res), 0644)
+			if err != nil {
+				fmt.Printf("write signed permit occured error: %s\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("signed permit is written to %s\n", c.String("out"))
+			return nil
+		},
+	}
+	verifyTypedDataSubcommand = &cli.Command{
+		Name:			"verifytypeddata",
+		Usage:			"verify eip712 typed data signature is valid",
+		Description:	"verify eip712 typed data signature is signed by address, typed data can be a json string(typeddata) or file(typeddatafile)",
+		ArgsUsage:		"<address> <signature> <typeddata> <typeddatafile>",
+		Flags: []cli.Flag{
+			addressFlag,
+			signatureFlag,
+			typedDataFlag,
+			typedDataFileFlag,
+		},
+		Action: func(c *cli.Context) error {
+			typedData := loadTypedData(c)
+			address := getAddressFlag(c, newAddressResolver(loadOptionalConfig()), "address")
+			sig := utils.HexStrToBytes(c.String("signature"))
+			ans, err := wallet.VerifyTypedData(address, sig, typedData)
+			if err != nil {
+				fmt.Printf("verify typed data occured error: %s\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(ans)
+			return nil
+		},
+	}
 	signTransactionSubcommand = &cli.Command{
 		Name:        "signtx",
 		Usage:       "sign a transaction",
@@ -143,6 +226,72 @@ var (
 			return nil
 		},
 	}
+	decodeTransactionSubcommand = &cli.Command{
+		Name:        "decodetx",
+		Usage:       "decode a raw transaction",
+		Description: "decode a signed raw transaction(legacy, eip2930, eip1559) in string(raw) or file(rawfile), show its fields, sender, chain id and hash",
+		ArgsUsage:   "<raw> <rawfile>",
+		Flags: []cli.Flag{
+			rawFlag,
+			rawFileFlag,
+		},
+		Action: func(c *cli.Context) error {
+			rawb := loadStringOrFilePath(c, "raw", "rawfile")
+			tx, err := types.DecodeRawTx(strings.TrimSpace(string(rawb)))
+			if err != nil {
+				fmt.Printf("decode tx error: %s\n", err)
+				os.Exit(1)
+			}
+			txstr, err := tx.String()
+			if err != nil {
+				fmt.Printf("tx to String occured error: %s\n", err)
+				os.Exit(1)
+			}
+			fmt.Print(txstr)
+			fmt.Printf("from: %s\n", tx.From.String())
+			if tx.ChainId != nil {
+				fmt.Printf("chainid: %s\n", tx.ChainId.String())
+			} else {
+				fmt.Printf("chainid: none(pre-eip155)\n")
+			}
+			fmt.Printf("hash: %s\n", utils.BytesToHexStr(tx.Hash()))
+			return nil
+		},
+	}
+	signOfflineTxSubcommand = &cli.Command{
+		Name:        "sign",
+		Usage:       "sign an offline transaction",
+		Description: "sign an offline transaction prepared by nodewallet prepare, in json or base64 payload in string(offlinetx) or file(offlinetxfile). " +
+					 "the transaction is shown for confirmation before signing, then the signed offline tx is output for node broadcast",
+		ArgsUsage:   "<keyfile> <offlinetx> <offlinetxfile> <out> <base64> <yes>",
+		Flags: []cli.Flag{
+			keyfileFlag,
+			offlineTxFlag,
+			offlineTxFileFlag,
+			outFlag,
+			base64Flag,
+			yesFlag,
+		},
+		Action: func(c *cli.Context) error {
+			o := loadOfflineTx(c)
+			// the summary goes to stderr, stdout is only the signed offline tx without --out so it can be piped to broadcast
+			fmt.Fprint(os.Stderr, o.Summary())
+			if !c.Bool("yes") && !confirm("sign this transaction?") {
+				fmt.Println("transaction is not signed")
+				os.Exit(1)
+			}
+			config := loadConfig()
+			wallet := unlockWallet(c, config)
+			err := wallet.SignOfflineTx(o)
+			if err != nil {
+				fmt.Printf("sign offline tx occured error: %s\n", err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "hash: %s\n", o.Hash)
+			writeOfflineTx(c, o)
+			return nil
+		},
+	}
 	WalletCommand = &cli.Command{
 		Name:	"wallet",
 		Usage:	"Ethereum wallet commands",
@@ -155,7 +304,13 @@ var (
 			createSubcommand,
 			signmessageSubcommand,
 			signTransactionSubcommand,
+			signOfflineTxSubcommand,
 			verifymessageSubcommand,
+			signTypedDataSubcommand,
+			verifyTypedDataSubcommand,
+			signPermitSubcommand,
+			decodeTransactionSubcommand,
+			hdSubcommand,
 		},
 	}
 )
@@ -6,10 +6,11 @@ import (
 	"fmt"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/tn606024/ethwallet/types"
-	"github.com/tn606024/ethwallet/utils"
 	"io/ioutil"
 	"math/big"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 )
 
@@ -45,6 +46,12 @@ func (c *EthConn) get(route string, result interface{}) error {
 	}
 	defer res.Body.Close()
 	resBody, err := ioutil.ReadAll(res.Body)
+	switch res.StatusCode {
+	case 500:
+		return fmt.Errorf("Server internal occured error: %s", string(resBody))
+	case 400:
+		return fmt.Errorf("Bad Request error: %s", string(resBody))
+	}
 	if err != nil {
 		return err
 	}
@@ -63,7 +70,7 @@ func (c *EthConn) get(route string, result interface{}) error {
 func (c *EthConn) post(route string, result interface{}, msg interface{}) error {
 	body, err := json.Marshal(msg)
 	if err != nil{
-		fmt.Errorf("json marshal error: %v", err)
+		return fmt.Errorf("json marshal error: %v", err)
 	}
 	req, err := http.NewRequest("POST",fmt.Sprintf("%s/%s", c.url, route), bytes.NewReader(body))
 	req.Header.Set("Content-Type","application/json")
@@ -114,11 +121,47 @@ func (c *EthConn) GetBalance(addr common.Address) (balance *big.Int, err error)
 	return
 }
 
-func (c *EthConn) GetErc20Balance(addr common.Address) (listBalance map[string]*big.Int, err error){
+func (c *EthConn) GetErc20Balance(addr common.Address) (listBalance map[string]*types.Amount, err error){
 	err = c.get(fmt.Sprintf("erc20balance?address=%s",addr.String()), &listBalance)
 	return
 }
 
+func (c *EthConn) GetDiscoveredErc20Balance(addr common.Address, source string, fromBlock uint64) (balances []types.Erc20Balance, err error){
+	err = c.get(fmt.Sprintf("discoverbalance?address=%s&source=%s&fromblock=%d", addr.String(), source, fromBlock), &balances)
+	return
+}
+
+func (c *EthConn) GetNfts(addr common.Address, fromBlock uint64) (nfts []types.Nft, err error){
+	err = c.get(fmt.Sprintf("nfts?address=%s&fromblock=%d", addr.String(), fromBlock), &nfts)
+	return
+}
+
+// GetNft returns tokenId of contract with owner's balance of it
+func (c *EthConn) GetNft(contract common.Address, tokenId *big.Int, owner common.Address) (nft *types.Nft, err error){
+	err = c.get(fmt.Sprintf("nft?contract=%s&tokenid=%s&owner=%s", contract.String(), tokenId.String(), owner.String()), &nft)
+	return
+}
+
+func (c *EthConn) ResolveEnsName(name string) (address common.Address, err error){
+	err = c.get(fmt.Sprintf("ens/resolve?name=%s", url.QueryEscape(name)), &address)
+	return
+}
+
+func (c *EthConn) LookupEnsAddress(addr common.Address) (name string, err error){
+	err = c.get(fmt.Sprintf("ens/lookup?address=%s", addr.String()), &name)
+	return
+}
+
+func (c *EthConn) GetApprovals(addr common.Address, fromBlock uint64) (approvals []types.Erc20Approval, err error){
+	err = c.get(fmt.Sprintf("approvals?address=%s&fromblock=%d", addr.String(), fromBlock), &approvals)
+	return
+}
+
+func (c *EthConn) GetPermitDomain(token common.Address, owner common.Address) (domain *types.PermitDomain, err error){
+	err = c.get(fmt.Sprintf("permitdomain?token=%s&owner=%s", token.String(), owner.String()), &domain)
+	return
+}
+
 func (c *EthConn) GetGasPrice() (gasPrice *big.Int, err error){
 	gasPrice = big.NewInt(0)
 	var resStr string
@@ -127,18 +170,48 @@ func (c *EthConn) GetGasPrice() (gasPrice *big.Int, err error){
 	return
 }
 
-func (c *EthConn) GetNonce(addr common.Address) (nonce uint64, err error){
+// GetBaseFee returns nil if the latest block has no base fee
+func (c *EthConn) GetBaseFee() (baseFee *big.Int, err error){
+	var resStr *string
+	err = c.get("basefee", &resStr)
+	if err != nil || resStr == nil {
+		return nil, err
+	}
+	baseFee, ok := new(big.Int).SetString(*resStr, 10)
+	if !ok {
+		return nil, fmt.Errorf("basefee is illegal: %s", *resStr)
+	}
+	return
+}
+
+func (c *EthConn) GetMaxPriorityFee() (tip *big.Int, err error){
+	tip = big.NewInt(0)
 	var resStr string
-	err = c.get(fmt.Sprintf("nonce?address=%s",addr.String()), &resStr)
-	nonce = utils.HexStrToUInt64(resStr)
+	err = c.get("maxpriorityfee", &resStr)
+	tip.SetString(resStr, 10)
 	return
 }
 
+func (c *EthConn) GetNonce(addr common.Address, param types.BlockParam) (nonce uint64, err error){
+	var resStr string
+	err = c.get(fmt.Sprintf("nonce?address=%s&param=%s",addr.String(), param), &resStr)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(resStr, 10, 64)
+}
+
 func (c *EthConn) GetTransaction(txid string) (tx types.NodeTransaction, err error){
 	err = c.get(fmt.Sprintf("tx?txid=%s",txid),&tx)
 	return
 }
 
+// GetTransactionReceipt returns nil if the transaction is pending or unknown
+func (c *EthConn) GetTransactionReceipt(txid string) (receipt *types.NodeReceipt, err error){
+	err = c.get(fmt.Sprintf("receipt?txid=%s",txid), &receipt)
+	return
+}
+
 func (c *EthConn) GetNormalTransactions(address common.Address) (txs []types.EsNormalTransaction, err error){
 	err = c.get(fmt.Sprintf("txs?address=%s", address.String()), &txs)
 	return
@@ -157,7 +230,34 @@ func (c *EthConn) GetTokenTransactions(address common.Address) (txs []types.EsEr
 func (c *EthConn) GetEstimateGas(tx types.TransactionRequest) (gas uint64, err error){
 	var resStr string
 	err = c.post("estimategas", &resStr, tx)
-	gas = utils.HexStrToUInt64(resStr)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(resStr, 10, 64)
+}
+
+// GetTransactionParams gets nonce at param, fees and estimated gas(if estimate is true) of tx in one request
+func (c *EthConn) GetTransactionParams(tx types.TransactionRequest, param types.BlockParam, estimate bool) (params types.TransactionParams, err error){
+	err = c.post(fmt.Sprintf("txparams?param=%s&estimate=%t", param, estimate), &params, tx)
+	return
+}
+
+func (c *EthConn) CreateAccessList(tx types.TransactionRequest) (accessList types.AccessList, gasUsed uint64, err error){
+	var res struct {
+		AccessList types.AccessList `json:"accesslist"`
+		GasUsed    string           `json:"gasused"`
+	}
+	err = c.post("accesslist", &res, tx)
+	if err != nil {
+		return nil, 0, err
+	}
+	gasUsed, err = strconv.ParseUint(res.GasUsed, 10, 64)
+	return res.AccessList, gasUsed, err
+}
+
+// Call returns the hex result of eth_call at latest block
+func (c *EthConn) Call(tx types.TransactionRequest) (res string, err error){
+	err = c.post("call", &res, tx)
 	return
 }
 
@@ -166,4 +266,10 @@ func (c *EthConn) SendRawTransaction(data string) (txid string, err error){
 	raw.Hex = data
 	err = c.post("send", &txid, raw)
 	return
-}
\ No newline at end of file
+}
+
+// GetBalances gets balances of assets of many addresses at block in one request
+func (c *EthConn) GetBalances(req types.BalancesRequest) (balances types.Balances, err error){
+	err = c.post("balances", &balances, req)
+	return
+}
@@ -24,6 +24,10 @@ func PubkeyToAddress(p ecdsa.PublicKey) common.Address{
 	return crypto.PubkeyToAddress(p)
 }
 
+func CreateAddress(b common.Address, nonce uint64) common.Address{
+	return crypto.CreateAddress(b, nonce)
+}
+
 func S256() elliptic.Curve{
 	return crypto.S256()
 }
@@ -33,6 +37,22 @@ func EncryptKey(key *Key, auth string, scryptN int, scryptP int) ([]byte, error)
 	return keystore.EncryptKey(&k, auth, scryptN, scryptP)
 }
 
+func EncryptData(data []byte, auth string, scryptN int, scryptP int) (keystore.CryptoJSON, error){
+	return keystore.EncryptDataV3(data, []byte(auth), scryptN, scryptP)
+}
+
+func DecryptData(cryptoJson keystore.CryptoJSON, auth string) ([]byte, error){
+	return keystore.DecryptDataV3(cryptoJson, auth)
+}
+
+func ToECDSA(d []byte) (*ecdsa.PrivateKey, error){
+	return crypto.ToECDSA(d)
+}
+
+func CompressPubkey(pubkey *ecdsa.PublicKey) []byte{
+	return crypto.CompressPubkey(pubkey)
+}
+
 func DecryptKey(keyjson []byte, auth string) (*Key, error){
 	key, err := keystore.DecryptKey(keyjson, auth)
 	tkey := ToKey(*key)
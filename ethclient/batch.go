@@ -0,0 +1,101 @@
+package ethclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/tn606024/ethwallet/types"
+)
+
+// maxBatchSize keeps a batch under the request limits of public nodes, bigger batches are split
+const maxBatchSize = 100
+
+// BatchElem is one call of a batch request, Error is set if the node returns an error for this call only
+type BatchElem struct {
+	Method string
+	Params []interface{}
+	Result interface{}
+	Error  error
+}
+
+type batchResponse struct {
+	ID     *int                 `json:"id"`
+	Result json.RawMessage      `json:"result"`
+	Error  *types.EthereumError `json:"error"`
+}
+
+// BatchCall sends elems in json-rpc batch requests and matches responses by id, the returned error
+// is only about the whole request, errors of each call are stored in BatchElem.Error.
+func (c *EthereumClient) BatchCall(elems []BatchElem) error {
+	for start := 0; start < len(elems); start += maxBatchSize {
+		end := start + maxBatchSize
+		if end > len(elems) {
+			end = len(elems)
+		}
+		err := c.batchCall(elems[start:end])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *EthereumClient) batchCall(elems []BatchElem) error {
+	reqs := make([]map[string]interface{}, len(elems))
+	for i, elem := range elems {
+		params := elem.Params
+		if params == nil {
+			params = []interface{}{}
+		}
+		reqs[i] = map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      i,
+			"method":  elem.Method,
+			"params":  params,
+		}
+	}
+	resBody, err := c.postJSONRPC(reqs)
+	if err != nil {
+		return err
+	}
+	// nodes which don't support batch reply a single error object
+	if trimmed := bytes.TrimSpace(resBody); len(trimmed) > 0 && trimmed[0] == '{' {
+		var responseError types.ResponseError
+		err = json.Unmarshal(trimmed, &responseError)
+		if err != nil {
+			return fmt.Errorf("json unmarshal resbody error: %s\n", err)
+		}
+		return fmt.Errorf("batch request error msg: %s\n", responseError.Error.Message)
+	}
+	var responses []batchResponse
+	err = json.Unmarshal(resBody, &responses)
+	if err != nil {
+		return fmt.Errorf("json unmarshal resbody error: %s\n", err)
+	}
+	answered := make([]bool, len(elems))
+	for _, res := range responses {
+		if res.ID == nil || *res.ID < 0 || *res.ID >= len(elems) || answered[*res.ID] {
+			return fmt.Errorf("batch response has unknown or duplicate id")
+		}
+		answered[*res.ID] = true
+		elem := &elems[*res.ID]
+		if res.Error != nil {
+			elem.Error = fmt.Errorf("%s error msg: %s", elem.Method, res.Error.Message)
+			continue
+		}
+		if res.Result == nil {
+			elem.Error = fmt.Errorf("%s has no result", elem.Method)
+			continue
+		}
+		err = json.Unmarshal(res.Result, elem.Result)
+		if err != nil {
+			elem.Error = fmt.Errorf("json Unmarshal %s result error: %s", elem.Method, err)
+		}
+	}
+	for i, ok := range answered {
+		if !ok {
+			elems[i].Error = fmt.Errorf("%s has no response", elems[i].Method)
+		}
+	}
+	return nil
+}
@@ -20,6 +20,9 @@ type EthereumClient struct {
 	network				*types.Network
 	id      	 		int
 	mux			 		sync.Mutex
+	// hasMulticall caches whether multicall3 is deployed on network, nil until it's checked
+	hasMulticall		*bool
+	multicallMux		sync.Mutex
 }
 
 func NewEthereumClient(url string, etherscanUrl string, etherscanApiKey string, network *types.Network) *EthereumClient {
@@ -36,30 +39,13 @@ func NewEthereumClient(url string, etherscanUrl string, etherscanApiKey string,
 }
 
 func (c *EthereumClient) call(method string, params []interface{}, result interface{}) (err error){
-	if c.url == "" {
-		fmt.Errorf("%s's node_url is not set in config.json",c.network.Name)
-	}
 	jsonrpc := map[string]interface{}{
 		"jsonrpc": "2.0",
 		"id":      c.id,
 		"method":  method,
 		"params":  params,
 	}
-
-	body, err :=json.Marshal(jsonrpc)
-	if err != nil {
-		return fmt.Errorf("json marshal jsonrpc error: %s\n", err)
-	}
-	req, err := http.NewRequest("POST", c.url, bytes.NewReader(body))
-	if err != nil{
-		return fmt.Errorf("consturct http request error: %s\n", err)
-	}
-	res, err := c.conn.Do(req)
-	if err != nil {
-		return fmt.Errorf("connected error: %s\n", err)
-	}
-	defer res.Body.Close()
-	resBody, err := ioutil.ReadAll(res.Body)
+	resBody, err := c.postJSONRPC(jsonrpc)
 	if err != nil {
 		return err
 	}
@@ -81,9 +67,31 @@ func (c *EthereumClient) call(method string, params []interface{}, result interf
 	return
 }
 
+// postJSONRPC posts a single or batch json-rpc request to node and returns the response body
+func (c *EthereumClient) postJSONRPC(msg interface{}) ([]byte, error){
+	if c.url == "" {
+		return nil, fmt.Errorf("%s's node_url is not set in config.json",c.network.Name)
+	}
+	body, err :=json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal jsonrpc error: %s\n", err)
+	}
+	req, err := http.NewRequest("POST", c.url, bytes.NewReader(body))
+	if err != nil{
+		return nil, fmt.Errorf("consturct http request error: %s\n", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := c.conn.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("connected error: %s\n", err)
+	}
+	defer res.Body.Close()
+	return ioutil.ReadAll(res.Body)
+}
+
 func (c *EthereumClient) callEtherscan(module, action string, params map[string]interface{}, result interface{}) (err error) {
 	if c.etherscanUrl == "" {
-		fmt.Errorf("%s's etherscan_api_url is not set in config.json",c.network.Name)
+		return fmt.Errorf("%s's etherscan_api_url is not set in config.json",c.network.Name)
 	}
 	req, err := http.NewRequest("GET", c.etherscanUrl, http.NoBody)
 	q := req.URL.Query()
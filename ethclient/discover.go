@@ -0,0 +1,160 @@
+package ethclient
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/types"
+	"strings"
+)
+
+// sources of token discovery, etherscan's tokentx or Transfer logs of node's eth_getLogs
+const (
+	DiscoverEtherscan = "etherscan"
+	DiscoverLogs      = "logs"
+)
+
+// discoverReorgDepth is how many blocks before the last scanned block are scanned again,
+// so a transfer in a block replaced by a reorg isn't missed
+const discoverReorgDepth = 12
+
+// discoverLogsBlocks is how many blocks a logs scan without fromBlock covers, scanning a chain like mainnet
+// from its genesis takes thousands of eth_getLogs
+const discoverLogsBlocks = 20 * logsWindowSize
+
+// DiscoverErc20Tokens finds token contracts which sent address a Transfer since fromBlock and resolves them
+// with GetErc20Info. Only blocks after the last scan in cache and blocks before the first scan are scanned,
+// contracts which aren't erc20 are skipped. A logs scan with fromBlock 0 starts at the first scan in cache,
+// or discoverLogsBlocks before the latest block on the first scan.
+func (c *EthereumClient) DiscoverErc20Tokens(address common.Address, source string, fromBlock uint64, cache *TokenCache) ([]*types.Erc20Token, error){
+	if cache == nil {
+		cache = NewTokenCache("")
+	}
+	err := cache.Load()
+	if err != nil {
+		return nil, err
+	}
+	network := c.network.Name
+	latest, err := c.latestBlockNumber()
+	if err != nil {
+		return nil, err
+	}
+	from, scanned, cached := cache.Scanned(network, address)
+	if source == DiscoverLogs && fromBlock == 0 {
+		if cached {
+			fromBlock = from
+		} else if latest > discoverLogsBlocks {
+			fromBlock = latest - discoverLogsBlocks
+		}
+	}
+	// blocks below the lowest scanned one are scanned when they are asked for
+	ranges := [][2]uint64{{fromBlock, latest}}
+	if cached {
+		ranges = ranges[:0]
+		if fromBlock < from {
+			ranges = append(ranges, [2]uint64{fromBlock, from - 1})
+		}
+		start := uint64(0)
+		if scanned > discoverReorgDepth {
+			start = scanned - discoverReorgDepth
+		}
+		ranges = append(ranges, [2]uint64{start, latest})
+	}
+	var found []common.Address
+	for _, r := range ranges {
+		var contracts []common.Address
+		switch source {
+		case DiscoverEtherscan:
+			contracts, err = c.etherscanTokenContracts(address, r[0], r[1])
+		case DiscoverLogs:
+			contracts, err = c.logTokenContracts(address, r[0], r[1])
+		default:
+			return nil, fmt.Errorf("discover source %s is unknown, use %s or %s", source, DiscoverEtherscan, DiscoverLogs)
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, contracts...)
+	}
+	cache.AddContracts(network, address, found, fromBlock, latest)
+	contracts := cache.Contracts(network, address)
+	var uncached []common.Address
+	for _, contract := range contracts {
+		if _, ok := cache.Token(network, contract); !ok {
+			uncached = append(uncached, contract)
+		}
+	}
+	infos, errs, err := c.GetErc20Infos(uncached)
+	if err != nil {
+		return nil, err
+	}
+	for i, token := range infos {
+		// a contract which isn't an erc20 is asked again next time
+		if errs[i] == nil {
+			cache.AddToken(network, token)
+		}
+	}
+	tokens := []*types.Erc20Token{}
+	for _, contract := range contracts {
+		if token, ok := cache.Token(network, contract); ok {
+			tokens = append(tokens, token)
+		}
+	}
+	err = cache.Save()
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (c *EthereumClient) etherscanTokenContracts(address common.Address, fromBlock uint64, toBlock uint64) ([]common.Address, error){
+	txs, err := c.GetErc20TokenTransactions(int(fromBlock), int(toBlock), false, address)
+	if err != nil {
+		return nil, err
+	}
+	var contracts []common.Address
+	for _, tx := range txs {
+		if strings.EqualFold(tx.To, address.String()) {
+			contracts = append(contracts, common.HexToAddress(tx.ContractAddress))
+		}
+	}
+	return contracts, nil
+}
+
+func (c *EthereumClient) logTokenContracts(address common.Address, fromBlock uint64, toBlock uint64) ([]common.Address, error){
+	filter := types.LogFilter{
+		Topics: [][]common.Hash{{types.Erc20TransferTopic}, nil, {common.BytesToHash(address.Bytes())}},
+	}
+	logs, err := c.getLogsInWindows(filter, fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	var contracts []common.Address
+	for _, log := range logs {
+		// erc721 Transfer has the token id as the 4th topic
+		if log.Removed || len(log.Topics) != 3 {
+			continue
+		}
+		contracts = append(contracts, common.HexToAddress(log.Address))
+	}
+	return contracts, nil
+}
+
+// GetDiscoveredErc20Balances discovers tokens of address and returns the ones it holds
+func (c *EthereumClient) GetDiscoveredErc20Balances(address common.Address, source string, fromBlock uint64, cache *TokenCache) ([]types.Erc20Balance, error){
+	tokens, err := c.DiscoverErc20Tokens(address, source, fromBlock, cache)
+	if err != nil {
+		return nil, err
+	}
+	// a token whose balanceOf fails is skipped like one which isn't held
+	balances, errs, err := c.getErc20Balances(tokens, address)
+	if err != nil {
+		return nil, err
+	}
+	held := []types.Erc20Balance{}
+	for i, token := range tokens {
+		if errs[i] == nil && balances[i].Sign() > 0 {
+			held = append(held, types.Erc20Balance{Token: token, Balance: types.NewAmount(balances[i], token.Decimals)})
+		}
+	}
+	return held, nil
+}
@@ -0,0 +1,183 @@
+package ethclient
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/types"
+	"github.com/tn606024/ethwallet/utils"
+)
+
+// EnsNotResolvedError is the error of a valid name which has no resolver or address, unlike a failed call
+type EnsNotResolvedError string
+
+func (e EnsNotResolvedError) Error() string {
+	return string(e)
+}
+
+// ResolveEnsName resolves name through the registry's resolver to the address of addr()
+func (c *EthereumClient) ResolveEnsName(name string) (common.Address, error){
+	if !c.network.HasEns() {
+		return common.Address{}, fmt.Errorf("ens isn't supported on network %s", c.network.Name)
+	}
+	name, err := types.NormalizeEnsName(name)
+	if err != nil {
+		return common.Address{}, err
+	}
+	node := types.Namehash(name)
+	res, err := c.GetCall(erc20CallRequest(&types.EnsRegistryAddress, types.GenerateEnsResolverData(node)), types.Latest)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("call resolver occured error: %s", err)
+	}
+	resolver := common.BytesToAddress(utils.HexStrToBytes(res))
+	if resolver == (common.Address{}) {
+		return common.Address{}, EnsNotResolvedError(fmt.Sprintf("ens name %s has no resolver", name))
+	}
+	res, err = c.GetCall(erc20CallRequest(&resolver, types.GenerateEnsAddrData(node)), types.Latest)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("call addr occured error: %s", err)
+	}
+	address := common.BytesToAddress(utils.HexStrToBytes(res))
+	if address == (common.Address{}) {
+		return common.Address{}, EnsNotResolvedError(fmt.Sprintf("ens name %s doesn't resolve to an address", name))
+	}
+	return address, nil
+}
+
+// ResolveAddress accepts an ens name or a hex address with a valid checksum, any other string is an error
+func (c *EthereumClient) ResolveAddress(s string) (common.Address, error){
+	if types.IsEnsName(s) {
+		return c.ResolveEnsName(s)
+	}
+	return utils.ParseAddress(s, c.network.ChainId)
+}
+
+// LookupEnsAddress returns the primary name of address, or "" if it has none
+func (c *EthereumClient) LookupEnsAddress(address common.Address) (string, error){
+	names, err := c.LookupEnsAddresses([]common.Address{address})
+	if err != nil {
+		return "", err
+	}
+	return names[address], nil
+}
+
+// LookupEnsAddresses finds primary names of addresses by reverse resolution in batch requests, a name is only
+// kept when it resolves back to the address, addresses without a name aren't in the map.
+func (c *EthereumClient) LookupEnsAddresses(addresses []common.Address) (map[common.Address]string, error){
+	names := make(map[common.Address]string)
+	if !c.network.HasEns() {
+		return names, nil
+	}
+	var unique []common.Address
+	seen := make(map[common.Address]bool)
+	for _, address := range addresses {
+		if !seen[address] && address != (common.Address{}) {
+			seen[address] = true
+			unique = append(unique, address)
+		}
+	}
+	nodes := make([]common.Hash, len(unique))
+	for i, address := range unique {
+		nodes[i] = types.EnsReverseNode(address)
+	}
+	resolvers, err := c.ensResolvers(nodes)
+	if err != nil {
+		return nil, err
+	}
+	calls := make([]ensCall, len(unique))
+	for i := range unique {
+		calls[i] = ensCall{resolvers[i], types.GenerateEnsNameData(nodes[i])}
+	}
+	results, err := c.ensCalls(calls)
+	if err != nil {
+		return nil, err
+	}
+	claimed := make([]string, len(unique))
+	forward := make([]common.Hash, len(unique))
+	for i, res := range results {
+		if res == "" {
+			continue
+		}
+		dec, err := utils.DecodeSingle(res, "string")
+		if err != nil {
+			continue
+		}
+		if name, err := types.NormalizeEnsName(dec.(string)); err == nil {
+			claimed[i] = name
+			forward[i] = types.Namehash(name)
+		}
+	}
+
+	// anyone can claim any name in reverse records, so the name has to resolve back
+	resolvers, err = c.ensResolvers(forward)
+	if err != nil {
+		return nil, err
+	}
+	for i := range unique {
+		calls[i] = ensCall{resolvers[i], types.GenerateEnsAddrData(forward[i])}
+	}
+	results, err = c.ensCalls(calls)
+	if err != nil {
+		return nil, err
+	}
+	for i, res := range results {
+		if claimed[i] != "" && res != "" && common.BytesToAddress(utils.HexStrToBytes(res)) == unique[i] {
+			names[unique[i]] = claimed[i]
+		}
+	}
+	return names, nil
+}
+
+type ensCall struct {
+	to   common.Address
+	data []byte
+}
+
+func (c *EthereumClient) ensResolvers(nodes []common.Hash) ([]common.Address, error) {
+	calls := make([]ensCall, len(nodes))
+	for i, node := range nodes {
+		if node != (common.Hash{}) {
+			calls[i] = ensCall{types.EnsRegistryAddress, types.GenerateEnsResolverData(node)}
+		}
+	}
+	results, err := c.ensCalls(calls)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]common.Address, len(nodes))
+	for i, res := range results {
+		if res != "" {
+			resolvers[i] = common.BytesToAddress(utils.HexStrToBytes(res))
+		}
+	}
+	return resolvers, nil
+}
+
+// ensCalls does calls in a batch request, a call to the zero address is skipped and a failed call's result is ""
+func (c *EthereumClient) ensCalls(calls []ensCall) ([]string, error) {
+	results := make([]string, len(calls))
+	var elems []BatchElem
+	var indexes []int
+	for i := range calls {
+		if calls[i].to == (common.Address{}) {
+			continue
+		}
+		elems = append(elems, BatchElem{
+			Method: "eth_call",
+			Params: []interface{}{erc20CallRequest(&calls[i].to, calls[i].data), types.Latest},
+			Result: &results[i],
+		})
+		indexes = append(indexes, i)
+	}
+	if len(elems) == 0 {
+		return results, nil
+	}
+	if err := c.BatchCall(elems); err != nil {
+		return nil, err
+	}
+	for j, elem := range elems {
+		if elem.Error != nil {
+			results[indexes[j]] = ""
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,202 @@
+package ethclient
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/types"
+	"github.com/tn606024/ethwallet/utils"
+	"math/big"
+)
+
+// maxMulticallSize is the calls in one aggregate3, which keeps an eth_call under nodes' gas cap
+const maxMulticallSize = 500
+
+// HasMulticall tells whether multicall3 is deployed on network, the answer is cached even if eth_getCode fails,
+// which only makes calls fall back to plain eth_calls
+func (c *EthereumClient) HasMulticall() bool {
+	c.multicallMux.Lock()
+	defer c.multicallMux.Unlock()
+	if c.hasMulticall == nil {
+		code, err := c.GetCode(types.Multicall3Address, types.Latest)
+		has := err == nil && len(utils.HexStrToBytes(code)) > 0
+		c.hasMulticall = &has
+	}
+	return *c.hasMulticall
+}
+
+// Multicall does calls at blockParam with multicall3's aggregate3, calls are split into aggregate3 eth_calls of
+// maxMulticallSize which are sent in one batch request. Calls are sent as plain eth_calls on chains without multicall3,
+// or when an aggregate3 fails, e.g. multicall3 isn't deployed yet at blockParam. A failed call has Success false,
+// the returned error is only about the whole request.
+func (c *EthereumClient) Multicall(calls []types.MulticallCall, blockParam types.BlockParam) ([]types.MulticallResult, error) {
+	results := make([]types.MulticallResult, len(calls))
+	if len(calls) == 0 {
+		return results, nil
+	}
+	if !c.HasMulticall() {
+		return results, c.plainCalls(calls, results, blockParam)
+	}
+	var chunks [][2]int
+	for start := 0; start < len(calls); start += maxMulticallSize {
+		end := start + maxMulticallSize
+		if end > len(calls) {
+			end = len(calls)
+		}
+		chunks = append(chunks, [2]int{start, end})
+	}
+	aggregated := make([]string, len(chunks))
+	elems := make([]BatchElem, len(chunks))
+	for i, chunk := range chunks {
+		data, err := types.GenerateAggregate3Data(calls[chunk[0]:chunk[1]])
+		if err != nil {
+			return nil, err
+		}
+		elems[i] = BatchElem{
+			Method: "eth_call",
+			Params: []interface{}{erc20CallRequest(&types.Multicall3Address, data), blockParam},
+			Result: &aggregated[i],
+		}
+	}
+	err := c.BatchCall(elems)
+	if err != nil {
+		return nil, err
+	}
+	for i, chunk := range chunks {
+		var decoded []types.MulticallResult
+		err := elems[i].Error
+		if err == nil {
+			decoded, err = types.DecodeAggregate3Result(utils.HexStrToBytes(aggregated[i]))
+		}
+		if err == nil && len(decoded) != chunk[1]-chunk[0] {
+			err = fmt.Errorf("aggregate3 returned %d results of %d calls", len(decoded), chunk[1]-chunk[0])
+		}
+		if err != nil {
+			err = c.plainCalls(calls[chunk[0]:chunk[1]], results[chunk[0]:chunk[1]], blockParam)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		copy(results[chunk[0]:chunk[1]], decoded)
+	}
+	return results, nil
+}
+
+func (c *EthereumClient) plainCalls(calls []types.MulticallCall, results []types.MulticallResult, blockParam types.BlockParam) error {
+	raw := make([]string, len(calls))
+	elems := make([]BatchElem, len(calls))
+	for i := range calls {
+		elems[i] = BatchElem{
+			Method: "eth_call",
+			Params: []interface{}{erc20CallRequest(&calls[i].Target, calls[i].CallData), blockParam},
+			Result: &raw[i],
+		}
+	}
+	err := c.BatchCall(elems)
+	if err != nil {
+		return err
+	}
+	for i := range calls {
+		results[i] = types.MulticallResult{Success: elems[i].Error == nil, ReturnData: utils.HexStrToBytes(raw[i])}
+	}
+	return nil
+}
+
+// GetBalances gets ether balances of addresses at blockParam with multicall3's getEthBalance, an address whose
+// getEthBalance didn't return a balance is asked by eth_getBalance
+func (c *EthereumClient) GetBalances(addresses []common.Address, blockParam types.BlockParam) ([]*big.Int, error) {
+	balances := make([]*big.Int, len(addresses))
+	var missing []int
+	if c.HasMulticall() {
+		calls := make([]types.MulticallCall, len(addresses))
+		for i, address := range addresses {
+			calls[i] = types.MulticallCall{Target: types.Multicall3Address, AllowFailure: true, CallData: types.GenerateGetEthBalanceData(address)}
+		}
+		results, err := c.Multicall(calls, blockParam)
+		if err != nil {
+			return nil, err
+		}
+		for i, res := range results {
+			if res.Success && len(res.ReturnData) == 32 {
+				balances[i] = new(big.Int).SetBytes(res.ReturnData)
+			} else {
+				missing = append(missing, i)
+			}
+		}
+	} else {
+		for i := range addresses {
+			missing = append(missing, i)
+		}
+	}
+	if len(missing) == 0 {
+		return balances, nil
+	}
+	raw := make([]string, len(missing))
+	elems := make([]BatchElem, len(missing))
+	for j, i := range missing {
+		elems[j] = BatchElem{
+			Method: "eth_getBalance",
+			Params: []interface{}{addresses[i], blockParam},
+			Result: &raw[j],
+		}
+	}
+	err := c.BatchCall(elems)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range missing {
+		if elems[j].Error != nil {
+			return nil, fmt.Errorf("get balance of %s occured error: %s", addresses[i].String(), elems[j].Error)
+		}
+		balances[i] = utils.HexStrToBigInt(raw[j])
+	}
+	return balances, nil
+}
+
+// GetAssetBalances gets balances of every token of every address at blockParam in multicalls, a nil token is ether.
+// balances[i][j] is the balance of addresses[i] of tokens[j] in the token's smallest unit.
+func (c *EthereumClient) GetAssetBalances(addresses []common.Address, tokens []*types.Erc20Token, blockParam types.BlockParam) ([][]*big.Int, error) {
+	balances := make([][]*big.Int, len(addresses))
+	for i := range balances {
+		balances[i] = make([]*big.Int, len(tokens))
+	}
+	var calls []types.MulticallCall
+	for _, address := range addresses {
+		data := utils.EncodeABI(types.Erc20FunctionInterface.BalanceOf.MethodId, address.Bytes())
+		for _, token := range tokens {
+			if token != nil {
+				calls = append(calls, types.MulticallCall{Target: *token.Address, AllowFailure: true, CallData: data})
+			}
+		}
+	}
+	results, err := c.Multicall(calls, blockParam)
+	if err != nil {
+		return nil, err
+	}
+	n := 0
+	for i, address := range addresses {
+		for j, token := range tokens {
+			if token == nil {
+				continue
+			}
+			if !results[n].Success {
+				return nil, fmt.Errorf("balanceOf %s of token %s failed", address.String(), token.Address.String())
+			}
+			balances[i][j] = new(big.Int).SetBytes(results[n].ReturnData)
+			n++
+		}
+	}
+	for j, token := range tokens {
+		if token != nil {
+			continue
+		}
+		ether, err := c.GetBalances(addresses, blockParam)
+		if err != nil {
+			return nil, err
+		}
+		for i := range addresses {
+			balances[i][j] = ether[i]
+		}
+	}
+	return balances, nil
+}
@@ -0,0 +1,211 @@
+package ethclient
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/types"
+	"github.com/tn606024/ethwallet/utils"
+	"math/big"
+)
+
+// GetNftStandard tells erc721 from erc1155 by erc165's supportsInterface
+func (c *EthereumClient) GetNftStandard(contract *common.Address) (string, error){
+	for _, standard := range []struct {
+		name        string
+		interfaceId []byte
+	}{{types.Erc721, types.Erc721InterfaceId}, {types.Erc1155, types.Erc1155InterfaceId}} {
+		res, err := c.GetCall(erc20CallRequest(contract, types.GenerateSupportsInterfaceData(standard.interfaceId)), types.Latest)
+		if err != nil {
+			return "", fmt.Errorf("call supportsInterface occured error: %s", err)
+		}
+		if utils.HexStrToBigInt(res).Cmp(big.NewInt(1)) == 0 {
+			return standard.name, nil
+		}
+	}
+	return "", fmt.Errorf("contract %s is neither erc721 nor erc1155", contract.String())
+}
+
+func (c *EthereumClient) GetNftOwner(contract *common.Address, tokenId *big.Int) (common.Address, error){
+	res, err := c.GetCall(erc20CallRequest(contract, types.GenerateOwnerOfData(tokenId)), types.Latest)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return common.BytesToAddress(utils.HexStrToBytes(res)), nil
+}
+
+// GetNftBalance returns how many tokens of contract owner has for erc721, or how many of tokenId for erc1155
+func (c *EthereumClient) GetNftBalance(standard string, contract *common.Address, owner common.Address, tokenId *big.Int) (*big.Int, error){
+	res, err := c.GetCall(erc20CallRequest(contract, types.GenerateNftBalanceOfData(standard, &owner, tokenId)), types.Latest)
+	if err != nil {
+		return nil, err
+	}
+	return utils.HexStrToBigInt(res), nil
+}
+
+// GetNftUri returns tokenURI of erc721 or uri of erc1155 with {id} replaced
+func (c *EthereumClient) GetNftUri(standard string, contract *common.Address, tokenId *big.Int) (string, error){
+	res, err := c.GetCall(erc20CallRequest(contract, types.GenerateNftUriData(standard, tokenId)), types.Latest)
+	if err != nil {
+		return "", err
+	}
+	return decodeNftUri(standard, res, tokenId)
+}
+
+// GetNft returns tokenId of contract with the balance owner has, 1 or 0 for erc721 whose Owner is set,
+// a uri which can't be read is left empty
+func (c *EthereumClient) GetNft(contract *common.Address, tokenId *big.Int, owner common.Address) (*types.Nft, error){
+	standard, err := c.GetNftStandard(contract)
+	if err != nil {
+		return nil, err
+	}
+	nft := &types.Nft{Contract: *contract, Standard: standard, TokenId: (*types.BigIntHex)(tokenId)}
+	switch standard {
+	case types.Erc721:
+		tokenOwner, err := c.GetNftOwner(contract, tokenId)
+		if err != nil {
+			return nil, fmt.Errorf("call ownerOf occured error: %s", err)
+		}
+		nft.Owner = &tokenOwner
+		balance := big.NewInt(0)
+		if tokenOwner == owner {
+			balance.SetInt64(1)
+		}
+		nft.Balance = (*types.BigIntHex)(balance)
+	case types.Erc1155:
+		balance, err := c.GetNftBalance(standard, contract, owner, tokenId)
+		if err != nil {
+			return nil, fmt.Errorf("call balanceOf occured error: %s", err)
+		}
+		nft.Balance = (*types.BigIntHex)(balance)
+	}
+	nft.Uri, _ = c.GetNftUri(standard, contract, tokenId)
+	return nft, nil
+}
+
+func decodeNftUri(standard string, res string, tokenId *big.Int) (string, error) {
+	dec, err := utils.DecodeSingle(res, "string")
+	if err != nil {
+		return "", err
+	}
+	uri := dec.(string)
+	if standard == types.Erc1155 {
+		uri = types.Erc1155Uri(uri, tokenId)
+	}
+	return uri, nil
+}
+
+// GetNfts finds erc721 Transfer and erc1155 TransferSingle, TransferBatch logs to owner since fromBlock, then keeps
+// tokens owner still has by ownerOf or balanceOf and gets their uris, all calls are in batch requests.
+func (c *EthereumClient) GetNfts(owner common.Address, fromBlock uint64) ([]types.Nft, error){
+	ownerTopic := common.BytesToHash(owner.Bytes())
+	latest, err := c.latestBlockNumber()
+	if err != nil {
+		return nil, err
+	}
+	erc721Logs, err := c.getLogsInWindows(types.LogFilter{Topics: [][]common.Hash{{types.Erc20TransferTopic}, nil, {ownerTopic}}}, fromBlock, latest)
+	if err != nil {
+		return nil, err
+	}
+	erc1155Logs, err := c.getLogsInWindows(types.LogFilter{Topics: [][]common.Hash{{types.Erc1155TransferSingleTopic, types.Erc1155TransferBatchTopic}, nil, nil, {ownerTopic}}}, fromBlock, latest)
+	if err != nil {
+		return nil, err
+	}
+	type candidate struct {
+		contract common.Address
+		standard string
+		tokenId  string
+	}
+	var candidates []candidate
+	seen := make(map[candidate]bool)
+	add := func(contract common.Address, standard string, tokenId *big.Int) {
+		cand := candidate{contract, standard, tokenId.String()}
+		if !seen[cand] {
+			seen[cand] = true
+			candidates = append(candidates, cand)
+		}
+	}
+	for _, log := range erc721Logs {
+		// erc20 Transfer has no token id topic
+		if log.Removed || len(log.Topics) != 4 {
+			continue
+		}
+		add(common.HexToAddress(log.Address), types.Erc721, utils.HexStrToBigInt(log.Topics[3]))
+	}
+	for _, log := range erc1155Logs {
+		if log.Removed || len(log.Topics) != 4 {
+			continue
+		}
+		ids, _, err := types.DecodeErc1155TransferData(common.HexToHash(log.Topics[0]), utils.HexStrToBytes(log.Data))
+		if err != nil {
+			continue
+		}
+		for _, id := range ids {
+			add(common.HexToAddress(log.Address), types.Erc1155, id)
+		}
+	}
+	nfts := []types.Nft{}
+	if len(candidates) == 0 {
+		return nfts, nil
+	}
+
+	results := make([]string, len(candidates))
+	elems := make([]BatchElem, len(candidates))
+	for i, cand := range candidates {
+		tokenId, _ := new(big.Int).SetString(cand.tokenId, 10)
+		data := types.GenerateOwnerOfData(tokenId)
+		if cand.standard == types.Erc1155 {
+			data = types.GenerateNftBalanceOfData(types.Erc1155, &owner, tokenId)
+		}
+		elems[i] = BatchElem{
+			Method: "eth_call",
+			Params: []interface{}{erc20CallRequest(&candidates[i].contract, data), types.Latest},
+			Result: &results[i],
+		}
+	}
+	err = c.BatchCall(elems)
+	if err != nil {
+		return nil, err
+	}
+	for i, cand := range candidates {
+		if elems[i].Error != nil {
+			continue
+		}
+		tokenId, _ := new(big.Int).SetString(cand.tokenId, 10)
+		balance := big.NewInt(1)
+		if cand.standard == types.Erc1155 {
+			balance = utils.HexStrToBigInt(results[i])
+		} else if common.BytesToAddress(utils.HexStrToBytes(results[i])) != owner {
+			continue
+		}
+		if balance.Sign() == 0 {
+			continue
+		}
+		nfts = append(nfts, types.Nft{
+			Contract: cand.contract,
+			Standard: cand.standard,
+			TokenId:  (*types.BigIntHex)(tokenId),
+			Balance:  (*types.BigIntHex)(balance),
+		})
+	}
+
+	uris := make([]string, len(nfts))
+	elems = make([]BatchElem, len(nfts))
+	for i, nft := range nfts {
+		elems[i] = BatchElem{
+			Method: "eth_call",
+			Params: []interface{}{erc20CallRequest(&nfts[i].Contract, types.GenerateNftUriData(nft.Standard, (*big.Int)(nft.TokenId))), types.Latest},
+			Result: &uris[i],
+		}
+	}
+	err = c.BatchCall(elems)
+	if err != nil {
+		return nil, err
+	}
+	// a token without uri is still listed
+	for i := range nfts {
+		if elems[i].Error == nil {
+			nfts[i].Uri, _ = decodeNftUri(nfts[i].Standard, uris[i], (*big.Int)(nfts[i].TokenId))
+		}
+	}
+	return nfts, nil
+}
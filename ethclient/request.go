@@ -2,11 +2,11 @@ package ethclient
 
 import (
 	"encoding/hex"
+	"fmt"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/tn606024/ethwallet/types"
 	"github.com/tn606024/ethwallet/utils"
 	"math/big"
-	"sync"
 )
 
 
@@ -21,6 +21,24 @@ func (c *EthereumClient) GetBlockNumber() (blockNum string, err error){
 }
 
 
+func (c *EthereumClient) GetBlockByNumber(blockParam types.BlockParam) (block types.NodeBlock, err error){
+	params := []interface{}{
+		blockParam,
+		false,
+	}
+	err = c.call("eth_getBlockByNumber", params, &block)
+	return
+}
+
+func (c *EthereumClient) GetMaxPriorityFeePerGas() (tip string, err error){
+	var params []interface{}
+	err = c.call("eth_maxPriorityFeePerGas", params, &tip)
+	if err != nil {
+		return "", err
+	}
+	return tip, nil
+}
+
 func (c *EthereumClient) GetBalance(address common.Address, blockParam types.BlockParam) (balance string, err error){
 	params := []interface{}{
 		address,
@@ -33,6 +51,15 @@ func (c *EthereumClient) GetBalance(address common.Address, blockParam types.Blo
 	return balance, nil
 }
 
+func (c *EthereumClient) GetCode(address common.Address, blockParam types.BlockParam) (code string, err error){
+	params := []interface{}{
+		address,
+		blockParam,
+	}
+	err = c.call("eth_getCode", params, &code)
+	return
+}
+
 func (c *EthereumClient) GetTransaction(txid string)  (transaction types.NodeTransaction, err error){
 	params := []interface{}{
 		txid,
@@ -41,6 +68,15 @@ func (c *EthereumClient) GetTransaction(txid string)  (transaction types.NodeTra
 	return
 }
 
+// GetTransactionReceipt returns nil if the transaction is pending or unknown
+func (c *EthereumClient) GetTransactionReceipt(txid string) (receipt *types.NodeReceipt, err error){
+	params := []interface{}{
+		txid,
+	}
+	err = c.call("eth_getTransactionReceipt", params, &receipt)
+	return
+}
+
 func (c *EthereumClient) GetEstimateGas(transaction *types.TransactionRequest) (estimateGas string, err error){
 	params := []interface{}{
 		transaction,
@@ -53,6 +89,21 @@ func (c *EthereumClient) GetEstimateGas(transaction *types.TransactionRequest) (
 	return estimateGas, nil
 }
 
+func (c *EthereumClient) CreateAccessList(transaction *types.TransactionRequest, blockParam types.BlockParam) (res types.AccessListResult, err error){
+	params := []interface{}{
+		transaction,
+		blockParam,
+	}
+	err = c.call("eth_createAccessList", params, &res)
+	if err != nil {
+		return types.AccessListResult{}, err
+	}
+	if res.Error != "" {
+		return types.AccessListResult{}, fmt.Errorf("create access list error: %s", res.Error)
+	}
+	return res, nil
+}
+
 func (c *EthereumClient)  GetGasPrice() (gasPrice string, err error){
 	var params []interface{}
 	err = c.call("eth_gasPrice", params, &gasPrice)
@@ -110,121 +161,328 @@ func (c *EthereumClient) SendRawTransaction(raw string) (res string, err error){
 	return res, nil
 }
 
-func (c *EthereumClient) GetErc20Balance(token *types.Erc20Token, address  *common.Address, listBalance map[string]*big.Int, wg *sync.WaitGroup, errs chan error){
-	defer wg.Done()
-	data := utils.EncodeABI(types.Erc20FunctionInterface.BalanceOf.MethodId, address.Bytes())
-	txr := &types.TransactionRequest{
-		To:   token.Address.String(),
+func erc20CallRequest(token *common.Address, data []byte) *types.TransactionRequest {
+	return &types.TransactionRequest{
+		To:   token.String(),
 		Data: bytesToData(data),
 	}
-	resp, err := c.GetCall(txr, types.Latest)
-	if err != nil{
-		errs <- err
+}
+
+// GetErc20ListBalance gets balances of every token in one batch request, balances are keyed by symbol,
+// or by symbol and address if several tokens have the symbol
+func (c *EthereumClient) GetErc20ListBalance(list []*types.Erc20Token, address common.Address) (map[string]*types.Amount, error){
+	balances, errs, err := c.getErc20Balances(list, address)
+	if err != nil {
+		return nil, err
+	}
+	listBalance := make(map[string]*types.Amount)
+	for i, token := range list {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("get %s balance occured error: %s", token.Symbol, errs[i])
+		}
+		key := token.Symbol
+		if len(types.Erc20TokensBySymbol(list, token.Symbol)) > 1 {
+			key = fmt.Sprintf("%s(%s)", token.Symbol, token.Address.String())
+		}
+		listBalance[key] = types.NewAmount(balances[i], token.Decimals)
+	}
+	return listBalance, nil
+}
+
+// getErc20Balances gets balances in token's smallest unit in the order of list with multicall, errs has the error of
+// every token whose balanceOf failed
+func (c *EthereumClient) getErc20Balances(list []*types.Erc20Token, address common.Address) (balances []*big.Int, errs []error, err error){
+	data := utils.EncodeABI(types.Erc20FunctionInterface.BalanceOf.MethodId, address.Bytes())
+	calls := make([]types.MulticallCall, len(list))
+	for i, token := range list {
+		calls[i] = types.MulticallCall{Target: *token.Address, AllowFailure: true, CallData: data}
 	}
-	res := utils.HexStrToBigInt(resp)
-	c.mux.Lock()
-	listBalance[token.Symbol] = types.WeiToToken(res, token.Decimals)
-	c.mux.Unlock()
-	return
+	results, err := c.Multicall(calls, types.Latest)
+	if err != nil {
+		return nil, nil, err
+	}
+	balances = make([]*big.Int, len(list))
+	errs = make([]error, len(list))
+	for i, res := range results {
+		if !res.Success {
+			errs[i] = fmt.Errorf("balanceOf of %s failed", list[i].Address.String())
+			continue
+		}
+		balances[i] = new(big.Int).SetBytes(res.ReturnData)
+	}
+	return balances, errs, nil
 }
 
-func (c *EthereumClient) GetErc20ListBalance(list []*types.Erc20Token, address common.Address) (map[string]*big.Int, error){
-	var wg sync.WaitGroup
-	errs := make(chan error)
-	wgDone := make(chan bool)
-	listBalance := make(map[string]*big.Int)
-	wg.Add(len(list))
-	for _ , token := range list{
-		go c.GetErc20Balance(token, &address, listBalance, &wg, errs)
+// GetErc20Info gets name, symbol and decimals in one multicall
+func (c *EthereumClient) GetErc20Info(contract *common.Address) (token *types.Erc20Token, err error){
+	tokens, errs, err := c.GetErc20Infos([]common.Address{*contract})
+	if err != nil {
+		return nil, err
 	}
-	wg.Wait()
-	close(wgDone)
-	select {
-	case <-wgDone:
-		close(errs)
-		return listBalance, nil
-		break
-	case err := <-errs:
-		close(errs)
-		return nil ,err
+	return tokens[0], errs[0]
+}
+
+// GetErc20Infos gets name, symbol and decimals of every contract in one multicall, errs has the error of every
+// contract which isn't an erc20
+func (c *EthereumClient) GetErc20Infos(contracts []common.Address) (tokens []*types.Erc20Token, errs []error, err error){
+	fields := []types.Method{types.Erc20FunctionInterface.Name, types.Erc20FunctionInterface.Symbol, types.Erc20FunctionInterface.Decimals}
+	calls := make([]types.MulticallCall, 0, len(contracts)*len(fields))
+	for _, contract := range contracts {
+		for _, field := range fields {
+			calls = append(calls, types.MulticallCall{Target: contract, AllowFailure: true, CallData: field.MethodId})
+		}
+	}
+	results, err := c.Multicall(calls, types.Latest)
+	if err != nil {
+		return nil, nil, err
 	}
-	return nil, nil
+	tokens = make([]*types.Erc20Token, len(contracts))
+	errs = make([]error, len(contracts))
+	for i := range contracts {
+		tokens[i], errs[i] = decodeErc20Info(&contracts[i], results[i*len(fields):(i+1)*len(fields)])
+	}
+	return tokens, errs, nil
 }
 
+func decodeErc20Info(contract *common.Address, results []types.MulticallResult) (*types.Erc20Token, error) {
+	for i, field := range []string{"name", "symbol", "decimals"} {
+		if !results[i].Success {
+			return nil, fmt.Errorf("%s of %s failed", field, contract.String())
+		}
+	}
+	token := &types.Erc20Token{Address: contract}
+	dec, err := utils.DecodeSingle(utils.BytesToHexStr(results[0].ReturnData), "string")
+	if err != nil {
+		return nil, err
+	}
+	token.Name = dec.(string)
+	dec, err = utils.DecodeSingle(utils.BytesToHexStr(results[1].ReturnData), "string")
+	if err != nil {
+		return nil, err
+	}
+	token.Symbol = dec.(string)
+	token.Decimals = int(new(big.Int).SetBytes(results[2].ReturnData).Int64())
+	return token, nil
+}
 
+type logQuery struct {
+	types.LogFilter
+	FromBlock types.BlockParam `json:"fromBlock"`
+	ToBlock   types.BlockParam `json:"toBlock"`
+}
 
-func(c *EthereumClient) GetErc20Name(contract *common.Address, token *types.Erc20Token, wg *sync.WaitGroup, errs chan error)  {
-	defer wg.Done()
-	ret, err := c.callVariable(contract, types.Erc20FunctionInterface.Name.MethodId)
-	if err != nil{
-		errs <- err
-	}
-	 dec, err := utils.DecodeSingle(ret, "string")
-	 res := dec.(string)
-	 c.mux.Lock()
-	 token.Name = res
-	 c.mux.Unlock()
-	if err != nil{
-		errs <- err
+// GetNodeLogs gets logs matching filter between fromBlock and toBlock from node
+func (c *EthereumClient) GetNodeLogs(filter types.LogFilter, fromBlock types.BlockParam, toBlock types.BlockParam) (logs []types.NodeLog, err error){
+	params := []interface{}{
+		logQuery{LogFilter: filter, FromBlock: fromBlock, ToBlock: toBlock},
 	}
+	err = c.call("eth_getLogs", params, &logs)
 	return
 }
 
-func(c *EthereumClient) GetErc20Symbol(contract *common.Address,  token *types.Erc20Token, wg *sync.WaitGroup,errs chan error)  {
-	defer wg.Done()
-	ret, err := c.callVariable(contract, types.Erc20FunctionInterface.Symbol.MethodId)
-	if err != nil{
-		errs <- err
-	}
-	dec, err := utils.DecodeSingle(ret, "string")
-	res := dec.(string)
-	c.mux.Lock()
-	token.Symbol = res
-	c.mux.Unlock()
-	if err != nil{
-		errs <- err
+// logsWindowSize is the block range of one eth_getLogs, hosted nodes reject bigger ranges or cap the number of logs
+const logsWindowSize = 5000
+
+// getLogsInWindows gets logs matching filter from fromBlock to toBlock in windows of logsWindowSize blocks,
+// windows are sent in batch requests and the logs are in block order
+func (c *EthereumClient) getLogsInWindows(filter types.LogFilter, fromBlock uint64, toBlock uint64) ([]types.NodeLog, error){
+	if fromBlock > toBlock {
+		return nil, nil
+	}
+	windows := make([][]types.NodeLog, (toBlock-fromBlock)/logsWindowSize+1)
+	elems := make([]BatchElem, len(windows))
+	for i := range windows {
+		start := fromBlock + uint64(i)*logsWindowSize
+		end := start + logsWindowSize - 1
+		if end > toBlock {
+			end = toBlock
+		}
+		query := logQuery{LogFilter: filter, FromBlock: types.BlockParam(fmt.Sprintf("0x%x", start)), ToBlock: types.BlockParam(fmt.Sprintf("0x%x", end))}
+		elems[i] = BatchElem{Method: "eth_getLogs", Params: []interface{}{query}, Result: &windows[i]}
+	}
+	if err := c.BatchCall(elems); err != nil {
+		return nil, err
+	}
+	var logs []types.NodeLog
+	for i, elem := range elems {
+		if elem.Error != nil {
+			start := fromBlock + uint64(i)*logsWindowSize
+			return nil, fmt.Errorf("get logs from block %d occured error: %s", start, elem.Error)
+		}
+		logs = append(logs, windows[i]...)
+	}
+	return logs, nil
+}
+
+// latestBlockNumber is the number of node's latest block
+func (c *EthereumClient) latestBlockNumber() (uint64, error){
+	blockNum, err := c.GetBlockNumber()
+	if err != nil {
+		return 0, err
 	}
-	return
+	return utils.HexStrToUInt64(blockNum), nil
 }
 
-func(c *EthereumClient) GetErc20Decimals(contract *common.Address, token *types.Erc20Token, wg *sync.WaitGroup,errs chan error) {
-	defer wg.Done()
-	ret, err := c.callVariable(contract, types.Erc20FunctionInterface.Decimals.MethodId)
-	if err != nil{
-		errs <- err
+// GetApprovals finds the latest Approval log of every token and spender of owner since fromBlock,
+// then gets their allowances and token infos in batch requests, only allowances which are not 0 are returned.
+func (c *EthereumClient) GetApprovals(owner common.Address, fromBlock uint64) ([]types.Erc20Approval, error){
+	filter := types.LogFilter{
+		Topics: [][]common.Hash{{types.Erc20ApprovalTopic}, {common.BytesToHash(owner.Bytes())}},
 	}
-	resb := utils.HexStrToBigInt(ret)
-	if err != nil{
-		errs <- err
+	latestBlock, err := c.latestBlockNumber()
+	if err != nil {
+		return nil, err
 	}
-	res := int(resb.Int64())
-	c.mux.Lock()
-	token.Decimals = res
-	c.mux.Unlock()
-	return
+	logs, err := c.getLogsInWindows(filter, fromBlock, latestBlock)
+	if err != nil {
+		return nil, err
+	}
+	type pair struct {
+		token   common.Address
+		spender common.Address
+	}
+	var pairs []pair
+	latest := make(map[pair]types.NodeLog)
+	for _, log := range logs {
+		// erc721 Approval has the token id as the 4th topic
+		if log.Removed || len(log.Topics) != 3 {
+			continue
+		}
+		p := pair{common.HexToAddress(log.Address), common.HexToAddress(log.Topics[2])}
+		if _, ok := latest[p]; !ok {
+			pairs = append(pairs, p)
+		}
+		latest[p] = log
+	}
+	if len(pairs) == 0 {
+		return []types.Erc20Approval{}, nil
+	}
+	results := make([]string, len(pairs))
+	elems := make([]BatchElem, len(pairs))
+	for i, p := range pairs {
+		data := utils.EncodeABI(types.Erc20FunctionInterface.Allowance.MethodId, owner.Bytes(), p.spender.Bytes())
+		elems[i] = BatchElem{
+			Method: "eth_call",
+			Params: []interface{}{erc20CallRequest(&pairs[i].token, data), types.Latest},
+			Result: &results[i],
+		}
+	}
+	err = c.BatchCall(elems)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make(map[common.Address]*types.Erc20Token)
+	var contracts []common.Address
+	for i, p := range pairs {
+		if _, ok := tokens[p.token]; !ok && elems[i].Error == nil && utils.HexStrToBigInt(results[i]).Sign() != 0 {
+			tokens[p.token] = nil
+			contracts = append(contracts, p.token)
+		}
+	}
+	infos, errs, err := c.GetErc20Infos(contracts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range contracts {
+		if errs[i] != nil {
+			infos[i] = &types.Erc20Token{Address: &contracts[i]}
+		}
+		tokens[contracts[i]] = infos[i]
+	}
+	approvals := []types.Erc20Approval{}
+	for i, p := range pairs {
+		if elems[i].Error != nil {
+			continue
+		}
+		allowance := utils.HexStrToBigInt(results[i])
+		if allowance.Sign() == 0 {
+			continue
+		}
+		token := tokens[p.token]
+		log := latest[p]
+		approvals = append(approvals, types.Erc20Approval{
+			Token:           token,
+			Spender:         p.spender,
+			Allowance:       (*types.BigIntHex)(allowance),
+			BlockNumber:     log.BlockNumber,
+			TransactionHash: log.TransactionHash,
+		})
+	}
+	return approvals, nil
 }
 
-func (c *EthereumClient) GetErc20Info(contract *common.Address) (token *types.Erc20Token, err error){
-	var wg sync.WaitGroup
-	errs := make(chan error)
-	wgDone := make(chan bool)
-	token = &types.Erc20Token{Address: contract}
-	wg.Add(3)
-	go c.GetErc20Name(contract, token, &wg, errs)
-	go c.GetErc20Symbol(contract, token, &wg, errs)
-	go c.GetErc20Decimals(contract, token, &wg, errs)
-	wg.Wait()
-	close(wgDone)
-	select {
-	case <-wgDone:
-		close(errs)
-		return token, nil
-		break
-	case err := <-errs:
-		close(errs)
-		return nil ,err
+// GetPermitDomain gets the eip712 domain of token's permit and owner's nonce, version() is optional
+// since many tokens only have it in DOMAIN_SEPARATOR.
+func (c *EthereumClient) GetPermitDomain(token *common.Address, owner common.Address) (*types.PermitDomain, error){
+	nonce, err := c.GetCall(erc20CallRequest(token, utils.EncodeABI(types.PermitFunctionInterface.Nonces.MethodId, owner.Bytes())), types.Latest)
+	if err != nil {
+		return nil, fmt.Errorf("call nonces occured error, the token may not support permit: %s", err)
 	}
-	return
+	if len(utils.HexStrToBytes(nonce)) != 32 {
+		return nil, fmt.Errorf("token %s has no nonces, it may not support permit", token.String())
+	}
+	separator, err := c.GetCall(erc20CallRequest(token, types.PermitFunctionInterface.DomainSeparator.MethodId), types.Latest)
+	if err != nil {
+		return nil, fmt.Errorf("call DOMAIN_SEPARATOR occured error, the token may not support permit: %s", err)
+	}
+	name, err := c.GetCall(erc20CallRequest(token, types.Erc20FunctionInterface.Name.MethodId), types.Latest)
+	if err != nil {
+		return nil, fmt.Errorf("call name occured error: %s", err)
+	}
+	domain := &types.PermitDomain{
+		ChainId:           (*types.BigIntHex)(c.network.ChainId),
+		VerifyingContract: *token,
+		DomainSeparator:   separator,
+		Owner:             owner,
+		Nonce:             (*types.BigIntHex)(utils.HexStrToBigInt(nonce)),
+	}
+	dec, err := utils.DecodeSingle(name, "string")
+	if err != nil {
+		return nil, fmt.Errorf("decode name occured error: %s", err)
+	}
+	domain.Name = dec.(string)
+	if version, err := c.GetCall(erc20CallRequest(token, types.PermitFunctionInterface.Version.MethodId), types.Latest); err == nil {
+		if dec, err := utils.DecodeSingle(version, "string"); err == nil {
+			domain.Version = dec.(string)
+		}
+	}
+	return domain, nil
+}
+
+// GetTransactionParams gets nonce, gas price, base fee, max priority fee and estimated gas of transaction
+// in one batch request, BaseFee and MaxPriorityFee are nil if the node doesn't support eip1559.
+func (c *EthereumClient) GetTransactionParams(transaction *types.TransactionRequest, blockParam types.BlockParam, estimate bool) (params types.TransactionParams, err error){
+	var nonce, gasPrice, tip, gas string
+	var block types.NodeBlock
+	elems := []BatchElem{
+		{Method: "eth_getTransactionCount", Params: []interface{}{transaction.From, blockParam}, Result: &nonce},
+		{Method: "eth_gasPrice", Result: &gasPrice},
+		{Method: "eth_getBlockByNumber", Params: []interface{}{types.Latest, false}, Result: &block},
+		{Method: "eth_maxPriorityFeePerGas", Result: &tip},
+	}
+	if estimate {
+		elems = append(elems, BatchElem{Method: "eth_estimateGas", Params: []interface{}{transaction}, Result: &gas})
+	}
+	err = c.BatchCall(elems)
+	if err != nil {
+		return
+	}
+	for i, elem := range elems {
+		// eth_maxPriorityFeePerGas is not supported by pre-london nodes
+		if elem.Error != nil && i != 3 {
+			return params, elem.Error
+		}
+	}
+	params.Nonce = utils.HexStrToUInt64(nonce)
+	params.GasPrice = utils.HexStrToBigInt(gasPrice)
+	if block.BaseFeePerGas != nil {
+		params.BaseFee = (*big.Int)(block.BaseFeePerGas)
+		if elems[3].Error == nil {
+			params.MaxPriorityFee = utils.HexStrToBigInt(tip)
+		}
+	}
+	params.GasLimit = utils.HexStrToUInt64(gas)
+	return params, nil
 }
 
 func (c *EthereumClient) GetNormalTransactions(startBlock, endBlock int, desc bool, address common.Address) (transactions []types.EsNormalTransaction, err error) {
@@ -0,0 +1,163 @@
+package ethclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// TokenCache keeps infos of discovered tokens and which tokens an address received in which blocks,
+// so discovery only scans blocks it hasn't scanned and doesn't resolve a token twice. It's saved in a json file keyed
+// by network name, an empty path keeps it in memory only.
+type TokenCache struct {
+	path     string
+	mux      sync.Mutex
+	networks map[string]*tokenCacheNetwork
+}
+
+type tokenCacheNetwork struct {
+	Tokens   map[string]*types.Erc20Token `json:"tokens"`
+	Accounts map[string]*tokenCacheAccount `json:"accounts"`
+}
+
+// tokenCacheAccount is the token contracts address received a transfer from, scanned from From up to Block
+type tokenCacheAccount struct {
+	From      uint64           `json:"from"`
+	Block     uint64           `json:"block"`
+	Contracts []common.Address `json:"contracts"`
+}
+
+func NewTokenCache(path string) *TokenCache {
+	return &TokenCache{
+		path:     path,
+		networks: map[string]*tokenCacheNetwork{},
+	}
+}
+
+func (tc *TokenCache) network(name string) *tokenCacheNetwork {
+	network, ok := tc.networks[name]
+	if !ok || network == nil {
+		network = &tokenCacheNetwork{}
+		tc.networks[name] = network
+	}
+	if network.Tokens == nil {
+		network.Tokens = map[string]*types.Erc20Token{}
+	}
+	if network.Accounts == nil {
+		network.Accounts = map[string]*tokenCacheAccount{}
+	}
+	return network
+}
+
+func (tc *TokenCache) Token(network string, contract common.Address) (*types.Erc20Token, bool) {
+	tc.mux.Lock()
+	defer tc.mux.Unlock()
+	token, ok := tc.network(network).Tokens[strings.ToLower(contract.String())]
+	return token, ok
+}
+
+func (tc *TokenCache) AddToken(network string, token *types.Erc20Token) {
+	tc.mux.Lock()
+	defer tc.mux.Unlock()
+	tc.network(network).Tokens[strings.ToLower(token.Address.String())] = token
+}
+
+// Contracts returns the token contracts address received
+func (tc *TokenCache) Contracts(network string, address common.Address) []common.Address {
+	tc.mux.Lock()
+	defer tc.mux.Unlock()
+	account, ok := tc.network(network).Accounts[strings.ToLower(address.String())]
+	if !ok {
+		return nil
+	}
+	return append([]common.Address{}, account.Contracts...)
+}
+
+// Scanned returns the blocks contracts of address are scanned from and up to, ok is false if it's never scanned
+func (tc *TokenCache) Scanned(network string, address common.Address) (from uint64, to uint64, ok bool) {
+	tc.mux.Lock()
+	defer tc.mux.Unlock()
+	account, ok := tc.network(network).Accounts[strings.ToLower(address.String())]
+	if !ok {
+		return 0, 0, false
+	}
+	return account.From, account.Block, true
+}
+
+// AddContracts adds contracts which are not known yet and extends the scanned blocks of address to fromBlock and toBlock,
+// the blocks between the scanned ones and fromBlock or toBlock must have been scanned too
+func (tc *TokenCache) AddContracts(network string, address common.Address, contracts []common.Address, fromBlock uint64, toBlock uint64) {
+	tc.mux.Lock()
+	defer tc.mux.Unlock()
+	accounts := tc.network(network).Accounts
+	key := strings.ToLower(address.String())
+	account, ok := accounts[key]
+	if !ok {
+		account = &tokenCacheAccount{From: fromBlock, Block: toBlock}
+		accounts[key] = account
+	}
+	known := make(map[common.Address]bool, len(account.Contracts))
+	for _, contract := range account.Contracts {
+		known[contract] = true
+	}
+	for _, contract := range contracts {
+		if !known[contract] {
+			known[contract] = true
+			account.Contracts = append(account.Contracts, contract)
+		}
+	}
+	if fromBlock < account.From {
+		account.From = fromBlock
+	}
+	if toBlock > account.Block {
+		account.Block = toBlock
+	}
+}
+
+// Load reads the file again, so tokens found by another process are seen
+func (tc *TokenCache) Load() error {
+	tc.mux.Lock()
+	defer tc.mux.Unlock()
+	if tc.path == "" {
+		return nil
+	}
+	b, err := ioutil.ReadFile(tc.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read token cache file error: %s", err)
+	}
+	networks := map[string]*tokenCacheNetwork{}
+	if err := json.Unmarshal(b, &networks); err != nil {
+		return fmt.Errorf("token cache file %s is broken: %s", tc.path, err)
+	}
+	tc.networks = networks
+	return nil
+}
+
+func (tc *TokenCache) Save() error {
+	tc.mux.Lock()
+	defer tc.mux.Unlock()
+	if tc.path == "" {
+		return nil
+	}
+	b, err := json.MarshalIndent(tc.networks, "", "	")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(tc.path), 0700); err != nil {
+		return fmt.Errorf("create token cache file dir error: %s", err)
+	}
+	tmp := tc.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return fmt.Errorf("write token cache file error: %s", err)
+	}
+	return os.Rename(tmp, tc.path)
+}
@@ -0,0 +1,439 @@
+package ethclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/tn606024/ethwallet/types"
+	"sync"
+	"time"
+)
+
+var (
+	wsCallTimeout       = 30 * time.Second
+	wsPingInterval      = 30 * time.Second
+	wsReconnectInterval = 3 * time.Second
+	wsMaxReconnectDelay = time.Minute
+	// wsReadTimeout is how long a connection may send neither a message nor a pong, a half-open connection
+	// is noticed by it even if writing pings still succeeds
+	wsReadTimeout = wsPingInterval + wsCallTimeout
+)
+
+// WsClient is a json-rpc client over websocket which supports eth_subscribe. When the connection drops
+// it keeps reconnecting and subscribes again, so subscriptions survive but may miss events in between.
+type WsClient struct {
+	url      string
+	conn     *websocket.Conn
+	writeMux sync.Mutex
+	mux      sync.Mutex
+	id       int
+	pending  map[int]*wsRequest
+	subs     map[string]*Subscription
+	closing  chan struct{}
+	closed   bool
+}
+
+// wsRequest waits for a response, sub is registered by readLoop as soon as eth_subscribe
+// returns, so notifications right after the response are not dropped
+type wsRequest struct {
+	res chan *wsMessage
+	sub *Subscription
+}
+
+type wsMessage struct {
+	ID     *int                 `json:"id"`
+	Method string               `json:"method"`
+	Result json.RawMessage      `json:"result"`
+	Error  *types.EthereumError `json:"error"`
+	Params struct {
+		Subscription string          `json:"subscription"`
+		Result       json.RawMessage `json:"result"`
+	} `json:"params"`
+}
+
+// Subscription is an eth_subscribe of WsClient, Err receives reconnect notices and
+// Done is closed after Unsubscribe or Close.
+type Subscription struct {
+	client *WsClient
+	params []interface{}
+	id     string
+	data   chan json.RawMessage
+	err    chan error
+	quit   chan struct{}
+	once   sync.Once
+}
+
+func DialWebsocket(url string) (*WsClient, error) {
+	if url == "" {
+		return nil, fmt.Errorf("ws_url is not set in config.json")
+	}
+	c := &WsClient{
+		url:     url,
+		pending: make(map[int]*wsRequest),
+		subs:    make(map[string]*Subscription),
+		closing: make(chan struct{}),
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("dial websocket error: %s", err)
+	}
+	c.start(conn)
+	return c, nil
+}
+
+func (c *WsClient) start(conn *websocket.Conn) {
+	c.mux.Lock()
+	c.conn = conn
+	c.mux.Unlock()
+	conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+	})
+	go c.readLoop(conn)
+	go c.pingLoop(conn)
+}
+
+func (c *WsClient) Close() {
+	c.mux.Lock()
+	if c.closed {
+		c.mux.Unlock()
+		return
+	}
+	c.closed = true
+	close(c.closing)
+	conn := c.conn
+	subs := c.subs
+	c.subs = make(map[string]*Subscription)
+	c.mux.Unlock()
+	conn.Close()
+	for _, sub := range subs {
+		sub.close()
+	}
+}
+
+// Call sends a json-rpc request over websocket and waits for its response
+func (c *WsClient) Call(method string, params []interface{}, result interface{}) error {
+	return c.call(method, params, result, nil)
+}
+
+func (c *WsClient) call(method string, params []interface{}, result interface{}, sub *Subscription) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	c.mux.Lock()
+	if c.closed {
+		c.mux.Unlock()
+		return fmt.Errorf("websocket client is closed")
+	}
+	c.id++
+	id := c.id
+	resCh := make(chan *wsMessage, 1)
+	c.pending[id] = &wsRequest{res: resCh, sub: sub}
+	conn := c.conn
+	c.mux.Unlock()
+	defer func() {
+		c.mux.Lock()
+		delete(c.pending, id)
+		c.mux.Unlock()
+	}()
+
+	c.writeMux.Lock()
+	err := conn.WriteJSON(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"method":  method,
+		"params":  params,
+	})
+	c.writeMux.Unlock()
+	if err != nil {
+		return fmt.Errorf("websocket write error: %s", err)
+	}
+	select {
+	case res := <-resCh:
+		if res == nil {
+			return fmt.Errorf("websocket connection is lost")
+		}
+		if res.Error != nil {
+			return fmt.Errorf("res error msg: %s", res.Error.Message)
+		}
+		return json.Unmarshal(res.Result, result)
+	case <-time.After(wsCallTimeout):
+		return fmt.Errorf("%s timeout", method)
+	case <-c.closing:
+		return fmt.Errorf("websocket client is closed")
+	}
+}
+
+// newSubscription sends eth_subscribe with params, e.g. "newHeads" or "logs", filter
+func (c *WsClient) newSubscription(params ...interface{}) (*Subscription, error) {
+	sub := &Subscription{
+		client: c,
+		params: params,
+		data:   make(chan json.RawMessage, 128),
+		err:    make(chan error, 1),
+		quit:   make(chan struct{}),
+	}
+	if err := c.subscribe(sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (c *WsClient) subscribe(sub *Subscription) error {
+	var id string
+	err := c.call("eth_subscribe", sub.params, &id, sub)
+	if err != nil {
+		return fmt.Errorf("eth_subscribe occured error: %s", err)
+	}
+	return nil
+}
+
+func (c *WsClient) readLoop(conn *websocket.Conn) {
+	for {
+		_, b, err := conn.ReadMessage()
+		if err != nil {
+			c.reconnect(conn)
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+		var msg wsMessage
+		if err := json.Unmarshal(b, &msg); err != nil {
+			continue
+		}
+		if msg.Method == "eth_subscription" {
+			c.mux.Lock()
+			sub := c.subs[msg.Params.Subscription]
+			c.mux.Unlock()
+			if sub != nil {
+				sub.deliver(msg.Params.Result)
+			}
+			continue
+		}
+		if msg.ID != nil {
+			c.mux.Lock()
+			req := c.pending[*msg.ID]
+			if req != nil && req.sub != nil && msg.Error == nil {
+				var id string
+				if json.Unmarshal(msg.Result, &id) == nil {
+					req.sub.id = id
+					c.subs[id] = req.sub
+				}
+			}
+			c.mux.Unlock()
+			if req != nil {
+				req.res <- &msg
+			}
+		}
+	}
+}
+
+func (c *WsClient) pingLoop(conn *websocket.Conn) {
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.writeMux.Lock()
+			err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsCallTimeout))
+			c.writeMux.Unlock()
+			if err != nil {
+				conn.Close()
+				return
+			}
+		case <-c.closing:
+			return
+		}
+	}
+}
+
+// reconnect fails pending calls of the lost connection, dials again with backoff and resubscribes
+func (c *WsClient) reconnect(lost *websocket.Conn) {
+	lost.Close()
+	c.mux.Lock()
+	if c.closed {
+		c.mux.Unlock()
+		return
+	}
+	for id, req := range c.pending {
+		// a caller which timed out may have a response buffered already, it never reads another
+		select {
+		case req.res <- nil:
+		default:
+		}
+		delete(c.pending, id)
+	}
+	subs := c.subs
+	c.subs = make(map[string]*Subscription)
+	c.mux.Unlock()
+
+	delay := wsReconnectInterval
+	for {
+		select {
+		case <-c.closing:
+			return
+		case <-time.After(delay):
+		}
+		conn, _, err := websocket.DefaultDialer.Dial(c.url, nil)
+		if err != nil {
+			if delay *= 2; delay > wsMaxReconnectDelay {
+				delay = wsMaxReconnectDelay
+			}
+			continue
+		}
+		c.start(conn)
+		break
+	}
+	for _, sub := range subs {
+		if sub.isDone() {
+			continue
+		}
+		err := c.subscribe(sub)
+		if err != nil {
+			sub.notify(fmt.Errorf("resubscribe after reconnecting failed: %s", err))
+			sub.close()
+			continue
+		}
+		sub.notify(fmt.Errorf("websocket reconnected, events in between may be missed"))
+	}
+}
+
+func (s *Subscription) Done() <-chan struct{} {
+	return s.quit
+}
+
+func (s *Subscription) Err() <-chan error {
+	return s.err
+}
+
+func (s *Subscription) Unsubscribe() error {
+	c := s.client
+	c.mux.Lock()
+	id := s.id
+	delete(c.subs, id)
+	c.mux.Unlock()
+	s.close()
+	var ok bool
+	return c.Call("eth_unsubscribe", []interface{}{id}, &ok)
+}
+
+// deliver never blocks readLoop, a slow subscriber would stall responses of every call. A notification
+// which doesn't fit in the buffer is dropped with a notice.
+func (s *Subscription) deliver(data json.RawMessage) {
+	select {
+	case s.data <- data:
+	case <-s.quit:
+	default:
+		s.notify(fmt.Errorf("subscriber is too slow, notifications are dropped"))
+	}
+}
+
+// notify never blocks, a notice is dropped if the last one is not read yet
+func (s *Subscription) notify(err error) {
+	select {
+	case s.err <- err:
+	default:
+	}
+}
+
+func (s *Subscription) close() {
+	s.once.Do(func() {
+		close(s.quit)
+	})
+}
+
+func (s *Subscription) isDone() bool {
+	select {
+	case <-s.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+// SubscribeNewHeads streams headers of new blocks, the channel is closed when subscription ends
+func (c *WsClient) SubscribeNewHeads() (<-chan types.NodeBlock, *Subscription, error) {
+	sub, err := c.newSubscription("newHeads")
+	if err != nil {
+		return nil, nil, err
+	}
+	ch := make(chan types.NodeBlock)
+	go func() {
+		defer close(ch)
+		for {
+			var head types.NodeBlock
+			if !sub.next(&head) {
+				return
+			}
+			select {
+			case ch <- head:
+			case <-sub.quit:
+				return
+			}
+		}
+	}()
+	return ch, sub, nil
+}
+
+// SubscribeLogs streams logs matching filter, logs removed by a reorg are sent again with Removed set
+func (c *WsClient) SubscribeLogs(filter types.LogFilter) (<-chan types.NodeLog, *Subscription, error) {
+	sub, err := c.newSubscription("logs", filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	ch := make(chan types.NodeLog)
+	go func() {
+		defer close(ch)
+		for {
+			var log types.NodeLog
+			if !sub.next(&log) {
+				return
+			}
+			select {
+			case ch <- log:
+			case <-sub.quit:
+				return
+			}
+		}
+	}()
+	return ch, sub, nil
+}
+
+// SubscribePendingTransactions streams hashes of transactions entering the node's pool
+func (c *WsClient) SubscribePendingTransactions() (<-chan string, *Subscription, error) {
+	sub, err := c.newSubscription("newPendingTransactions")
+	if err != nil {
+		return nil, nil, err
+	}
+	ch := make(chan string)
+	go func() {
+		defer close(ch)
+		for {
+			var hash string
+			if !sub.next(&hash) {
+				return
+			}
+			select {
+			case ch <- hash:
+			case <-sub.quit:
+				return
+			}
+		}
+	}()
+	return ch, sub, nil
+}
+
+// next decodes the next notification into result, it returns false when subscription ends
+func (s *Subscription) next(result interface{}) bool {
+	for {
+		select {
+		case data := <-s.data:
+			if err := json.Unmarshal(data, result); err != nil {
+				s.notify(fmt.Errorf("decode notification error: %s", err))
+				continue
+			}
+			return true
+		case <-s.quit:
+			return false
+		}
+	}
+}
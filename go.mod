@@ -5,7 +5,9 @@ go 1.14
 require (
 	github.com/ethereum/go-ethereum v1.9.18
 	github.com/gin-gonic/gin v1.6.3
+	github.com/gorilla/websocket v1.4.2
 	github.com/pborman/uuid v0.0.0-20170112150404-1b00554d8222
+	github.com/tyler-smith/go-bip39 v1.0.1-0.20181017060643-dbb3b84ba2ef
 	github.com/urfave/cli v1.22.1
 	github.com/urfave/cli/v2 v2.2.0
 	golang.org/x/crypto v0.0.0-20200728195943-123391ffb6de
@@ -0,0 +1,116 @@
+package rlp
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrCanonSize        = errors.New("rlp: non-canonical size information")
+	ErrValueTooLarge    = errors.New("rlp: value size exceeds available input length")
+	ErrMoreThanOneValue = errors.New("rlp: input contains more than one value")
+	ErrEmptyInput       = errors.New("rlp: empty input")
+)
+
+// Decode decodes exactly one item, a byte string is returned as []byte and a list as []interface{}
+// which elements are []byte or []interface{} again. Non-canonical encodings are rejected.
+func Decode(b []byte) (interface{}, error) {
+	item, rest, err := Split(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, ErrMoreThanOneValue
+	}
+	return item, nil
+}
+
+// DecodeList decodes exactly one item which must be a list
+func DecodeList(b []byte) ([]interface{}, error) {
+	item, err := Decode(b)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := item.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("rlp: expected list, got string")
+	}
+	return list, nil
+}
+
+// Split decodes the first item of b and returns the remaining bytes
+func Split(b []byte) (item interface{}, rest []byte, err error) {
+	isList, content, rest, err := readHeader(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !isList {
+		return content, rest, nil
+	}
+	list := []interface{}{}
+	for len(content) > 0 {
+		var elem interface{}
+		elem, content, err = Split(content)
+		if err != nil {
+			return nil, nil, err
+		}
+		list = append(list, elem)
+	}
+	return list, rest, nil
+}
+
+func readHeader(b []byte) (isList bool, content []byte, rest []byte, err error) {
+	if len(b) == 0 {
+		return false, nil, nil, ErrEmptyInput
+	}
+	prefix := b[0]
+	var offset, size int
+	switch {
+	case prefix < strOffset.short:
+		return false, b[:1], b[1:], nil
+	case prefix <= strOffset.long:
+		offset, size = 1, int(prefix-strOffset.short)
+		// a single byte below 0x80 must be encoded as itself
+		if size == 1 && len(b) > 1 && b[1] < strOffset.short {
+			return false, nil, nil, ErrCanonSize
+		}
+	case prefix < arrOffset.short:
+		offset, size, err = readLongSize(b, int(prefix-strOffset.long))
+	case prefix <= arrOffset.long:
+		offset, size = 1, int(prefix-arrOffset.short)
+		isList = true
+	default:
+		offset, size, err = readLongSize(b, int(prefix-arrOffset.long))
+		isList = true
+	}
+	if err != nil {
+		return false, nil, nil, err
+	}
+	if offset+size > len(b) || offset+size < offset {
+		return false, nil, nil, ErrValueTooLarge
+	}
+	return isList, b[offset : offset+size], b[offset+size:], nil
+}
+
+func readLongSize(b []byte, sizesize int) (offset int, size int, err error) {
+	if 1+sizesize > len(b) {
+		return 0, 0, ErrValueTooLarge
+	}
+	if b[1] == 0 {
+		return 0, 0, ErrCanonSize
+	}
+	if sizesize > 8 {
+		return 0, 0, ErrValueTooLarge
+	}
+	var s uint64
+	for _, c := range b[1 : 1+sizesize] {
+		s = s<<8 | uint64(c)
+	}
+	if s < 56 {
+		return 0, 0, ErrCanonSize
+	}
+	if s > uint64(len(b)) {
+		return 0, 0, ErrValueTooLarge
+	}
+	return 1 + sizesize, int(s), nil
+}
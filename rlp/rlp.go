@@ -1,6 +1,9 @@
 package rlp
 
-import "github.com/tn606024/ethwallet/utils"
+import (
+	"fmt"
+	"github.com/tn606024/ethwallet/utils"
+)
 
 type Offset struct {
 	short  byte
@@ -25,7 +28,50 @@ func EncodeList(list [][]byte) (res []byte){
 	}
 	temp := utils.ConcatCopy(buf...)
 
-	return encodeString(temp, arrOffset)
+	return encodeList(temp)
+}
+
+// RawValue is an already encoded item, Encode writes it unchanged
+type RawValue []byte
+
+// Encode encodes a byte string or a nested list, item can be []byte, [][]byte, RawValue or []interface{}
+// which elements are one of these types again.
+func Encode(item interface{}) (res []byte){
+	switch v := item.(type) {
+	case RawValue:
+		return v
+	case []byte:
+		return encodeString(v, strOffset)
+	case [][]byte:
+		return EncodeList(v)
+	case []interface{}:
+		var buf [][]byte
+		for _, elem := range v {
+			buf = append(buf, Encode(elem))
+		}
+		return encodeList(utils.ConcatCopy(buf...))
+	default:
+		panic(fmt.Errorf("rlp: type %T is not supported", item))
+	}
+}
+
+// EncodeAccessList encodes an eip2930 access list [[address, [storageKey, ...]], ...],
+// storageKeys[i] are the storage keys of addresses[i].
+func EncodeAccessList(addresses [][]byte, storageKeys [][][]byte) []byte {
+	var buf [][]byte
+	for i, address := range addresses {
+		var keys [][]byte
+		if i < len(storageKeys) {
+			keys = storageKeys[i]
+		}
+		buf = append(buf, encodeList(utils.ConcatCopy(encodeString(address, strOffset), EncodeList(keys))))
+	}
+	return encodeList(utils.ConcatCopy(buf...))
+}
+
+func encodeList(b []byte) (res []byte) {
+	head := encodeStringHeader(len(b), arrOffset)
+	return append(head, b...)
 }
 
 func encodeString(b []byte, offset *Offset) (res []byte) {
@@ -2,43 +2,48 @@ package server
 
 import (
 	"fmt"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
 	"github.com/tn606024/ethwallet/ethclient"
 	"github.com/tn606024/ethwallet/types"
 	"github.com/tn606024/ethwallet/utils"
+	"math/big"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 )
 
 
 func SetupServer(network *types.Network, port int) *gin.Engine{
-	var networkUrl *types.NetworkUrl
 	path := types.LoadConfigPath()
 	config, err := types.ImportConfig(path)
-
-	erc20list := config.Erc20List
 	if err != nil {
 		fmt.Printf("Import Config occured error: %s", err)
 		os.Exit(1)
 	}
-	switch network.Name {
-	case "mainnet":
-		networkUrl = config.Mainnet
-	case "ropsten":
-		networkUrl = config.Ropsten
-	case "rinkeby":
-		networkUrl =config.Rinkeby
-	default:
-		fmt.Printf("can't find netwokk url in config\n")
+	// the server's network may not be the one in config.json
+	config.Network = network
+	err = config.LoadErc20List()
+	if err != nil {
+		fmt.Printf("warning: load token lists occured error, their tokens are skipped: %s\n", err)
+	}
+	erc20list := config.Erc20List
+	networkUrl, err := config.GetNetworkUrl(network.Name)
+	if err != nil {
+		fmt.Printf("%s\n", err)
 		os.Exit(1)
 	}
-	client := ethclient.NewEthereumClient(networkUrl.NodeUrl, networkUrl.EtherscanApiUrl, config.EtherscanApiKey, config.Network)
+	client := ethclient.NewEthereumClient(networkUrl.NodeUrl, networkUrl.EtherscanApiUrl, config.EtherscanApiKey, network)
+	tokenCache := ethclient.NewTokenCache(config.TokenCacheFile)
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.Default()
 	r.GET("/balance", func(c *gin.Context){
 		param := c.DefaultQuery("param","latest")
-		addr := utils.HexToAddress(c.Query("address"))
+		addr, ok := queryAddress(c, client, "address")
+		if !ok {
+			return
+		}
 		blockParam, err := types.NewBlockParam(param)
 		if err != nil {
 			c.String(http.StatusBadRequest, "param is illegal: %s", param)
@@ -56,7 +61,10 @@ func SetupServer(network *types.Network, port int) *gin.Engine{
 	})
 	r.GET("/erc20balance", func(c *gin.Context){
 
-		addr := utils.HexToAddress(c.Query("address"))
+		addr, ok := queryAddress(c, client, "address")
+		if !ok {
+			return
+		}
 		listbalance, err := client.GetErc20ListBalance(erc20list, addr)
 		if err != nil {
 			c.String(http.StatusInternalServerError, "server error occured: %s", err)
@@ -66,6 +74,207 @@ func SetupServer(network *types.Network, port int) *gin.Engine{
 			"result": listbalance,
 		})
 	})
+	r.POST("/balances", func(c *gin.Context){
+		var req types.BalancesRequest
+		err := c.BindJSON(&req)
+		if err != nil{
+			c.String(http.StatusBadRequest, "request is illegal")
+			return
+		}
+		if len(req.Addresses) == 0 || len(req.Addresses) > types.MaxBalancesAddresses {
+			c.String(http.StatusBadRequest, "addresses must have 1 to %d addresses, got %d", types.MaxBalancesAddresses, len(req.Addresses))
+			return
+		}
+		blockParam, err := req.BlockParam()
+		if err != nil {
+			c.String(http.StatusBadRequest, "block is illegal: %s", err)
+			return
+		}
+		addresses := make([]common.Address, len(req.Addresses))
+		for i, s := range req.Addresses {
+			addresses[i], err = client.ResolveAddress(s)
+			if err != nil {
+				c.String(http.StatusBadRequest, "addresses[%d] is illegal: %s", i, err)
+				return
+			}
+		}
+		if len(req.Assets) == 0 {
+			req.Assets = []string{types.EtherSymbol}
+		}
+		tokens, err := balanceTokens(client, network, erc20list, req.Assets)
+		if err != nil {
+			c.String(http.StatusBadRequest, "assets is illegal: %s", err)
+			return
+		}
+		balances, err := client.GetAssetBalances(addresses, tokens, blockParam)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "server error occured: %s", err)
+			return
+		}
+		res := make(types.Balances, len(addresses))
+		for i, address := range addresses {
+			amounts := make(map[string]*types.Amount, len(tokens))
+			for j, token := range tokens {
+				decimals := 18
+				if token != nil {
+					decimals = token.Decimals
+				}
+				amounts[req.Assets[j]] = types.NewAmount(balances[i][j], decimals)
+			}
+			res[address.String()] = amounts
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"result": res,
+		})
+	})
+	r.GET("/discoverbalance", func(c *gin.Context){
+		addr, ok := queryAddress(c, client, "address")
+		if !ok {
+			return
+		}
+		source := c.DefaultQuery("source", ethclient.DiscoverEtherscan)
+		sFromBlock := c.DefaultQuery("fromblock", "0")
+		fromBlock, err := strconv.ParseUint(sFromBlock, 10, 64)
+		if err != nil {
+			c.String(http.StatusBadRequest, "fromblock is illegal, %s", sFromBlock)
+			return
+		}
+		balances, err := client.GetDiscoveredErc20Balances(addr, source, fromBlock, tokenCache)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "server error occured: %s", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"result": balances,
+		})
+	})
+	r.GET("/nfts", func(c *gin.Context){
+		addr, ok := queryAddress(c, client, "address")
+		if !ok {
+			return
+		}
+		sFromBlock := c.DefaultQuery("fromblock", "0")
+		fromBlock, err := strconv.ParseUint(sFromBlock, 10, 64)
+		if err != nil {
+			c.String(http.StatusBadRequest, "fromblock is illegal, %s", sFromBlock)
+			return
+		}
+		nfts, err := client.GetNfts(addr, fromBlock)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "server error occured: %s", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"result": nfts,
+		})
+	})
+	r.GET("/nft", func(c *gin.Context){
+		contract, ok := queryAddress(c, client, "contract")
+		if !ok {
+			return
+		}
+		owner, ok := queryAddress(c, client, "owner")
+		if !ok {
+			return
+		}
+		sTokenId := c.Query("tokenid")
+		tokenId, err := utils.ToBigInt(sTokenId)
+		if err != nil || tokenId.Sign() < 0 {
+			c.String(http.StatusBadRequest, "tokenid is illegal, %s", sTokenId)
+			return
+		}
+		nft, err := client.GetNft(&contract, tokenId, owner)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "server error occured: %s", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"result": nft,
+		})
+	})
+	r.GET("/approvals", func(c *gin.Context){
+		addr, ok := queryAddress(c, client, "address")
+		if !ok {
+			return
+		}
+		sFromBlock := c.DefaultQuery("fromblock", "0")
+		fromBlock, err := strconv.ParseUint(sFromBlock, 10, 64)
+		if err != nil {
+			c.String(http.StatusBadRequest, "fromblock is illegal, %s", sFromBlock)
+			return
+		}
+		approvals, err := client.GetApprovals(addr, fromBlock)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "server error occured: %s", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"result": approvals,
+		})
+	})
+	r.GET("/permitdomain", func(c *gin.Context){
+		token, ok := queryAddress(c, client, "token")
+		if !ok {
+			return
+		}
+		if token == (common.Address{}) {
+			c.String(http.StatusBadRequest, "token is needed")
+			return
+		}
+		owner, ok := queryAddress(c, client, "owner")
+		if !ok {
+			return
+		}
+		domain, err := client.GetPermitDomain(&token, owner)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "server error occured: %s", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"result": domain,
+		})
+	})
+	r.GET("/ens/resolve", func(c *gin.Context){
+		name := c.Query("name")
+		if name == "" {
+			c.String(http.StatusBadRequest, "name is required")
+			return
+		}
+		if !network.HasEns() {
+			c.String(http.StatusBadRequest, "ens isn't supported on network %s", network.Name)
+			return
+		}
+		if _, err := types.NormalizeEnsName(name); err != nil {
+			c.String(http.StatusBadRequest, "name is illegal: %s", err)
+			return
+		}
+		address, err := client.ResolveEnsName(name)
+		if _, ok := err.(ethclient.EnsNotResolvedError); ok {
+			c.String(http.StatusBadRequest, "resolve ens name occured error: %s", err)
+			return
+		}
+		if err != nil {
+			c.String(http.StatusInternalServerError, "server error occured: %s", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"result": address,
+		})
+	})
+	r.GET("/ens/lookup", func(c *gin.Context){
+		addr, ok := queryAddress(c, client, "address")
+		if !ok {
+			return
+		}
+		name, err := client.LookupEnsAddress(addr)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "server error occured: %s", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"result": name,
+		})
+	})
 	r.GET("/tx", func(c *gin.Context){
 		txid := c.Query("txid")
 		tx, err := client.GetTransaction(txid)
@@ -77,6 +286,17 @@ func SetupServer(network *types.Network, port int) *gin.Engine{
 			"result": tx,
 		})
 	})
+	r.GET("/receipt", func(c *gin.Context){
+		txid := c.Query("txid")
+		receipt, err := client.GetTransactionReceipt(txid)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "server error occured: %s", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"result": receipt,
+		})
+	})
 	r.GET("/block", func(c *gin.Context) {
 		block, err := client.GetBlockNumber()
 		if err != nil {
@@ -98,9 +318,44 @@ func SetupServer(network *types.Network, port int) *gin.Engine{
 			"result": res.String(),
 		})
 	})
+	r.GET("/basefee", func(c *gin.Context) {
+		block, err := client.GetBlockByNumber(types.Latest)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "server error occured: %s", err)
+			return
+		}
+		if block.BaseFeePerGas == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"result": nil,
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"result": (*big.Int)(block.BaseFeePerGas).String(),
+		})
+	})
+	r.GET("/maxpriorityfee", func(c *gin.Context) {
+		tip, err := client.GetMaxPriorityFeePerGas()
+		if err != nil {
+			c.String(http.StatusInternalServerError, "server error occured: %s", err)
+			return
+		}
+		res := utils.HexStrToBigInt(tip)
+		c.JSON(http.StatusOK, gin.H{
+			"result": res.String(),
+		})
+	})
 	r.GET("/nonce", func(c *gin.Context) {
-		addr := utils.HexToAddress( c.Query("address"))
-		blockParam, err := types.NewBlockParam(c.DefaultQuery("param","latest"))
+		addr, ok := queryAddress(c, client, "address")
+		if !ok {
+			return
+		}
+		param := c.DefaultQuery("param","latest")
+		blockParam, err := types.NewBlockParam(param)
+		if err != nil {
+			c.String(http.StatusBadRequest, "param is illegal: %s", param)
+			return
+		}
 		nonce, err := client.GetTransactionCount(addr, blockParam)
 		if err != nil {
 			c.String(http.StatusInternalServerError, "server error occured: %s", err)
@@ -118,6 +373,9 @@ func SetupServer(network *types.Network, port int) *gin.Engine{
 			c.String(http.StatusBadRequest, "request is illegal")
 			return
 		}
+		if !checkTransactionRequest(c, network, &txReq) {
+			return
+		}
 		estimateGas, err := client.GetEstimateGas(&txReq)
 		if err != nil {
 			c.String(http.StatusInternalServerError, "server error occured: %s", err)
@@ -128,6 +386,83 @@ func SetupServer(network *types.Network, port int) *gin.Engine{
 			"result": strconv.FormatUint(res,10),
 		})
 	})
+	r.POST("/txparams", func(c *gin.Context){
+		var txReq types.TransactionRequest
+		param := c.DefaultQuery("param","latest")
+		blockParam, err := types.NewBlockParam(param)
+		if err != nil {
+			c.String(http.StatusBadRequest, "param is illegal: %s", param)
+			return
+		}
+		estimate, err := strconv.ParseBool(c.DefaultQuery("estimate","true"))
+		if err != nil {
+			c.String(http.StatusBadRequest, "estimate is illegal: %s", c.Query("estimate"))
+			return
+		}
+		err = c.BindJSON(&txReq)
+		if err != nil || txReq.From == "" {
+			c.String(http.StatusBadRequest, "request is illegal")
+			return
+		}
+		if !checkTransactionRequest(c, network, &txReq) {
+			return
+		}
+		res, err := client.GetTransactionParams(&txReq, blockParam, estimate)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "server error occured: %s", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"result": res,
+		})
+	})
+	r.POST("/accesslist", func(c *gin.Context){
+		var txReq types.TransactionRequest
+		err := c.BindJSON(&txReq)
+		if err != nil{
+			c.String(http.StatusBadRequest, "request is illegal")
+			return
+		}
+		if !checkTransactionRequest(c, network, &txReq) {
+			return
+		}
+		res, err := client.CreateAccessList(&txReq, types.Latest)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "server error occured: %s", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"result": gin.H{
+				"accesslist": res.AccessList,
+				"gasused":    strconv.Itoa(int(res.GasUsed)),
+			},
+		})
+	})
+	r.POST("/call", func(c *gin.Context){
+		var txReq types.TransactionRequest
+		param := c.DefaultQuery("param","latest")
+		blockParam, err := types.NewBlockParam(param)
+		if err != nil {
+			c.String(http.StatusBadRequest, "param is illegal: %s", param)
+			return
+		}
+		err = c.BindJSON(&txReq)
+		if err != nil{
+			c.String(http.StatusBadRequest, "request is illegal")
+			return
+		}
+		if !checkTransactionRequest(c, network, &txReq) {
+			return
+		}
+		res, err := client.GetCall(&txReq, blockParam)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "server error occured: %s", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"result": res,
+		})
+	})
 
 	r.GET("/logs", func(c *gin.Context) {
 		topics := make(map[string]string)
@@ -138,7 +473,10 @@ func SetupServer(network *types.Network, port int) *gin.Engine{
 			return
 		}
 		toBlock := c.DefaultQuery("toblock","latest")
-		addr := utils.HexToAddress( c.Query("address"))
+		addr, ok := queryOptionalAddress(c, client, "address")
+		if !ok {
+			return
+		}
 		topic0 := c.Query("topic0")
 		if topic0 != "" {
 			topics["topic0"] = topic0
@@ -190,7 +528,10 @@ func SetupServer(network *types.Network, port int) *gin.Engine{
 	})
 
 	r.GET("/txs", func(c *gin.Context){
-		addr := utils.HexToAddress( c.Query("address"))
+		addr, ok := queryAddress(c, client, "address")
+		if !ok {
+			return
+		}
 		sStartBlock := c.DefaultQuery("startblock","0")
 		sEndBlock := c.DefaultQuery("endblock","9999999")
 		sdesc := c.DefaultQuery("desc","true")
@@ -214,12 +555,19 @@ func SetupServer(network *types.Network, port int) *gin.Engine{
 			c.String(http.StatusInternalServerError, "server error occured: %s", err)
 			return
 		}
+		annotateEnsNames(client, len(transactions), func(i int) (string, string, *string, *string) {
+			tx := &transactions[i]
+			return tx.From, tx.To, &tx.FromName, &tx.ToName
+		})
 		c.JSON(http.StatusOK, gin.H{
 			"result": transactions,
 		})
 	})
 	r.GET("/intxs", func(c *gin.Context){
-		addr := utils.HexToAddress( c.Query("address"))
+		addr, ok := queryAddress(c, client, "address")
+		if !ok {
+			return
+		}
 		sStartBlock := c.DefaultQuery("startblock","0")
 		sEndBlock := c.DefaultQuery("endblock","9999999")
 		sdesc := c.DefaultQuery("desc","true")
@@ -243,12 +591,19 @@ func SetupServer(network *types.Network, port int) *gin.Engine{
 			c.String(http.StatusInternalServerError, "server error occured: %s", err)
 			return
 		}
+		annotateEnsNames(client, len(transactions), func(i int) (string, string, *string, *string) {
+			tx := &transactions[i]
+			return tx.From, tx.To, &tx.FromName, &tx.ToName
+		})
 		c.JSON(http.StatusOK, gin.H{
 			"result": transactions,
 		})
 	})
 	r.GET("/tokentxs", func(c *gin.Context){
-		addr := utils.HexToAddress( c.Query("address"))
+		addr, ok := queryAddress(c, client, "address")
+		if !ok {
+			return
+		}
 		sStartBlock := c.DefaultQuery("startblock","0")
 		sEndBlock := c.DefaultQuery("endblock","9999999")
 		sdesc := c.DefaultQuery("desc","true")
@@ -272,6 +627,10 @@ func SetupServer(network *types.Network, port int) *gin.Engine{
 			c.String(http.StatusInternalServerError, "server error occured: %s", err)
 			return
 		}
+		annotateEnsNames(client, len(transactions), func(i int) (string, string, *string, *string) {
+			tx := &transactions[i]
+			return tx.From, tx.To, &tx.FromName, &tx.ToName
+		})
 		c.JSON(http.StatusOK, gin.H{
 			"result": transactions,
 		})
@@ -290,4 +649,106 @@ func SetupServer(network *types.Network, port int) *gin.Engine{
 	})
 	fmt.Printf("server run at http://127.0.0.1:%d\n", port)
 	return r
-}
\ No newline at end of file
+}
+
+// queryAddress reads a required address or ens name from query key
+func queryAddress(c *gin.Context, client *ethclient.EthereumClient, key string) (common.Address, bool) {
+	s := c.Query(key)
+	if s == "" {
+		c.String(http.StatusBadRequest, "%s is required", key)
+		return common.Address{}, false
+	}
+	return resolveQueryAddress(c, client, key, s)
+}
+
+// queryOptionalAddress is queryAddress of an optional key, an empty query is the zero address
+func queryOptionalAddress(c *gin.Context, client *ethclient.EthereumClient, key string) (common.Address, bool) {
+	s := c.Query(key)
+	if s == "" {
+		return common.Address{}, true
+	}
+	return resolveQueryAddress(c, client, key, s)
+}
+
+func resolveQueryAddress(c *gin.Context, client *ethclient.EthereumClient, key string, s string) (common.Address, bool) {
+	address, err := client.ResolveAddress(s)
+	if err != nil {
+		c.String(http.StatusBadRequest, "%s is illegal: %s", key, err)
+		return common.Address{}, false
+	}
+	return address, true
+}
+
+// balanceTokens finds the token of every asset, ETH is nil. An address not in erc20_list is read from the chain.
+func balanceTokens(client *ethclient.EthereumClient, network *types.Network, erc20list []*types.Erc20Token, assets []string) ([]*types.Erc20Token, error) {
+	tokens := make([]*types.Erc20Token, len(assets))
+	seen := make(map[string]bool)
+	for i, asset := range assets {
+		if seen[asset] {
+			return nil, fmt.Errorf("asset %s is repeated", asset)
+		}
+		seen[asset] = true
+		switch {
+		case strings.EqualFold(asset, types.EtherSymbol):
+		case strings.HasPrefix(asset, "0x"):
+			address, err := utils.ParseAddress(asset, network.ChainId)
+			if err != nil {
+				return nil, err
+			}
+			tokens[i] = types.Erc20TokenByAddress(erc20list, address)
+			if tokens[i] == nil {
+				tokens[i], err = client.GetErc20Info(&address)
+				if err != nil {
+					return nil, fmt.Errorf("token %s isn't an erc20 token: %s", asset, err)
+				}
+			}
+		default:
+			token, err := types.FindErc20Token(erc20list, asset)
+			if err != nil {
+				return nil, err
+			}
+			tokens[i] = token
+		}
+	}
+	return tokens, nil
+}
+
+// checkTransactionRequest validates from and to of a request body, an empty one is left to the node
+func checkTransactionRequest(c *gin.Context, network *types.Network, txReq *types.TransactionRequest) bool {
+	for _, field := range []struct {
+		name  string
+		value string
+	}{{"from", txReq.From}, {"to", txReq.To}} {
+		if field.value == "" {
+			continue
+		}
+		if _, err := utils.ParseAddress(field.value, network.ChainId); err != nil {
+			c.String(http.StatusBadRequest, "%s is illegal: %s", field.name, err)
+			return false
+		}
+	}
+	return true
+}
+
+// annotateEnsNames sets primary names of counterparties of n history transactions, tx returns from, to and
+// the name fields of the i-th transaction. History is still returned without names if the lookup fails.
+func annotateEnsNames(client *ethclient.EthereumClient, n int, tx func(i int) (from string, to string, fromName *string, toName *string)) {
+	var list []common.Address
+	for i := 0; i < n; i++ {
+		from, to, _, _ := tx(i)
+		for _, address := range []string{from, to} {
+			if common.IsHexAddress(address) {
+				list = append(list, common.HexToAddress(address))
+			}
+		}
+	}
+	names, err := client.LookupEnsAddresses(list)
+	if err != nil {
+		return
+	}
+	for i := 0; i < n; i++ {
+		from, to, fromName, toName := tx(i)
+		*fromName = names[common.HexToAddress(from)]
+		*toName = names[common.HexToAddress(to)]
+	}
+}
@@ -2,7 +2,10 @@ package tests
 
 import (
 	"bytes"
+	"encoding/json"
+	"github.com/tn606024/ethwallet/abi"
 	"github.com/tn606024/ethwallet/utils"
+	"math/big"
 	"testing"
 )
 
@@ -74,3 +77,79 @@ func TestToMethodID(t *testing.T) {
 		}
 	}
 }
+
+func TestMethod_Pack(t *testing.T) {
+	contract, err := abi.JSON([]byte(testAbiJson))
+	if err != nil {
+		t.Fatalf("abi.JSON error: %s", err)
+	}
+	method, err := contract.Method(testAbiEncode.method)
+	if err != nil {
+		t.Fatalf("Method error: %s", err)
+	}
+	if method.Signature != testAbiEncode.signature {
+		t.Errorf("the ans is %s, but we got %s", testAbiEncode.signature, method.Signature)
+	}
+	args, err := abi.ParseArgs(testAbiEncode.args)
+	if err != nil {
+		t.Fatalf("ParseArgs error: %s", err)
+	}
+	data, err := method.Pack(args...)
+	if err != nil {
+		t.Fatalf("Pack error: %s", err)
+	}
+	if utils.BytesToHexStr(data) != testAbiEncode.data {
+		t.Errorf("the ans is %s, but we got %s", testAbiEncode.data, utils.BytesToHexStr(data))
+	}
+}
+
+func TestMethod_UnpackInput(t *testing.T) {
+	contract, err := abi.JSON([]byte(testAbiJson))
+	if err != nil {
+		t.Fatalf("abi.JSON error: %s", err)
+	}
+	data := utils.HexStrToBytes(testAbiEncode.data)
+	method, err := contract.MethodById(data[:4])
+	if err != nil {
+		t.Fatalf("MethodById error: %s", err)
+	}
+	values, err := method.UnpackInput(data)
+	if err != nil {
+		t.Fatalf("UnpackInput error: %s", err)
+	}
+	formatted, err := method.Inputs.Format(values)
+	if err != nil {
+		t.Fatalf("Format error: %s", err)
+	}
+	b, _ := json.Marshal(formatted)
+	if string(b) != testAbiEncode.formatted {
+		t.Errorf("the ans is %s, but we got %s", testAbiEncode.formatted, string(b))
+	}
+	// every truncated input must fail instead of panic
+	for i := 4; i < len(data); i += 32 {
+		if _, err := method.UnpackInput(data[:i]); err == nil {
+			t.Errorf("UnpackInput of %d bytes should fail", i)
+		}
+	}
+}
+
+func TestEvent_UnpackLog(t *testing.T) {
+	contract, err := abi.JSON([]byte(testAbiJson))
+	if err != nil {
+		t.Fatalf("abi.JSON error: %s", err)
+	}
+	event, err := contract.EventByTopic(testAbiTransferLog.topics[0])
+	if err != nil {
+		t.Fatalf("EventByTopic error: %s", err)
+	}
+	res, err := event.UnpackLog(testAbiTransferLog.topics, utils.HexStrToBytes(testAbiTransferLog.data))
+	if err != nil {
+		t.Fatalf("UnpackLog error: %s", err)
+	}
+	if res["from"] != testAbiTransferLog.from || res["to"] != testAbiTransferLog.to {
+		t.Errorf("the ans is %s -> %s, but we got %v -> %v", testAbiTransferLog.from.String(), testAbiTransferLog.to.String(), res["from"], res["to"])
+	}
+	if res["value"].(*big.Int).String() != testAbiTransferLog.value {
+		t.Errorf("the ans is %s, but we got %s", testAbiTransferLog.value, res["value"].(*big.Int).String())
+	}
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/utils"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestChecksumAddress(t *testing.T) {
+	// eip55 and eip1191 test vectors
+	tests := []struct {
+		chainId  *big.Int
+		expected []string
+	}{
+		{nil, []string{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359", "0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB", "0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb"}},
+		{big.NewInt(1), []string{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359", "0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB", "0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb"}},
+		{big.NewInt(30), []string{"0x5aaEB6053f3e94c9b9a09f33669435E7ef1bEAeD", "0xFb6916095cA1Df60bb79ce92cE3EA74c37c5d359", "0xDBF03B407c01E7CD3cBea99509D93F8Dddc8C6FB", "0xD1220A0Cf47c7B9BE7a2e6ba89F429762E7B9adB"}},
+		{big.NewInt(31), []string{"0x5aAeb6053F3e94c9b9A09F33669435E7EF1BEaEd", "0xFb6916095CA1dF60bb79CE92ce3Ea74C37c5D359", "0xdbF03B407C01E7cd3cbEa99509D93f8dDDc8C6fB", "0xd1220a0CF47c7B9Be7A2E6Ba89f429762E7b9adB"}},
+	}
+	for _, test := range tests {
+		for _, expected := range test.expected {
+			if checksum := utils.ChecksumAddress(common.HexToAddress(expected), test.chainId); checksum != expected {
+				t.Errorf("checksum on chain %v is %s, expected %s", test.chainId, checksum, expected)
+			}
+		}
+	}
+}
+
+func TestParseAddress(t *testing.T) {
+	valid := []struct {
+		address string
+		chainId *big.Int
+	}{
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", nil},
+		{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", nil},
+		{"0X5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED", nil},
+		{"0x5aaEB6053f3e94c9b9a09f33669435E7ef1bEAeD", big.NewInt(30)},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", big.NewInt(30)},
+	}
+	for _, test := range valid {
+		address, err := utils.ParseAddress(test.address, test.chainId)
+		if err != nil || !strings.EqualFold(address.Hex(), test.address) {
+			t.Errorf("%s on chain %v is parsed to %s, %v", test.address, test.chainId, address.Hex(), err)
+		}
+	}
+	invalid := []struct {
+		address string
+		chainId *big.Int
+		message string
+	}{
+		{"", nil, "empty"},
+		{"5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", nil, "0x"},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAe", nil, "39 hex digits"},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAedd", nil, "41 hex digits"},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeg", nil, "non-hex character 'g' at 41"},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", nil, "invalid eip55 checksum"},
+		{"0x5aaEB6053f3e94c9b9a09f33669435E7ef1bEAeD", big.NewInt(1), "invalid eip55 checksum"},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", big.NewInt(30), "neither eip55 nor eip1191"},
+	}
+	for _, test := range invalid {
+		_, err := utils.ParseAddress(test.address, test.chainId)
+		if err == nil || !strings.Contains(err.Error(), test.message) {
+			t.Errorf("%s on chain %v got error %v, expected %q", test.address, test.chainId, err, test.message)
+		}
+	}
+}
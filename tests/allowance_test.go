@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/ethclient"
+	"github.com/tn606024/ethwallet/types"
+	"github.com/tn606024/ethwallet/utils"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newApprovalNode has Approval logs of TestAddress in several log windows for spenders 0x11..11(twice), 0xaa..aa(allowance is 0 now)
+// and an erc721 Approval, its token contract answers name, symbol, decimals and allowance
+func newApprovalNode(t *testing.T) *httptest.Server {
+	owner := common.BytesToHash(TestAddress.Bytes()).Hex()
+	log := func(spender string, block int, erc721 bool) map[string]interface{} {
+		topics := []string{types.Erc20ApprovalTopic.Hex(), owner, "0x000000000000000000000000" + strings.Repeat(spender, 20)}
+		if erc721 {
+			topics = append(topics, fmt.Sprintf("0x%064x", 1))
+		}
+		return map[string]interface{}{
+			"address": TestContractAddress.Hex(), "topics": topics, "data": "0x",
+			"blockNumber": fmt.Sprintf("0x%x", block), "transactionHash": fmt.Sprintf("0x%064x", block), "removed": false,
+		}
+	}
+	answer := func(req rpcRequest) map[string]interface{} {
+		res := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		switch req.Method {
+		case "eth_blockNumber":
+			res["result"] = fmt.Sprintf("0x%x", testLatestBlock)
+		case "eth_getLogs":
+			var filter types.LogFilter
+			json.Unmarshal(req.Params[0], &filter)
+			if len(filter.Topics) != 2 || filter.Topics[0][0] != types.Erc20ApprovalTopic || filter.Topics[1][0].Hex() != owner {
+				t.Errorf("unexpected log filter: %s", string(req.Params[0]))
+			}
+			res["result"] = logsInRange(t, req.Params[0], []map[string]interface{}{log("11", 5, false), log("aa", 6, false), log("11", 7005, false), log("33", 11000, true)})
+		case "eth_call":
+			var call types.TransactionRequest
+			json.Unmarshal(req.Params[0], &call)
+			switch {
+			case strings.HasPrefix(call.Data, "0x06fdde03"):
+				res["result"] = abiString("Test Token")
+			case strings.HasPrefix(call.Data, "0x95d89b41"):
+				res["result"] = abiString("TST")
+			case strings.HasPrefix(call.Data, "0x313ce567"):
+				res["result"] = fmt.Sprintf("0x%064x", 6)
+			case strings.HasPrefix(call.Data, "0xdd62ed3e") && strings.HasSuffix(call.Data, "aa"):
+				res["result"] = fmt.Sprintf("0x%064x", 0)
+			case strings.HasPrefix(call.Data, "0xdd62ed3e"):
+				res["result"] = utils.BytesToHexStr(types.MaxAllowance.Bytes())
+			}
+		default:
+			res["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+		}
+		return res
+	}
+	return newRPCNode(t, answer)
+}
+
+func TestEthereumClient_GetApprovals(t *testing.T) {
+	node := newApprovalNode(t)
+	defer node.Close()
+	client := ethclient.NewEthereumClient(node.URL, "", "", TestNetwork)
+	approvals, err := client.GetApprovals(TestAddress, 0)
+	if err != nil {
+		t.Fatalf("GetApprovals error: %s", err)
+	}
+	if len(approvals) != 1 {
+		t.Fatalf("got %d approvals, expected 1: %+v", len(approvals), approvals)
+	}
+	approval := approvals[0]
+	if approval.Spender != common.HexToAddress("0x"+strings.Repeat("11", 20)) || approval.BlockNumber != 7005 {
+		t.Errorf("approval is of spender %s at block %d, expected 0x11..11 at block 7005", approval.Spender.String(), approval.BlockNumber)
+	}
+	if approval.Token.Symbol != "TST" || approval.Token.Decimals != 6 || *approval.Token.Address != TestContractAddress {
+		t.Errorf("unexpected token: %+v", approval.Token)
+	}
+	if allowance := approval.Token.FormatAllowance((*big.Int)(approval.Allowance)); allowance != "unlimited" {
+		t.Errorf("allowance is %s, expected unlimited", allowance)
+	}
+}
+
+func TestErc20Token_AllowanceData(t *testing.T) {
+	token := &types.Erc20Token{Decimals: 6, Symbol: "USDC", Address: &TestContractAddress}
+	spender := TestPrivateKeyAddress
+	value := big.NewInt(1500000)
+	approve := utils.BytesToHexStr(token.GenerateApproveData(&spender, value))
+	if approve != "0x095ea7b3"+fmt.Sprintf("%064s", strings.ToLower(spender.Hex()[2:]))+fmt.Sprintf("%064x", 1500000) {
+		t.Errorf("unexpected approve data: %s", approve)
+	}
+	transferFrom := utils.BytesToHexStr(token.GenerateTransferFromData(&TestAddress, &spender, value))
+	if !strings.HasPrefix(transferFrom, "0x23b872dd") || len(transferFrom) != 2+8+64*3 {
+		t.Errorf("unexpected transferFrom data: %s", transferFrom)
+	}
+	allowance := utils.BytesToHexStr(token.GenerateAllowanceData(&TestAddress, &spender))
+	if !strings.HasPrefix(allowance, "0xdd62ed3e") || len(allowance) != 2+8+64*2 {
+		t.Errorf("unexpected allowance data: %s", allowance)
+	}
+	if s := token.FormatAllowance(value); s != "1.5" {
+		t.Errorf("allowance is %s, expected 1.5", s)
+	}
+}
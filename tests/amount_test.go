@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/tn606024/ethwallet/types"
+	"math/big"
+	"testing"
+)
+
+func TestParseEtherAmount(t *testing.T) {
+	tests := []struct {
+		input string
+		wei   string
+	}{
+		{"100", "100"},
+		{"1.25ether", "1250000000000000000"},
+		{"1.25 ETH", "1250000000000000000"},
+		{"30gwei", "30000000000"},
+		{"1.5gwei", "1500000000"},
+		{"7wei", "7"},
+		{".5ether", "500000000000000000"},
+		{"0.000000000000000001ether", "1"},
+	}
+	for _, test := range tests {
+		amount, err := types.ParseEtherAmount(test.input, "wei")
+		if err != nil {
+			t.Errorf("ParseEtherAmount(%s) error: %s", test.input, err)
+			continue
+		}
+		if amount.Value.String() != test.wei || amount.Decimals != 18 {
+			t.Errorf("ParseEtherAmount(%s) is %s with %d decimals, expected %s", test.input, amount.Value.String(), amount.Decimals, test.wei)
+		}
+	}
+	for _, input := range []string{"1.5", "1.5wei", "0.0000000001gwei", "-1ether", "1e18", "abc", "", "ether", "1.2.3ether"} {
+		if amount, err := types.ParseEtherAmount(input, "wei"); err == nil {
+			t.Errorf("ParseEtherAmount(%s) is %s, expected an error", input, amount.Value.String())
+		}
+	}
+}
+
+func TestAmount(t *testing.T) {
+	usdc, err := types.ParseAmount("0.5", 6)
+	if err != nil {
+		t.Fatalf("ParseAmount error: %s", err)
+	}
+	if usdc.Value.Int64() != 500000 {
+		t.Errorf("0.5 USDC is %s, expected 500000", usdc.Value.String())
+	}
+	if _, err := types.ParseAmount("0.0000001", 6); err == nil {
+		t.Errorf("amount with more than 6 decimals is parsed")
+	}
+
+	tests := []struct {
+		value    string
+		decimals int
+		str      string
+	}{
+		{"1234500", 6, "1.2345"},
+		{"1000000", 6, "1"},
+		{"1", 18, "0.000000000000000001"},
+		{"-1500", 3, "-1.5"},
+		{"0", 18, "0"},
+		{"123", 0, "123"},
+	}
+	for _, test := range tests {
+		value, _ := new(big.Int).SetString(test.value, 10)
+		if str := types.NewAmount(value, test.decimals).String(); str != test.str {
+			t.Errorf("amount %s with %d decimals is %s, expected %s", test.value, test.decimals, str, test.str)
+		}
+	}
+
+	b, err := json.Marshal(map[string]*types.Amount{"USDC": types.NewAmount(big.NewInt(1234500), 6)})
+	if err != nil {
+		t.Fatalf("marshal error: %s", err)
+	}
+	if string(b) != `{"USDC":"1.2345"}` {
+		t.Errorf("marshaled amount is %s", string(b))
+	}
+	var balances map[string]*types.Amount
+	if err := json.Unmarshal(b, &balances); err != nil {
+		t.Fatalf("unmarshal error: %s", err)
+	}
+	rescaled, err := balances["USDC"].Rescale(6)
+	if err != nil || rescaled.Value.Int64() != 1234500 {
+		t.Errorf("rescaled amount is %v, error: %v", rescaled, err)
+	}
+	if _, err := balances["USDC"].Rescale(2); err == nil {
+		t.Errorf("rescale losing digits succeeds")
+	}
+	if usdc.Cmp(balances["USDC"]) >= 0 {
+		t.Errorf("0.5 is not less than 1.2345")
+	}
+}
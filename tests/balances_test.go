@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/ethclient"
+	"github.com/tn606024/ethwallet/types"
+	"github.com/tn606024/ethwallet/wallet"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBalancesRequest_BlockParam(t *testing.T) {
+	tests := []struct {
+		block    string
+		expected types.BlockParam
+		err      bool
+	}{
+		{"", types.Latest, false},
+		{"pending", types.Pending, false},
+		{"0x0a", "0xa", false},
+		{"12345", "0x3039", false},
+		{"0xzz", "", true},
+		{"-1", "", true},
+		{"newest", "", true},
+	}
+	for _, test := range tests {
+		req := types.BalancesRequest{Block: test.block}
+		param, err := req.BlockParam()
+		if (err != nil) != test.err || param != test.expected {
+			t.Errorf("block %q is parsed to %q, %v, expected %q", test.block, param, err, test.expected)
+		}
+	}
+}
+
+func TestEthereumClient_GetAssetBalances(t *testing.T) {
+	other := common.HexToAddress("0x" + strings.Repeat("22", 20))
+	token := &types.Erc20Token{Symbol: "TST", Decimals: 2, Address: &TestContractAddress}
+	for _, multicall := range []bool{true, false} {
+		counts := make(map[string]int)
+		node := newMulticallNode(t, multicall, counts)
+		client := ethclient.NewEthereumClient(node.URL, "", "", TestNetwork)
+
+		balances, err := client.GetAssetBalances([]common.Address{TestAddress, other}, []*types.Erc20Token{token, nil}, types.Latest)
+		if err != nil {
+			t.Fatalf("multicall %v: GetAssetBalances error: %s", multicall, err)
+		}
+		if balances[0][0].Int64() != 12345 || balances[0][1].Int64() != 1000 || balances[1][0].Int64() != 12345 || balances[1][1].Int64() != 0 {
+			t.Errorf("multicall %v: balances are %v", multicall, balances)
+		}
+		if multicall && (counts["eth_call"] != 2 || counts["eth_getBalance"] != 0) {
+			t.Errorf("with multicall3 requests are %v, expected 2 eth_calls", counts)
+		}
+
+		bogus := common.HexToAddress("0x" + strings.Repeat("33", 20))
+		_, err = client.GetAssetBalances([]common.Address{TestAddress}, []*types.Erc20Token{{Symbol: "BAD", Address: &bogus}}, types.Latest)
+		if err == nil || !strings.Contains(err.Error(), bogus.String()) {
+			t.Errorf("multicall %v: balance of a failed token should be an error naming it, got %v", multicall, err)
+		}
+		node.Close()
+	}
+}
+
+func TestEthereumWallet_GetBalances(t *testing.T) {
+	other := common.HexToAddress("0x" + strings.Repeat("22", 20))
+	var answer types.Balances
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": answer})
+	}))
+	defer ts.Close()
+	ew := wallet.ImportEmptyEthereumWallet(types.Config{ServerUrl: ts.URL, Network: TestNetwork})
+	one := types.NewAmount(big.NewInt(1), 18)
+	answer = types.Balances{
+		TestAddress.String(): {"ETH": one, "USDT": one},
+		other.String():       {"ETH": one, "USDT": one},
+	}
+	balances, err := ew.GetBalances([]common.Address{TestAddress, other}, []string{"ETH", "USDT"}, "latest")
+	if err != nil || len(balances) != 2 {
+		t.Fatalf("GetBalances got %v, %v", balances, err)
+	}
+	// a balance missing in the answer isn't a zero balance
+	answer = types.Balances{
+		TestAddress.String(): {"ETH": one, "USDT": one},
+		other.String():       {"ETH": one},
+	}
+	if _, err := ew.GetBalances([]common.Address{TestAddress, other}, []string{"ETH", "USDT"}, "latest"); err == nil || !strings.Contains(err.Error(), "USDT") {
+		t.Errorf("a missing USDT balance got error %v", err)
+	}
+	answer = types.Balances{TestAddress.String(): {"ETH": one, "USDT": one}}
+	if _, err := ew.GetBalances([]common.Address{TestAddress, other}, []string{"ETH", "USDT"}, "latest"); err == nil || !strings.Contains(err.Error(), other.String()) {
+		t.Errorf("a missing address got error %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/tn606024/ethwallet/ethclient"
+	"github.com/tn606024/ethwallet/types"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newBatchNode answers batch requests in reverse order, eth_maxPriorityFeePerGas always fails
+func newBatchNode(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqs []struct {
+			ID     int           `json:"id"`
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+			t.Errorf("batch request is not an array: %s", err)
+			return
+		}
+		var responses []map[string]interface{}
+		for i := len(reqs) - 1; i >= 0; i-- {
+			res := map[string]interface{}{"jsonrpc": "2.0", "id": reqs[i].ID}
+			switch reqs[i].Method {
+			case "eth_getTransactionCount":
+				res["result"] = "0x10"
+			case "eth_gasPrice":
+				res["result"] = "0x3b9aca00"
+			case "eth_getBlockByNumber":
+				res["result"] = map[string]interface{}{"number": "0x1", "baseFeePerGas": "0x7"}
+			case "eth_estimateGas":
+				res["result"] = "0x5208"
+			case "eth_call":
+				res["result"] = fmt.Sprintf("0x%064x", 1000*(reqs[i].ID+1))
+			default:
+				res["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+			}
+			responses = append(responses, res)
+		}
+		json.NewEncoder(w).Encode(responses)
+	}))
+}
+
+func TestEthereumClient_BatchCall(t *testing.T) {
+	node := newBatchNode(t)
+	defer node.Close()
+	client := ethclient.NewEthereumClient(node.URL, "", "", types.EthereumNet)
+	results := make([]string, 250)
+	elems := make([]ethclient.BatchElem, len(results))
+	for i := range elems {
+		elems[i] = ethclient.BatchElem{Method: "eth_call", Result: &results[i]}
+	}
+	elems[3].Method = "eth_unknown"
+	if err := client.BatchCall(elems); err != nil {
+		t.Fatalf("BatchCall error: %s", err)
+	}
+	for i, elem := range elems {
+		if i == 3 {
+			if elem.Error == nil {
+				t.Errorf("elem 3 should fail")
+			}
+			continue
+		}
+		// ids restart in every chunk of 100
+		ans := fmt.Sprintf("0x%064x", 1000*(i%100+1))
+		if elem.Error != nil || results[i] != ans {
+			t.Errorf("the ans of %d is %s, but we got %s(%v)", i, ans, results[i], elem.Error)
+		}
+	}
+}
+
+func TestEthereumClient_GetTransactionParams(t *testing.T) {
+	node := newBatchNode(t)
+	defer node.Close()
+	client := ethclient.NewEthereumClient(node.URL, "", "", types.EthereumNet)
+	txr := &types.TransactionRequest{From: TestPrivateKeyAddress.String(), To: TestPrivateKeyAddress.String()}
+	params, err := client.GetTransactionParams(txr, types.Latest, true)
+	if err != nil {
+		t.Fatalf("GetTransactionParams error: %s", err)
+	}
+	if params.Nonce != 16 || params.GasPrice.Int64() != 1000000000 || params.BaseFee.Int64() != 7 || params.GasLimit != 21000 {
+		t.Errorf("params are not expected: %+v", params)
+	}
+	if params.MaxPriorityFee != nil {
+		t.Errorf("MaxPriorityFee should be nil when the node doesn't support it")
+	}
+}
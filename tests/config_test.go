@@ -0,0 +1,39 @@
+package tests
+
+import (
+	"github.com/tn606024/ethwallet/types"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestImportConfig_Networks(t *testing.T) {
+	f, err := ioutil.TempFile("", "config*.json")
+	if err != nil {
+		t.Fatalf("TempFile error: %s", err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(testNetworksConfig)
+	f.Close()
+	if err != nil {
+		t.Fatalf("WriteString error: %s", err)
+	}
+	c, err := types.ImportConfig(f.Name())
+	if err != nil {
+		t.Fatalf("ImportConfig error: %s", err)
+	}
+	if c.Network.Name != "privnet" || c.Network.ChainId.String() != "99999999999" {
+		t.Errorf("the ans is privnet(99999999999), but we got %s(%s)", c.Network.Name, c.Network.ChainId.String())
+	}
+	networkUrl, err := c.GetNetworkUrl("privnet")
+	if err != nil {
+		t.Fatalf("GetNetworkUrl error: %s", err)
+	}
+	if networkUrl.NodeUrl != "http://127.0.0.1:8545" {
+		t.Errorf("the ans is http://127.0.0.1:8545, but we got %s", networkUrl.NodeUrl)
+	}
+	_, err = c.GetNetworkUrl("sepolia")
+	if err != nil {
+		t.Errorf("GetNetworkUrl error: %s", err)
+	}
+}
@@ -0,0 +1,204 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/ethclient"
+	"github.com/tn606024/ethwallet/types"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newDiscoverNode is a node at block 100 and its etherscan api, TestAddress received TestContractAddress(held),
+// 0x22..22(not erc20), 0x33..33(not held) and an erc721 0x44..44. fromBlocks records fromBlock of every eth_getLogs.
+func newDiscoverNode(t *testing.T, fromBlocks *[]string) (node *httptest.Server, etherscan *httptest.Server) {
+	holder := common.BytesToHash(TestAddress.Bytes()).Hex()
+	log := func(contract string, erc721 bool) map[string]interface{} {
+		topics := []string{types.Erc20TransferTopic.Hex(), fmt.Sprintf("0x%064x", 1), holder}
+		if erc721 {
+			topics = append(topics, fmt.Sprintf("0x%064x", 1))
+		}
+		return map[string]interface{}{
+			"address": contract, "topics": topics, "data": "0x", "blockNumber": "0x5",
+			"transactionHash": fmt.Sprintf("0x%064x", 5), "removed": false,
+		}
+	}
+	answer := func(req rpcRequest) map[string]interface{} {
+		res := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		switch req.Method {
+		case "eth_blockNumber":
+			res["result"] = "0x64"
+		case "eth_getLogs":
+			var query struct {
+				types.LogFilter
+				FromBlock string `json:"fromBlock"`
+			}
+			json.Unmarshal(req.Params[0], &query)
+			if len(query.Topics) != 3 || query.Topics[0][0] != types.Erc20TransferTopic || query.Topics[2][0].Hex() != holder {
+				t.Errorf("unexpected log filter: %s", string(req.Params[0]))
+			}
+			*fromBlocks = append(*fromBlocks, query.FromBlock)
+			res["result"] = []interface{}{
+				log(TestContractAddress.Hex(), false), log("0x"+strings.Repeat("22", 20), false),
+				log("0x"+strings.Repeat("33", 20), false), log("0x"+strings.Repeat("44", 20), true),
+			}
+		case "eth_call":
+			var call types.TransactionRequest
+			json.Unmarshal(req.Params[0], &call)
+			if strings.EqualFold(call.To, "0x"+strings.Repeat("22", 20)) {
+				res["error"] = map[string]interface{}{"code": 3, "message": "execution reverted"}
+				break
+			}
+			switch {
+			case strings.HasPrefix(call.Data, "0x06fdde03"):
+				res["result"] = abiString("Test Token")
+			case strings.HasPrefix(call.Data, "0x95d89b41"):
+				res["result"] = abiString("TST")
+			case strings.HasPrefix(call.Data, "0x313ce567"):
+				res["result"] = fmt.Sprintf("0x%064x", 2)
+			case strings.HasPrefix(call.Data, "0x70a08231") && strings.EqualFold(call.To, TestContractAddress.Hex()):
+				res["result"] = fmt.Sprintf("0x%064x", 12345)
+			default:
+				res["result"] = fmt.Sprintf("0x%064x", 0)
+			}
+		default:
+			res["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+		}
+		return res
+	}
+	etherscan = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("action") != "tokentx" {
+			t.Errorf("unexpected etherscan request: %s", r.URL.RawQuery)
+		}
+		txs := []map[string]string{
+			{"blockNumber": "5", "contractAddress": TestContractAddress.Hex(), "from": "0x" + strings.Repeat("11", 20), "to": strings.ToLower(TestAddress.Hex())},
+			{"blockNumber": "6", "contractAddress": "0x" + strings.Repeat("55", 20), "from": strings.ToLower(TestAddress.Hex()), "to": "0x" + strings.Repeat("11", 20)},
+		}
+		result, _ := json.Marshal(txs)
+		json.NewEncoder(w).Encode(types.EsResponse{Status: 1, Message: "OK", Result: result})
+	}))
+	return newRPCNode(t, answer), etherscan
+}
+
+func TestEthereumClient_GetDiscoveredErc20Balances(t *testing.T) {
+	var fromBlocks []string
+	node, etherscan := newDiscoverNode(t, &fromBlocks)
+	defer node.Close()
+	defer etherscan.Close()
+	dir, err := ioutil.TempDir("", "tokens")
+	if err != nil {
+		t.Fatalf("TempDir error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "tokens.json")
+	client := ethclient.NewEthereumClient(node.URL, etherscan.URL, "", TestNetwork)
+
+	for _, source := range []string{ethclient.DiscoverLogs, ethclient.DiscoverEtherscan} {
+		os.Remove(path)
+		balances, err := client.GetDiscoveredErc20Balances(TestAddress, source, 0, ethclient.NewTokenCache(path))
+		if err != nil {
+			t.Fatalf("GetDiscoveredErc20Balances of %s error: %s", source, err)
+		}
+		if len(balances) != 1 || *balances[0].Token.Address != TestContractAddress || balances[0].Token.Symbol != "TST" || balances[0].Balance.String() != "123.45" {
+			t.Errorf("unexpected balances of %s: %+v", source, balances)
+		}
+	}
+
+	// the cache of the logs scan is kept, so only the last blocks are scanned again
+	os.Remove(path)
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetDiscoveredErc20Balances(TestAddress, ethclient.DiscoverLogs, 0, ethclient.NewTokenCache(path)); err != nil {
+			t.Fatalf("GetDiscoveredErc20Balances error: %s", err)
+		}
+	}
+	if len(fromBlocks) != 3 || fromBlocks[1] != "0x0" || fromBlocks[2] != "0x58" {
+		t.Errorf("logs are scanned from %v, expected 0x0 and then 0x58", fromBlocks)
+	}
+	cache := ethclient.NewTokenCache(path)
+	if err := cache.Load(); err != nil {
+		t.Fatalf("Load error: %s", err)
+	}
+	if token, ok := cache.Token(TestNetwork.Name, TestContractAddress); !ok || token.Decimals != 2 {
+		t.Errorf("token isn't cached: %+v", token)
+	}
+	if contracts := cache.Contracts(TestNetwork.Name, TestAddress); len(contracts) != 3 {
+		t.Errorf("contracts are %v, expected 3 contracts", contracts)
+	}
+	if from, to, ok := cache.Scanned(TestNetwork.Name, TestAddress); !ok || from != 0 || to != 100 {
+		t.Errorf("blocks %d-%d are scanned, expected 0-100", from, to)
+	}
+
+	// a scan from an earlier block than the cached one scans the blocks before it too
+	os.Remove(path)
+	fromBlocks = nil
+	for _, fromBlock := range []uint64{50, 10} {
+		if _, err := client.GetDiscoveredErc20Balances(TestAddress, ethclient.DiscoverLogs, fromBlock, ethclient.NewTokenCache(path)); err != nil {
+			t.Fatalf("GetDiscoveredErc20Balances error: %s", err)
+		}
+	}
+	if len(fromBlocks) != 3 || fromBlocks[0] != "0x32" || fromBlocks[1] != "0xa" || fromBlocks[2] != "0x58" {
+		t.Errorf("logs are scanned from %v, expected 0x32, then 0xa and 0x58", fromBlocks)
+	}
+	cache = ethclient.NewTokenCache(path)
+	cache.Load()
+	if from, to, _ := cache.Scanned(TestNetwork.Name, TestAddress); from != 10 || to != 100 {
+		t.Errorf("blocks %d-%d are scanned, expected 10-100", from, to)
+	}
+}
+
+func TestEthereumClient_DiscoverErc20Tokens_LogsWindow(t *testing.T) {
+	var mux sync.Mutex
+	var fromBlocks []uint64
+	node := newRPCNode(t, func(req rpcRequest) map[string]interface{} {
+		res := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		switch req.Method {
+		case "eth_blockNumber":
+			res["result"] = fmt.Sprintf("0x%x", 18000000)
+		case "eth_getLogs":
+			var query struct {
+				FromBlock string `json:"fromBlock"`
+			}
+			json.Unmarshal(req.Params[0], &query)
+			from, _ := strconv.ParseUint(strings.TrimPrefix(query.FromBlock, "0x"), 16, 64)
+			mux.Lock()
+			fromBlocks = append(fromBlocks, from)
+			mux.Unlock()
+			res["result"] = []interface{}{}
+		default:
+			res["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+		}
+		return res
+	})
+	defer node.Close()
+	dir, err := ioutil.TempDir("", "tokens")
+	if err != nil {
+		t.Fatalf("TempDir error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "tokens.json")
+	client := ethclient.NewEthereumClient(node.URL, "", "", TestNetwork)
+
+	// without fromblock only the last 100000 blocks are scanned, and later runs don't scan before them
+	for i := 0; i < 2; i++ {
+		if _, err := client.DiscoverErc20Tokens(TestAddress, ethclient.DiscoverLogs, 0, ethclient.NewTokenCache(path)); err != nil {
+			t.Fatalf("DiscoverErc20Tokens error: %s", err)
+		}
+	}
+	lowest := fromBlocks[0]
+	for _, from := range fromBlocks {
+		if from < lowest {
+			lowest = from
+		}
+	}
+	if lowest != 17900000 || len(fromBlocks) != 22 {
+		t.Errorf("%d eth_getLogs are sent from block %d, expected 22 from block 17900000", len(fromBlocks), lowest)
+	}
+}
@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/ethclient"
+	"github.com/tn606024/ethwallet/types"
+	"github.com/tn606024/ethwallet/utils"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNamehash(t *testing.T) {
+	tests := map[string]string{
+		"":        "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"eth":     "0x93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae",
+		"foo.eth": "0xde9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f",
+	}
+	for name, expected := range tests {
+		if hash := types.Namehash(name).Hex(); hash != expected {
+			t.Errorf("namehash of %q is %s, expected %s", name, hash, expected)
+		}
+	}
+	if name, err := types.NormalizeEnsName(" Foo.ETH "); err != nil || name != "foo.eth" {
+		t.Errorf("normalized name is %q, %v", name, err)
+	}
+	for _, name := range []string{"foo..eth", ".eth", "föo.eth"} {
+		if _, err := types.NormalizeEnsName(name); err == nil {
+			t.Errorf("%s should be illegal", name)
+		}
+	}
+	if !types.IsEnsName("foo.eth") || types.IsEnsName(TestAddress.Hex()) || types.IsEnsName("foo") {
+		t.Errorf("IsEnsName can't tell names from addresses")
+	}
+}
+
+// newEnsNode is a node where test.eth resolves to TestAddress, TestAddress's primary name is test.eth and
+// TestContractAddress claims test.eth in its reverse record too
+func newEnsNode(t *testing.T) *httptest.Server {
+	resolver := common.HexToAddress("0x" + strings.Repeat("99", 20))
+	word := func(address common.Address) string { return common.BytesToHash(address.Bytes()).Hex() }
+	node := types.Namehash("test.eth")
+	answer := func(req rpcRequest) map[string]interface{} {
+		res := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		var call types.TransactionRequest
+		json.Unmarshal(req.Params[0], &call)
+		res["result"] = word(common.Address{})
+		switch {
+		case req.Method != "eth_call":
+			res["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+		case strings.EqualFold(call.To, types.EnsRegistryAddress.Hex()):
+			switch call.Data {
+			case utils.BytesToHexStr(types.GenerateEnsResolverData(node)),
+				utils.BytesToHexStr(types.GenerateEnsResolverData(types.EnsReverseNode(TestAddress))),
+				utils.BytesToHexStr(types.GenerateEnsResolverData(types.EnsReverseNode(TestContractAddress))):
+				res["result"] = word(resolver)
+			}
+		case strings.EqualFold(call.To, resolver.Hex()):
+			switch call.Data {
+			case utils.BytesToHexStr(types.GenerateEnsAddrData(node)):
+				res["result"] = word(TestAddress)
+			case utils.BytesToHexStr(types.GenerateEnsNameData(types.EnsReverseNode(TestAddress))),
+				utils.BytesToHexStr(types.GenerateEnsNameData(types.EnsReverseNode(TestContractAddress))):
+				res["result"] = abiString("test.eth")
+			default:
+				res["error"] = map[string]interface{}{"code": 3, "message": "execution reverted"}
+			}
+		default:
+			t.Errorf("unexpected call to %s", call.To)
+		}
+		return res
+	}
+	return newRPCNode(t, answer)
+}
+
+func TestEthereumClient_ResolveEnsName(t *testing.T) {
+	node := newEnsNode(t)
+	defer node.Close()
+	client := ethclient.NewEthereumClient(node.URL, "", "", TestNetwork)
+	if address, err := client.ResolveEnsName("Test.eth"); err != nil || address != TestAddress {
+		t.Errorf("test.eth is resolved to %s, %v", address.Hex(), err)
+	}
+	if _, err := client.ResolveEnsName("none.eth"); err == nil {
+		t.Errorf("a name without resolver should be an error")
+	} else if _, ok := err.(ethclient.EnsNotResolvedError); !ok {
+		t.Errorf("a name without resolver should be an EnsNotResolvedError, got %T", err)
+	}
+	if address, err := client.ResolveAddress(TestContractAddress.Hex()); err != nil || address != TestContractAddress {
+		t.Errorf("address is resolved to %s, %v", address.Hex(), err)
+	}
+	if _, err := client.ResolveAddress("0x1234"); err == nil {
+		t.Errorf("0x1234 should be neither an address nor an ens name")
+	}
+
+	names, err := client.LookupEnsAddresses([]common.Address{TestAddress, TestContractAddress, TestAddress})
+	if err != nil {
+		t.Fatalf("LookupEnsAddresses error: %s", err)
+	}
+	if len(names) != 1 || names[TestAddress] != "test.eth" {
+		t.Errorf("names are %v, only TestAddress should be test.eth", names)
+	}
+
+	// a failed call isn't an EnsNotResolvedError, the server answers 500 for it
+	node.Close()
+	if _, err := client.ResolveEnsName("test.eth"); err == nil {
+		t.Errorf("resolve through a closed node should be an error")
+	} else if _, ok := err.(ethclient.EnsNotResolvedError); ok {
+		t.Errorf("a failed call shouldn't be an EnsNotResolvedError: %s", err)
+	}
+}
@@ -3,6 +3,9 @@ package tests
 import (
 	"github.com/tn606024/ethwallet/conn"
 	"github.com/tn606024/ethwallet/server"
+	"github.com/tn606024/ethwallet/types"
+	"io/ioutil"
+	"net/http"
 	"net/http/httptest"
 	"testing"
 )
@@ -28,7 +31,7 @@ func TestServer(t *testing.T){
 	if err != nil {
 		t.Fatalf("%v\n", err)
 	}
-	_, err = ethconn.GetNonce(TestAddress)
+	_, err = ethconn.GetNonce(TestAddress, types.Latest)
 	if err != nil {
 		t.Fatalf("%v\n", err)
 	}
@@ -36,6 +39,10 @@ func TestServer(t *testing.T){
 	if err != nil {
 		t.Fatalf("%v\n", err)
 	}
+	_, err = ethconn.GetTransactionReceipt(TestTransaction)
+	if err != nil {
+		t.Fatalf("%v\n", err)
+	}
 	_, err = ethconn.GetNormalTransactions(TestAddress)
 	if err != nil {
 		t.Fatalf("%v\n", err)
@@ -56,4 +63,29 @@ func TestServer(t *testing.T){
 	if err != nil {
 		t.Fatalf("%v\n", err)
 	}
-}
\ No newline at end of file
+}
+
+func TestServer_RequiredAddress(t *testing.T) {
+	ts := httptest.NewServer(server.SetupServer(TestNetwork, 8080))
+	defer ts.Close()
+	paths := map[string]string{
+		"/balance":      "address is required",
+		"/nonce":        "address is required",
+		"/erc20balance": "address is required",
+		"/txs":          "address is required",
+		"/nfts":         "address is required",
+		"/approvals":    "address is required",
+		"/permitdomain?token=" + TestContractAddress.Hex(): "owner is required",
+	}
+	for path, expected := range paths {
+		res, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("get %s error: %s", path, err)
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if res.StatusCode != http.StatusBadRequest || string(body) != expected {
+			t.Errorf("%s answers %d %q, expected 400 %q", path, res.StatusCode, body, expected)
+		}
+	}
+}
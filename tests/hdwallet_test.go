@@ -0,0 +1,37 @@
+package tests
+
+import (
+	"github.com/tn606024/ethwallet/wallet"
+	"testing"
+)
+
+func TestHDWallet_Derive(t *testing.T) {
+	for _, test := range testHDWallet {
+		hd, err := wallet.NewHDWallet(test.mnemonic, test.passphrase, config)
+		if err != nil {
+			t.Fatalf("NewHDWallet error: %s", err)
+		}
+		account, err := hd.Derive(test.index)
+		if err != nil {
+			t.Fatalf("Derive error: %s", err)
+		}
+		if account.Key.Address.String() != test.address {
+			t.Errorf("the ans is %s, but we got %s", test.address, account.Key.Address.String())
+		}
+	}
+}
+
+func TestNewMnemonic(t *testing.T) {
+	mnemonic, err := wallet.NewMnemonic(24)
+	if err != nil {
+		t.Fatalf("NewMnemonic error: %s", err)
+	}
+	_, err = wallet.NewHDWallet(mnemonic, "", config)
+	if err != nil {
+		t.Errorf("NewHDWallet error: %s", err)
+	}
+	_, err = wallet.NewMnemonic(13)
+	if err == nil {
+		t.Errorf("NewMnemonic should reject 13 words")
+	}
+}
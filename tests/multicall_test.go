@@ -0,0 +1,161 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/abi"
+	"github.com/tn606024/ethwallet/ethclient"
+	"github.com/tn606024/ethwallet/types"
+	"github.com/tn606024/ethwallet/utils"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var (
+	testAggregate3Calls = abi.Arguments{{Type: "tuple[]", Components: []abi.Argument{{Name: "target", Type: "address"}, {Name: "allowFailure", Type: "bool"}, {Name: "callData", Type: "bytes"}}}}
+	testAggregate3Results = abi.Arguments{{Type: "tuple[]", Components: []abi.Argument{{Name: "success", Type: "bool"}, {Name: "returnData", Type: "bytes"}}}}
+)
+
+// testContractCall answers calls to TestContractAddress as an erc20 and getEthBalance of multicall3, other calls revert
+func testContractCall(to common.Address, data []byte) ([]byte, bool) {
+	word := func(n int64) []byte { return common.LeftPadBytes(big.NewInt(n).Bytes(), 32) }
+	switch {
+	case to == types.Multicall3Address && strings.HasPrefix(fmt.Sprintf("%x", data), "4d2301cc"):
+		if common.BytesToAddress(data[4:]) == TestAddress {
+			return word(1000), true
+		}
+		return word(0), true
+	case to != TestContractAddress:
+		return nil, false
+	case strings.HasPrefix(fmt.Sprintf("%x", data), "70a08231"):
+		return word(12345), true
+	case strings.HasPrefix(fmt.Sprintf("%x", data), "06fdde03"):
+		return utils.HexStrToBytes(abiString("Test Token")), true
+	case strings.HasPrefix(fmt.Sprintf("%x", data), "95d89b41"):
+		return utils.HexStrToBytes(abiString("TST")), true
+	case strings.HasPrefix(fmt.Sprintf("%x", data), "313ce567"):
+		return word(2), true
+	}
+	return nil, false
+}
+
+// newMulticallNode is a node which has multicall3 if multicall, and counts eth_calls and eth_getBalance requests.
+// aggregate3 at block 0x1 reverts as if multicall3 wasn't deployed yet.
+func newMulticallNode(t *testing.T, multicall bool, counts map[string]int) *httptest.Server {
+	answer := func(req rpcRequest) map[string]interface{} {
+		res := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		counts[req.Method]++
+		switch req.Method {
+		case "eth_getCode":
+			res["result"] = "0x"
+			if multicall {
+				res["result"] = "0x6080"
+			}
+		case "eth_getBalance":
+			var address common.Address
+			json.Unmarshal(req.Params[0], &address)
+			res["result"] = "0x0"
+			if address == TestAddress {
+				res["result"] = fmt.Sprintf("0x%x", 1000)
+			}
+		case "eth_call":
+			var call types.TransactionRequest
+			var block string
+			json.Unmarshal(req.Params[0], &call)
+			json.Unmarshal(req.Params[1], &block)
+			to, data := common.HexToAddress(call.To), utils.HexStrToBytes(call.Data)
+			if to == types.Multicall3Address && strings.HasPrefix(call.Data, "0x82ad56cb") {
+				if !multicall || block == "0x1" {
+					res["error"] = map[string]interface{}{"code": 3, "message": "execution reverted"}
+					break
+				}
+				decoded, err := testAggregate3Calls.Unpack(data[4:])
+				if err != nil {
+					t.Fatalf("aggregate3 data can't be decoded: %s", err)
+				}
+				var results []interface{}
+				for _, item := range decoded[0].([]interface{}) {
+					fields := item.([]interface{})
+					ret, ok := testContractCall(fields[0].(common.Address), fields[2].([]byte))
+					if !ok && !fields[1].(bool) {
+						t.Errorf("calls should allow failure")
+					}
+					results = append(results, []interface{}{ok, ret})
+				}
+				encoded, _ := testAggregate3Results.Pack(results)
+				res["result"] = utils.BytesToHexStr(encoded)
+				break
+			}
+			if to == types.Multicall3Address && block == "0x1" {
+				res["result"] = "0x"
+				break
+			}
+			if ret, ok := testContractCall(to, data); ok {
+				res["result"] = "0x" + fmt.Sprintf("%x", ret)
+			} else {
+				res["error"] = map[string]interface{}{"code": 3, "message": "execution reverted"}
+			}
+		default:
+			res["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+		}
+		return res
+	}
+	return newRPCNode(t, answer)
+}
+
+func TestGenerateAggregate3Data(t *testing.T) {
+	data, err := types.GenerateAggregate3Data([]types.MulticallCall{{Target: TestContractAddress, AllowFailure: true, CallData: []byte{0x31, 0x3c, 0xe5, 0x67}}})
+	if err != nil {
+		t.Fatalf("GenerateAggregate3Data error: %s", err)
+	}
+	expected := "82ad56cb" + fmt.Sprintf("%064x%064x%064x", 0x20, 1, 0x20) + fmt.Sprintf("%064x", TestContractAddress.Bytes()) +
+		fmt.Sprintf("%064x%064x%064x", 1, 0x60, 4) + "313ce567" + strings.Repeat("0", 56)
+	if got := fmt.Sprintf("%x", data); got != expected {
+		t.Errorf("aggregate3 data is %s, expected %s", got, expected)
+	}
+	encoded, _ := testAggregate3Results.Pack([]interface{}{[]interface{}{true, []byte{1}}, []interface{}{false, []byte{}}})
+	results, err := types.DecodeAggregate3Result(encoded)
+	if err != nil || len(results) != 2 || !results[0].Success || results[0].ReturnData[0] != 1 || results[1].Success {
+		t.Errorf("aggregate3 result is decoded to %+v, %v", results, err)
+	}
+}
+
+func TestEthereumClient_Multicall(t *testing.T) {
+	other := common.HexToAddress("0x" + strings.Repeat("22", 20))
+	tokens := []*types.Erc20Token{{Symbol: "TST", Decimals: 2, Address: &TestContractAddress}}
+	for _, multicall := range []bool{true, false} {
+		counts := make(map[string]int)
+		node := newMulticallNode(t, multicall, counts)
+		client := ethclient.NewEthereumClient(node.URL, "", "", TestNetwork)
+
+		balances, err := client.GetErc20ListBalance(tokens, TestAddress)
+		if err != nil || balances["TST"].String() != "123.45" {
+			t.Errorf("multicall %v: erc20 balances are %v, %v", multicall, balances, err)
+		}
+		infos, errs, err := client.GetErc20Infos([]common.Address{TestContractAddress, other})
+		if err != nil || errs[0] != nil || infos[0].Symbol != "TST" || infos[0].Name != "Test Token" || infos[0].Decimals != 2 || errs[1] == nil {
+			t.Errorf("multicall %v: erc20 infos are %+v, %v, %v", multicall, infos, errs, err)
+		}
+		ethBalances, err := client.GetBalances([]common.Address{TestAddress, other}, types.Latest)
+		if err != nil || ethBalances[0].Int64() != 1000 || ethBalances[1].Int64() != 0 {
+			t.Errorf("multicall %v: balances are %v, %v", multicall, ethBalances, err)
+		}
+		if multicall && (counts["eth_call"] != 3 || counts["eth_getCode"] != 1 || counts["eth_getBalance"] != 0) {
+			t.Errorf("with multicall3 requests are %v, expected 3 eth_calls", counts)
+		}
+		if !multicall && (counts["eth_call"] != 7 || counts["eth_getBalance"] != 2) {
+			t.Errorf("without multicall3 requests are %v, expected 7 plain eth_calls and 2 eth_getBalance", counts)
+		}
+
+		// multicall3 isn't deployed at block 1, so eth_getBalance is used
+		getBalances := counts["eth_getBalance"]
+		ethBalances, err = client.GetBalances([]common.Address{TestAddress}, types.BlockParam("0x1"))
+		if err != nil || ethBalances[0].Int64() != 1000 || counts["eth_getBalance"] != getBalances+1 {
+			t.Errorf("multicall %v: balances at block 1 are %v, %v", multicall, ethBalances, err)
+		}
+		node.Close()
+	}
+}
@@ -0,0 +1,201 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/abi"
+	"github.com/tn606024/ethwallet/ethclient"
+	"github.com/tn606024/ethwallet/types"
+	"github.com/tn606024/ethwallet/utils"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var (
+	testErc721Address  = common.HexToAddress("0x" + strings.Repeat("77", 20))
+	testErc1155Address = common.HexToAddress("0x" + strings.Repeat("88", 20))
+)
+
+func TestGenerateNftData(t *testing.T) {
+	from, to := TestAddress, TestContractAddress
+	word := func(b []byte) string { return fmt.Sprintf("%064x", b) }
+	tests := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{"ownerOf", types.GenerateOwnerOfData(big.NewInt(5)), "6352211e" + fmt.Sprintf("%064x", 5)},
+		{"tokenURI", types.GenerateNftUriData(types.Erc721, big.NewInt(5)), "c87b56dd" + fmt.Sprintf("%064x", 5)},
+		{"uri", types.GenerateNftUriData(types.Erc1155, big.NewInt(5)), "0e89341c" + fmt.Sprintf("%064x", 5)},
+		{"balanceOf erc721", types.GenerateNftBalanceOfData(types.Erc721, &from, nil), "70a08231" + word(from.Bytes())},
+		{"balanceOf erc1155", types.GenerateNftBalanceOfData(types.Erc1155, &from, big.NewInt(5)), "00fdd58e" + word(from.Bytes()) + fmt.Sprintf("%064x", 5)},
+		{"safeTransferFrom erc721", types.GenerateErc721SafeTransferFromData(&from, &to, big.NewInt(5)), "42842e0e" + word(from.Bytes()) + word(to.Bytes()) + fmt.Sprintf("%064x", 5)},
+		{"supportsInterface", types.GenerateSupportsInterfaceData(types.Erc721InterfaceId), "01ffc9a780ac58cd" + strings.Repeat("0", 56)},
+	}
+	for _, test := range tests {
+		if got := fmt.Sprintf("%x", test.data); got != test.expected {
+			t.Errorf("%s data is %s, expected %s", test.name, got, test.expected)
+		}
+	}
+
+	single, err := types.GenerateErc1155SafeTransferFromData(&from, &to, big.NewInt(5), big.NewInt(3), []byte{})
+	if err != nil {
+		t.Fatalf("GenerateErc1155SafeTransferFromData error: %s", err)
+	}
+	expected := "f242432a" + word(from.Bytes()) + word(to.Bytes()) + fmt.Sprintf("%064x%064x%064x%064x", 5, 3, 0xa0, 0)
+	if got := fmt.Sprintf("%x", single); got != expected {
+		t.Errorf("erc1155 safeTransferFrom data is %s, expected %s", got, expected)
+	}
+	batch, err := types.GenerateErc1155SafeBatchTransferFromData(&from, &to, []*big.Int{big.NewInt(1), big.NewInt(2)}, []*big.Int{big.NewInt(3), big.NewInt(4)}, []byte{})
+	if err != nil {
+		t.Fatalf("GenerateErc1155SafeBatchTransferFromData error: %s", err)
+	}
+	expected = "2eb2c2d6" + word(from.Bytes()) + word(to.Bytes()) + fmt.Sprintf("%064x%064x%064x", 0xa0, 0x100, 0x160) +
+		fmt.Sprintf("%064x%064x%064x", 2, 1, 2) + fmt.Sprintf("%064x%064x%064x", 2, 3, 4) + fmt.Sprintf("%064x", 0)
+	if got := fmt.Sprintf("%x", batch); got != expected {
+		t.Errorf("erc1155 safeBatchTransferFrom data is %s, expected %s", got, expected)
+	}
+	if _, err := types.GenerateErc1155SafeBatchTransferFromData(&from, &to, []*big.Int{big.NewInt(1)}, nil, []byte{}); err == nil {
+		t.Errorf("token ids and amounts of different length should be illegal")
+	}
+}
+
+func TestDecodeErc1155TransferData(t *testing.T) {
+	ids, values, err := types.DecodeErc1155TransferData(types.Erc1155TransferSingleTopic, utils.HexStrToBytes(fmt.Sprintf("0x%064x%064x", 7, 2)))
+	if err != nil || len(ids) != 1 || ids[0].Int64() != 7 || values[0].Int64() != 2 {
+		t.Errorf("TransferSingle is decoded to %v %v, %v", ids, values, err)
+	}
+	data, _ := abi.Arguments{{Type: "uint256[]"}, {Type: "uint256[]"}}.Pack([]*big.Int{big.NewInt(1), big.NewInt(2)}, []*big.Int{big.NewInt(10), big.NewInt(20)})
+	ids, values, err = types.DecodeErc1155TransferData(types.Erc1155TransferBatchTopic, data)
+	if err != nil || len(ids) != 2 || ids[1].Int64() != 2 || values[1].Int64() != 20 {
+		t.Errorf("TransferBatch is decoded to %v %v, %v", ids, values, err)
+	}
+	if uri := types.Erc1155Uri("https://example.com/{id}.json", big.NewInt(0x4cce)); uri != "https://example.com/"+strings.Repeat("0", 60)+"4cce.json" {
+		t.Errorf("erc1155 uri is %s", uri)
+	}
+}
+
+// newNftNode is a node where TestAddress received erc721 tokens 1(still owned) and 2(sent away), and erc1155 tokens 5(balance 3)
+// and 6(balance 0) of a TransferBatch
+func newNftNode(t *testing.T) *httptest.Server {
+	holder := common.BytesToHash(TestAddress.Bytes()).Hex()
+	other := fmt.Sprintf("0x%064x", 1)
+	batchData, _ := abi.Arguments{{Type: "uint256[]"}, {Type: "uint256[]"}}.Pack([]*big.Int{big.NewInt(5), big.NewInt(6)}, []*big.Int{big.NewInt(3), big.NewInt(1)})
+	log := func(contract common.Address, block int, topics []string, data string) map[string]interface{} {
+		return map[string]interface{}{
+			"address": contract.Hex(), "topics": topics, "data": data, "blockNumber": fmt.Sprintf("0x%x", block),
+			"transactionHash": fmt.Sprintf("0x%064x", 5), "removed": false,
+		}
+	}
+	answer := func(req rpcRequest) map[string]interface{} {
+		res := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		switch req.Method {
+		case "eth_blockNumber":
+			res["result"] = fmt.Sprintf("0x%x", testLatestBlock)
+		case "eth_getLogs":
+			var query types.LogFilter
+			json.Unmarshal(req.Params[0], &query)
+			switch {
+			case len(query.Topics) == 3 && query.Topics[0][0] == types.Erc20TransferTopic && query.Topics[2][0].Hex() == holder:
+				res["result"] = logsInRange(t, req.Params[0], []map[string]interface{}{
+					log(testErc721Address, 5, []string{types.Erc20TransferTopic.Hex(), other, holder, fmt.Sprintf("0x%064x", 1)}, "0x"),
+					log(testErc721Address, 6000, []string{types.Erc20TransferTopic.Hex(), other, holder, fmt.Sprintf("0x%064x", 2)}, "0x"),
+					log(TestContractAddress, 11000, []string{types.Erc20TransferTopic.Hex(), other, holder}, fmt.Sprintf("0x%064x", 100)),
+				})
+			case len(query.Topics) == 4 && len(query.Topics[0]) == 2 && query.Topics[3][0].Hex() == holder:
+				res["result"] = logsInRange(t, req.Params[0], []map[string]interface{}{
+					log(testErc1155Address, 9000, []string{types.Erc1155TransferBatchTopic.Hex(), other, other, holder}, utils.BytesToHexStr(batchData)),
+				})
+			default:
+				t.Errorf("unexpected log filter: %s", string(req.Params[0]))
+				res["result"] = []interface{}{}
+			}
+		case "eth_call":
+			var call types.TransactionRequest
+			json.Unmarshal(req.Params[0], &call)
+			switch {
+			case call.Data == utils.BytesToHexStr(types.GenerateSupportsInterfaceData(types.Erc721InterfaceId)):
+				res["result"] = fmt.Sprintf("0x%064x", 0)
+				if strings.EqualFold(call.To, testErc721Address.Hex()) {
+					res["result"] = fmt.Sprintf("0x%064x", 1)
+				}
+			case call.Data == utils.BytesToHexStr(types.GenerateSupportsInterfaceData(types.Erc1155InterfaceId)):
+				res["result"] = fmt.Sprintf("0x%064x", 0)
+				if strings.EqualFold(call.To, testErc1155Address.Hex()) {
+					res["result"] = fmt.Sprintf("0x%064x", 1)
+				}
+			case call.Data == utils.BytesToHexStr(types.GenerateOwnerOfData(big.NewInt(1))):
+				res["result"] = common.BytesToHash(TestAddress.Bytes()).Hex()
+			case call.Data == utils.BytesToHexStr(types.GenerateOwnerOfData(big.NewInt(2))):
+				res["result"] = other
+			case call.Data == utils.BytesToHexStr(types.GenerateNftBalanceOfData(types.Erc1155, &TestAddress, big.NewInt(5))):
+				res["result"] = fmt.Sprintf("0x%064x", 3)
+			case call.Data == utils.BytesToHexStr(types.GenerateNftBalanceOfData(types.Erc1155, &TestAddress, big.NewInt(6))):
+				res["result"] = fmt.Sprintf("0x%064x", 0)
+			case strings.HasPrefix(call.Data, "0xc87b56dd"):
+				res["result"] = abiString("ipfs://token/1")
+			case strings.HasPrefix(call.Data, "0x0e89341c"):
+				res["result"] = abiString("https://example.com/{id}.json")
+			default:
+				res["error"] = map[string]interface{}{"code": 3, "message": "execution reverted"}
+			}
+		default:
+			res["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+		}
+		return res
+	}
+	return newRPCNode(t, answer)
+}
+
+func TestEthereumClient_GetNfts(t *testing.T) {
+	node := newNftNode(t)
+	defer node.Close()
+	client := ethclient.NewEthereumClient(node.URL, "", "", TestNetwork)
+	nfts, err := client.GetNfts(TestAddress, 0)
+	if err != nil {
+		t.Fatalf("GetNfts error: %s", err)
+	}
+	if len(nfts) != 2 {
+		t.Fatalf("got %d nfts, expected 2: %+v", len(nfts), nfts)
+	}
+	erc721, erc1155 := nfts[0], nfts[1]
+	if erc721.Contract != testErc721Address || erc721.Standard != types.Erc721 || (*big.Int)(erc721.TokenId).Int64() != 1 ||
+		(*big.Int)(erc721.Balance).Int64() != 1 || erc721.Uri != "ipfs://token/1" {
+		t.Errorf("unexpected erc721 token: %+v", erc721)
+	}
+	if erc1155.Contract != testErc1155Address || erc1155.Standard != types.Erc1155 || (*big.Int)(erc1155.TokenId).Int64() != 5 ||
+		(*big.Int)(erc1155.Balance).Int64() != 3 || erc1155.Uri != "https://example.com/"+fmt.Sprintf("%064x", 5)+".json" {
+		t.Errorf("unexpected erc1155 token: %+v", erc1155)
+	}
+}
+
+func TestEthereumClient_GetNft(t *testing.T) {
+	node := newNftNode(t)
+	defer node.Close()
+	client := ethclient.NewEthereumClient(node.URL, "", "", TestNetwork)
+	tests := []struct {
+		contract common.Address
+		tokenId  int64
+		standard string
+		balance  int64
+	}{
+		{testErc721Address, 1, types.Erc721, 1},
+		{testErc721Address, 2, types.Erc721, 0},
+		{testErc1155Address, 5, types.Erc1155, 3},
+	}
+	for _, test := range tests {
+		nft, err := client.GetNft(&test.contract, big.NewInt(test.tokenId), TestAddress)
+		if err != nil {
+			t.Fatalf("GetNft of token %d error: %s", test.tokenId, err)
+		}
+		if nft.Standard != test.standard || (*big.Int)(nft.Balance).Int64() != test.balance || (test.standard == types.Erc721) != (nft.Owner != nil) {
+			t.Errorf("unexpected token %d: %+v", test.tokenId, nft)
+		}
+	}
+	if _, err := client.GetNft(&TestContractAddress, big.NewInt(1), TestAddress); err == nil {
+		t.Errorf("GetNft of an erc20 should be an error")
+	}
+}
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/tn606024/ethwallet/wallet"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestNonceManager(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nonces")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "nonces.json")
+	m := wallet.NewNonceManager(path)
+	chainId := TestNetwork.ChainId
+	acquire := func(pending uint64) uint64 {
+		nonce, err := m.Acquire(chainId, TestPrivateKeyAddress, pending)
+		if err != nil {
+			t.Fatalf("Acquire error: %s", err)
+		}
+		return nonce
+	}
+	// node doesn't count sends yet, they still get different nonces
+	for i := uint64(5); i < 8; i++ {
+		if nonce := acquire(5); nonce != i {
+			t.Errorf("nonce is %d, expected %d", nonce, i)
+		}
+	}
+	// a failed send leaves a gap which is filled first
+	if err := m.Release(chainId, TestPrivateKeyAddress, 6); err != nil {
+		t.Fatalf("Release error: %s", err)
+	}
+	if nonce := acquire(5); nonce != 6 {
+		t.Errorf("gap nonce is %d, expected 6", nonce)
+	}
+	if nonce := acquire(6); nonce != 8 {
+		t.Errorf("nonce is %d, expected 8", nonce)
+	}
+	// another wallet sent transactions, node's pending count is ahead
+	if nonce := acquire(12); nonce != 12 {
+		t.Errorf("nonce is %d after resync, expected 12", nonce)
+	}
+	if nonce, _ := m.Acquire(chainId, TestAddress, 0); nonce != 0 {
+		t.Errorf("nonce of another address is %d, expected 0", nonce)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("nonce file is not saved: %s", err)
+	}
+	var saved map[string]struct {
+		Next     uint64           `json:"next"`
+		Reserved map[uint64]int64 `json:"reserved"`
+	}
+	if err := json.Unmarshal(b, &saved); err != nil {
+		t.Fatalf("nonce file unmarshal error: %s", err)
+	}
+	account := saved[chainId.String()+":0x2c7536e3605d9c16a7a3d7b1898e529396a65c23"]
+	if account.Next != 13 || len(account.Reserved) != 1 {
+		t.Errorf("unexpected saved account: %+v", account)
+	}
+	if wallet.NewNonceManager(path) != m {
+		t.Errorf("managers of the same file are not shared")
+	}
+}
+
+func TestNonceManager_Concurrent(t *testing.T) {
+	m := wallet.NewNonceManager("")
+	var wg sync.WaitGroup
+	var mux sync.Mutex
+	seen := map[uint64]bool{}
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nonce, err := m.Acquire(TestNetwork.ChainId, TestPrivateKeyAddress, 100)
+			if err != nil {
+				t.Errorf("Acquire error: %s", err)
+				return
+			}
+			mux.Lock()
+			defer mux.Unlock()
+			if seen[nonce] {
+				t.Errorf("nonce %d is handed out twice", nonce)
+			}
+			seen[nonce] = true
+		}()
+	}
+	wg.Wait()
+	for i := uint64(100); i < 150; i++ {
+		if !seen[i] {
+			t.Errorf("nonce %d is skipped", i)
+		}
+	}
+}
+
+// managers of a directory and its symlink act like two processes sharing the nonce file
+func TestNonceManager_SharedFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nonces")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(dir, link); err != nil {
+		t.Skipf("symlink isn't supported: %s", err)
+	}
+	managers := []*wallet.NonceManager{
+		wallet.NewNonceManager(filepath.Join(dir, "nonces.json")),
+		wallet.NewNonceManager(filepath.Join(link, "nonces.json")),
+	}
+	if managers[0] == managers[1] {
+		t.Fatalf("managers should be different")
+	}
+	var wg sync.WaitGroup
+	var mux sync.Mutex
+	seen := map[uint64]bool{}
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func(m *wallet.NonceManager) {
+			defer wg.Done()
+			nonce, err := m.Acquire(TestNetwork.ChainId, TestPrivateKeyAddress, 0)
+			if err != nil {
+				t.Errorf("Acquire error: %s", err)
+				return
+			}
+			mux.Lock()
+			defer mux.Unlock()
+			if seen[nonce] {
+				t.Errorf("nonce %d is handed out twice", nonce)
+			}
+			seen[nonce] = true
+		}(managers[i%2])
+	}
+	wg.Wait()
+	if len(seen) != 200 {
+		t.Errorf("%d nonces are handed out, expected 200", len(seen))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "nonces.json.lock")); !os.IsNotExist(err) {
+		t.Errorf("lock file is left: %v", err)
+	}
+}
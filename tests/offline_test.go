@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/types"
+	"github.com/tn606024/ethwallet/wallet"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestOfflineTx(t *testing.T) {
+	w, err := wallet.ImportWallet(TestWalletAuth.auth, TestWalletAuth.path, types.Config{Network: TestNetwork})
+	if err != nil {
+		t.Fatalf("ImportWallet error: %s", err)
+	}
+	token := &types.Erc20Token{Decimals: 6, Symbol: "USDT", Address: &TestContractAddress}
+	tx := &types.Transaction{
+		Type:                 types.DynamicFeeTxType,
+		Nonce:                3,
+		MaxFeePerGas:         big.NewInt(30000000000),
+		MaxPriorityFeePerGas: big.NewInt(1500000000),
+		GasLimit:             60000,
+		From:                 &TestAddress,
+		To:                   &TestContractAddress,
+		Value:                big.NewInt(0),
+		Data:                 token.GenerateTransferData(big.NewInt(25000000), &TestPrivateKeyAddress),
+	}
+	o := types.NewOfflineTx(TestNetwork, tx, token)
+	summary := o.Summary()
+	for _, line := range []string{"send: 25 USDT", "to: " + TestPrivateKeyAddress.String(), "max fee: 30 gwei, tip: 1.5 gwei", "max cost: 0.0018 ether"} {
+		if !strings.Contains(summary, line) {
+			t.Errorf("summary doesn't contain %q:\n%s", line, summary)
+		}
+	}
+	if _, err := o.Verify(); err == nil {
+		t.Errorf("unsigned offline tx is verified")
+	}
+	if err := w.SignOfflineTx(o); err != nil {
+		t.Fatalf("SignOfflineTx error: %s", err)
+	}
+	payload, err := o.Base64()
+	if err != nil {
+		t.Fatalf("Base64 error: %s", err)
+	}
+	parsed, err := types.ParseOfflineTx([]byte(payload))
+	if err != nil {
+		t.Fatalf("ParseOfflineTx error: %s", err)
+	}
+	signed, err := parsed.Verify()
+	if err != nil {
+		t.Fatalf("Verify error: %s", err)
+	}
+	if *signed.From != TestAddress || parsed.Hash != o.Hash {
+		t.Errorf("signed by %s with hash %s, expected %s", signed.From.String(), parsed.Hash, o.Hash)
+	}
+	parsed.Transaction.Value = big.NewInt(1)
+	if _, err := parsed.Verify(); err == nil {
+		t.Errorf("raw different from transaction is verified")
+	}
+	parsed.Transaction.Value = big.NewInt(0)
+	parsed.Transaction.AccessList = types.AccessList{{Address: TestContractAddress, StorageKeys: []common.Hash{{1}}}}
+	if _, err := parsed.Verify(); err == nil || !strings.Contains(err.Error(), "access list") {
+		t.Errorf("raw with a different access list got error %v", err)
+	}
+
+	other := types.NewOfflineTx(types.EthereumNet, &types.Transaction{From: &TestAddress, To: &TestAddress, Value: big.NewInt(1), GasPrice: big.NewInt(1)}, nil)
+	if err := w.SignOfflineTx(other); err == nil {
+		t.Errorf("offline tx of another network is signed")
+	}
+}
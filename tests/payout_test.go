@@ -0,0 +1,206 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/tn606024/ethwallet/types"
+	"github.com/tn606024/ethwallet/wallet"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestReadPayoutCsv(t *testing.T) {
+	tokens := []*types.Erc20Token{{Decimals: 6, Symbol: "USDT", Address: &TestContractAddress}}
+	csv := "recipient,symbol,amount\n" +
+		TestAddress.String() + ",ETH,1.5\n" +
+		strings.ToLower(TestPrivateKeyAddress.String()) + ", USDT, 0.000001\n" +
+		TestAddress.String() + ",eth,2\n"
+	payouts, err := types.ReadPayoutCsv(strings.NewReader(csv), tokens, nil)
+	if err != nil {
+		t.Fatalf("ReadPayoutCsv error: %s", err)
+	}
+	expected := []struct {
+		line  int
+		value string
+		token bool
+	}{
+		{2, "1500000000000000000", false},
+		{3, "1", true},
+		{4, "2000000000000000000", false},
+	}
+	if len(payouts) != len(expected) {
+		t.Fatalf("got %d payouts, expected %d", len(payouts), len(expected))
+	}
+	for i, e := range expected {
+		p := payouts[i]
+		if p.Line != e.line || p.Value.String() != e.value || (p.Token != nil) != e.token {
+			t.Errorf("payout %d is line %d value %s token %v, expected %+v", i, p.Line, p.Value.String(), p.Token != nil, e)
+		}
+	}
+	if payouts[1].Recipient != TestPrivateKeyAddress {
+		t.Errorf("recipient is %s, expected %s", payouts[1].Recipient.String(), TestPrivateKeyAddress.String())
+	}
+
+	bad := "0x1234,ETH,1\n" +
+		TestAddress.String() + ",DAI,1\n" +
+		TestAddress.String() + ",USDT,0.0000001\n" +
+		TestAddress.String() + ",ETH,-1\n" +
+		TestAddress.String() + ",ETH,0\n" +
+		"0x0000000000000000000000000000000000000000,ETH,1\n"
+	_, err = types.ReadPayoutCsv(strings.NewReader(bad), tokens, nil)
+	if err == nil {
+		t.Fatalf("invalid payout csv is read")
+	}
+	for i := 1; i <= 6; i++ {
+		if !strings.Contains(err.Error(), "line "+strconv.Itoa(i)+":") {
+			t.Errorf("error of line %d is not reported: %s", i, err)
+		}
+	}
+
+	// an eip1191 checksum is only valid on its chain
+	rsk := "0x5aaEB6053f3e94c9b9a09f33669435E7ef1bEAeD,ETH,1\n"
+	if _, err := types.ReadPayoutCsv(strings.NewReader(rsk), nil, big.NewInt(30)); err != nil {
+		t.Errorf("eip1191 recipient on chain 30 error: %s", err)
+	}
+	if _, err := types.ReadPayoutCsv(strings.NewReader(rsk), nil, big.NewInt(1)); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Errorf("eip1191 recipient on chain 1 got error %v, expected a checksum error", err)
+	}
+}
+
+func TestPayoutResults(t *testing.T) {
+	csv := TestAddress.String() + ",ETH,1\n" + TestPrivateKeyAddress.String() + ",ETH,2\n"
+	payouts, err := types.ReadPayoutCsv(strings.NewReader(csv), nil, nil)
+	if err != nil {
+		t.Fatalf("ReadPayoutCsv error: %s", err)
+	}
+	payouts[0].Nonce, payouts[0].Txid, payouts[0].Status, payouts[0].Raw = 7, TestTransaction, types.PayoutSent, "0x02f8"
+	payouts[1].Nonce, payouts[1].Status, payouts[1].Error = 8, types.PayoutFailed, "nonce too low, replacement"
+	var buf bytes.Buffer
+	if err := types.WritePayoutResults(&buf, payouts); err != nil {
+		t.Fatalf("WritePayoutResults error: %s", err)
+	}
+
+	resumed, _ := types.ReadPayoutCsv(strings.NewReader(csv), nil, nil)
+	if err := types.ReadPayoutResults(bytes.NewReader(buf.Bytes()), resumed); err != nil {
+		t.Fatalf("ReadPayoutResults error: %s", err)
+	}
+	for i := range payouts {
+		p, r := payouts[i], resumed[i]
+		if p.Nonce != r.Nonce || p.Txid != r.Txid || p.Status != r.Status || p.Error != r.Error || p.Raw != r.Raw {
+			t.Errorf("resumed payout %d is %+v, expected %+v", i, r, p)
+		}
+	}
+
+	changed, _ := types.ReadPayoutCsv(strings.NewReader(TestAddress.String()+",ETH,1\n"+TestPrivateKeyAddress.String()+",ETH,3\n"), nil, nil)
+	if err := types.ReadPayoutResults(bytes.NewReader(buf.Bytes()), changed); err == nil {
+		t.Errorf("results of a changed csv are read")
+	}
+}
+
+// newPayoutServer answers transactions of txs by txid, node's latest and pending nonce are both 5.
+// Raw transactions sent are recorded.
+func newPayoutServer(t *testing.T, txs map[string]map[string]interface{}, sent chan<- string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var result interface{}
+		switch r.URL.Path {
+		case "/tx":
+			tx, ok := txs[r.URL.Query().Get("txid")]
+			if !ok {
+				tx = map[string]interface{}{}
+			}
+			result = tx
+		case "/nonce":
+			result = "5"
+		case "/txparams":
+			result = map[string]interface{}{"nonce": "5", "gasprice": 1000, "gaslimit": "21000"}
+		case "/balance":
+			result = "1000000000000000000000"
+		case "/send":
+			var raw types.Raw
+			json.NewDecoder(r.Body).Decode(&raw)
+			sent <- raw.Hex
+			result = "0x01"
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+	}))
+}
+
+func TestEthereumWallet_SendPayouts_Resume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "payouts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	csv := ""
+	for i := 0; i < 5; i++ {
+		csv += TestAddress.String() + ",ETH," + strconv.Itoa(i+1) + "\n"
+	}
+	payouts, err := types.ReadPayoutCsv(strings.NewReader(csv), nil, TestNetwork.ChainId)
+	if err != nil {
+		t.Fatalf("ReadPayoutCsv error: %s", err)
+	}
+	set := func(p *types.Payout, status string, nonce uint64, txid string) {
+		p.Status, p.Nonce, p.Txid, p.Raw = status, nonce, txid, "0xraw"+txid[2:]
+	}
+	// mined, its send only errored
+	set(payouts[0], types.PayoutFailed, 2, "0xa1")
+	// not found and nonce 3 is used by a mined transaction, it gets a new nonce
+	set(payouts[1], types.PayoutFailed, 3, "0xb2")
+	// not found and nonce 5 is unused, it's signed again at nonce 5
+	set(payouts[2], types.PayoutFailed, 5, "0xc3")
+	// signed and not found, sent again at nonce 6 which a new payout mustn't get
+	set(payouts[3], types.PayoutSigned, 6, "0xd4")
+	// still pending, sent again as it is
+	set(payouts[4], types.PayoutFailed, 4, "0xe5")
+	txs := map[string]map[string]interface{}{
+		"0xa1": {"hash": "0xa1", "blockHash": "0x01"},
+		"0xe5": {"hash": "0xe5"},
+	}
+	sent := make(chan string, len(payouts))
+	ts := newPayoutServer(t, txs, sent)
+	defer ts.Close()
+	config := types.Config{ServerUrl: ts.URL, Network: TestNetwork, NonceFile: filepath.Join(dir, "nonces.json")}
+	ew, err := wallet.ImportEthereumWallet(TestWalletAuth.auth, TestWalletAuth.path, config)
+	if err != nil {
+		t.Fatalf("ImportEthereumWallet error: %s", err)
+	}
+	if err := ew.SendPayouts(payouts, 2, nil, nil, nil, func() error { return nil }); err != nil {
+		t.Fatalf("SendPayouts error: %s", err)
+	}
+	close(sent)
+
+	resent := map[string]bool{}
+	nonces := map[uint64]string{}
+	for raw := range sent {
+		if strings.HasPrefix(raw, "0xraw") {
+			resent[raw] = true
+			continue
+		}
+		tx, err := types.DecodeRawTx(raw)
+		if err != nil {
+			t.Fatalf("DecodeRawTx error: %s", err)
+		}
+		nonces[tx.Nonce] = tx.Value.String()
+	}
+	if len(resent) != 2 || !resent["0xrawd4"] || !resent["0xrawe5"] {
+		t.Errorf("resent raws are %v, expected 0xrawd4 and 0xrawe5", resent)
+	}
+	expected := map[uint64]string{5: "3000000000000000000", 7: "2000000000000000000"}
+	if len(nonces) != len(expected) || nonces[5] != expected[5] || nonces[7] != expected[7] {
+		t.Errorf("signed again nonce to value are %v, expected %v", nonces, expected)
+	}
+	for i, p := range payouts {
+		if p.Status != types.PayoutSent {
+			t.Errorf("payout %d is %s: %s", i, p.Status, p.Error)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tn606024/ethwallet/ethclient"
+	"github.com/tn606024/ethwallet/types"
+	"github.com/tn606024/ethwallet/utils"
+	"github.com/tn606024/ethwallet/wallet"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// permitDomainSeparator hashes the domain of TestContractAddress on TestNetwork, version is left out if it's empty
+func permitDomainSeparator(name string, version string) string {
+	domainType := "EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"
+	if version == "" {
+		domainType = "EIP712Domain(string name,uint256 chainId,address verifyingContract)"
+	}
+	data := crypto.Keccak256([]byte(domainType))
+	data = append(data, crypto.Keccak256([]byte(name))...)
+	if version != "" {
+		data = append(data, crypto.Keccak256([]byte(version))...)
+	}
+	data = append(data, common.LeftPadBytes(TestNetwork.ChainId.Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(TestContractAddress.Bytes(), 32)...)
+	return utils.BytesToHexStr(crypto.Keccak256(data))
+}
+
+// newPermitNode is a token without version() whose domain has version 2 and nonce of owner is 5
+func newPermitNode(t *testing.T) *httptest.Server {
+	return newRPCNode(t, func(req rpcRequest) map[string]interface{} {
+		res := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		var call types.TransactionRequest
+		json.Unmarshal(req.Params[0], &call)
+		switch {
+		case req.Method != "eth_call" || !strings.EqualFold(call.To, TestContractAddress.Hex()):
+			t.Errorf("unexpected request: %s %s", req.Method, call.To)
+		case strings.HasPrefix(call.Data, "0x7ecebe00"):
+			if !strings.HasSuffix(call.Data, strings.ToLower(TestPrivateKeyAddress.Hex()[2:])) {
+				t.Errorf("nonces of unexpected owner: %s", call.Data)
+			}
+			res["result"] = fmt.Sprintf("0x%064x", 5)
+		case strings.HasPrefix(call.Data, "0x3644e515"):
+			res["result"] = permitDomainSeparator("Test Token", "2")
+		case strings.HasPrefix(call.Data, "0x06fdde03"):
+			res["result"] = abiString("Test Token")
+		default:
+			res["error"] = map[string]interface{}{"code": 3, "message": "execution reverted"}
+		}
+		return res
+	})
+}
+
+func TestEthereumClient_GetPermitDomain(t *testing.T) {
+	node := newPermitNode(t)
+	defer node.Close()
+	client := ethclient.NewEthereumClient(node.URL, "", "", TestNetwork)
+	domain, err := client.GetPermitDomain(&TestContractAddress, TestPrivateKeyAddress)
+	if err != nil {
+		t.Fatalf("GetPermitDomain error: %s", err)
+	}
+	if domain.Name != "Test Token" || domain.Version != "" || (*big.Int)(domain.Nonce).Int64() != 5 || (*big.Int)(domain.ChainId).Cmp(TestNetwork.ChainId) != 0 {
+		t.Errorf("unexpected domain: %+v", domain)
+	}
+}
+
+func TestWallet_SignPermit(t *testing.T) {
+	w := newTestWallet(t, TestNetwork)
+	spender := common.HexToAddress("0x" + strings.Repeat("11", 20))
+	domain := &types.PermitDomain{
+		Name:              "Test Token",
+		ChainId:           (*types.BigIntHex)(TestNetwork.ChainId),
+		VerifyingContract: TestContractAddress,
+		DomainSeparator:   permitDomainSeparator("Test Token", "2"),
+		Owner:             TestPrivateKeyAddress,
+		Nonce:             (*types.BigIntHex)(big.NewInt(5)),
+	}
+	value, deadline := big.NewInt(1500000), big.NewInt(1700000000)
+	permit, err := w.SignPermit(domain, spender, value, deadline)
+	if err != nil {
+		t.Fatalf("SignPermit error: %s", err)
+	}
+	if permit.V != 27 && permit.V != 28 || permit.Signature != permit.R+permit.S[2:]+fmt.Sprintf("%02x", permit.V) {
+		t.Errorf("v, r and s don't match signature: %+v", permit)
+	}
+	typedData, err := domain.TypedData(domain.NewPermit(spender, value, deadline))
+	if err != nil {
+		t.Fatalf("TypedData error: %s", err)
+	}
+	if typedData.Domain["version"] != "2" {
+		t.Errorf("domain version is %v, expected 2", typedData.Domain["version"])
+	}
+	ok, err := wallet.VerifyTypedData(TestPrivateKeyAddress, utils.HexStrToBytes(permit.Signature), typedData)
+	if err != nil || !ok {
+		t.Errorf("signature isn't signed by owner: %v %s", ok, err)
+	}
+	expectedData := "0xd505accf" + fmt.Sprintf("%064s%064s%064x%064x%064x", strings.ToLower(TestPrivateKeyAddress.Hex()[2:]), strings.ToLower(spender.Hex()[2:]), 1500000, 1700000000, permit.V) + permit.R[2:] + permit.S[2:]
+	if permit.Data != expectedData {
+		t.Errorf("permit data is %s, expected %s", permit.Data, expectedData)
+	}
+
+	domain.DomainSeparator = permitDomainSeparator("Other Token", "1")
+	if _, err := w.SignPermit(domain, spender, value, deadline); err == nil {
+		t.Errorf("SignPermit should fail if DOMAIN_SEPARATOR doesn't match")
+	}
+	domain.Owner = TestAddress
+	if _, err := w.SignPermit(domain, spender, value, deadline); err == nil {
+		t.Errorf("SignPermit should fail if domain is of another owner")
+	}
+}
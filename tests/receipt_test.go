@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/tn606024/ethwallet/types"
+	"github.com/tn606024/ethwallet/wallet"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newReorgServer plays a transaction mined in block 10, dropped by a reorg, then mined again in block 11,
+// every receipt poll moves the chain one step forward.
+func newReorgServer() *httptest.Server {
+	var mux sync.Mutex
+	step := 0
+	receipts := []interface{}{
+		map[string]interface{}{"blockNumber": "10", "blockHash": "0xaa", "status": "1", "gasUsed": "21000"},
+		nil,
+		map[string]interface{}{"blockNumber": "11", "blockHash": "0xbb", "status": "1", "gasUsed": "21000", "effectiveGasPrice": "1000000000"},
+	}
+	heads := []string{"10", "10", "12", "13"}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.Lock()
+		defer mux.Unlock()
+		var result interface{}
+		switch r.URL.Path {
+		case "/receipt":
+			if step < len(heads) {
+				step++
+			}
+			result = receipts[2]
+			if step <= len(receipts) {
+				result = receipts[step-1]
+			}
+		case "/block":
+			result = heads[step-1]
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+	}))
+}
+
+func TestEthereumWallet_WaitForConfirmations(t *testing.T) {
+	ts := newReorgServer()
+	defer ts.Close()
+	ew := wallet.ImportLookupEthereumWallet(TestPrivateKeyAddress, types.Config{ServerUrl: ts.URL, Network: TestNetwork})
+	var reorged []*types.NodeReceipt
+	receipt, err := ew.WaitForConfirmations(TestTransaction, 3, time.Millisecond, time.Second, func(old *types.NodeReceipt) {
+		reorged = append(reorged, old)
+	})
+	if err != nil {
+		t.Fatalf("WaitForConfirmations error: %s", err)
+	}
+	if receipt.BlockHash != "0xbb" || receipt.BlockNumber != 11 || receipt.GasUsed != 21000 {
+		t.Errorf("unexpected receipt: %+v", receipt)
+	}
+	if len(reorged) != 1 || reorged[0].BlockHash != "0xaa" {
+		t.Errorf("reorg is not noticed: %v", reorged)
+	}
+	_, err = ew.WaitForConfirmations(TestTransaction, 10, time.Millisecond, 20*time.Millisecond, nil)
+	if err == nil {
+		t.Errorf("expects timeout error")
+	}
+}
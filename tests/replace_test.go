@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/tn606024/ethwallet/types"
+	"github.com/tn606024/ethwallet/wallet"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newReplaceServer serves a pending transaction of TestAddress and decodes the raw transaction sent
+func newReplaceServer(t *testing.T, pending map[string]interface{}, sent chan<- *types.Transaction) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var result interface{}
+		switch r.URL.Path {
+		case "/tx":
+			result = pending
+		case "/txparams":
+			result = map[string]interface{}{"nonce": "9", "gasprice": 1000, "basefee": 100, "maxpriorityfee": 50, "gaslimit": "0"}
+		case "/balance":
+			result = "1000000000000000000"
+		case "/send":
+			var raw types.Raw
+			json.NewDecoder(r.Body).Decode(&raw)
+			tx, err := types.DecodeRawTx(raw.Hex)
+			if err != nil {
+				t.Errorf("DecodeRawTx error: %s", err)
+			}
+			sent <- tx
+			result = "0x01"
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+	}))
+}
+
+func TestEthereumWallet_ReplaceTransaction(t *testing.T) {
+	tests := []struct {
+		name     string
+		pending  map[string]interface{}
+		cancel   bool
+		tip      *big.Int
+		expected types.Transaction
+	}{
+		{
+			name: "speedup legacy",
+			pending: map[string]interface{}{"hash": "0xaa", "type": "0", "nonce": "7", "from": TestAddress.String(), "to": TestContractAddress.String(),
+				"value": "5", "gas": "50000", "gasPrice": "900", "input": "0xa9059cbb"},
+			// bumped 990 is lower than node's gas price 1000
+			expected: types.Transaction{Type: types.LegacyTxType, Nonce: 7, GasPrice: big.NewInt(1000), GasLimit: 50000, To: &TestContractAddress, Value: big.NewInt(5), Data: []byte{0xa9, 0x05, 0x9c, 0xbb}},
+		},
+		{
+			name: "cancel eip1559",
+			pending: map[string]interface{}{"hash": "0xaa", "type": "2", "nonce": "8", "from": TestAddress.String(), "to": TestContractAddress.String(),
+				"value": "5", "gas": "50000", "gasPrice": "500", "maxFeePerGas": "500", "maxPriorityFeePerGas": "101", "input": "0xa9059cbb"},
+			cancel: true,
+			// tip 101 is bumped to 112, max fee is 2 * base fee + tip
+			expected: types.Transaction{Type: types.DynamicFeeTxType, Nonce: 8, MaxFeePerGas: big.NewInt(550), MaxPriorityFeePerGas: big.NewInt(112), GasLimit: 21000, To: &TestAddress, Value: big.NewInt(0)},
+		},
+	}
+	for _, test := range tests {
+		sent := make(chan *types.Transaction, 1)
+		ts := newReplaceServer(t, test.pending, sent)
+		ew, err := wallet.ImportEthereumWallet(TestWalletAuth.auth, TestWalletAuth.path, types.Config{ServerUrl: ts.URL, Network: TestNetwork})
+		if err != nil {
+			t.Fatalf("ImportEthereumWallet error: %s", err)
+		}
+		if test.cancel {
+			_, _, err = ew.CancelTransaction("0xaa", nil, nil, test.tip)
+		} else {
+			_, _, err = ew.SpeedUpTransaction("0xaa", nil, nil, test.tip)
+		}
+		ts.Close()
+		if err != nil {
+			t.Errorf("%s error: %s", test.name, err)
+			continue
+		}
+		tx := <-sent
+		e := test.expected
+		if tx.Type != e.Type || tx.Nonce != e.Nonce || tx.GasLimit != e.GasLimit || *tx.To != *e.To || tx.Value.Cmp(e.Value) != 0 || string(tx.Data) != string(e.Data) {
+			t.Errorf("%s sent unexpected tx: %+v", test.name, tx)
+		}
+		if e.Type == types.LegacyTxType && tx.GasPrice.Cmp(e.GasPrice) != 0 {
+			t.Errorf("%s gasprice is %s, expected %s", test.name, tx.GasPrice, e.GasPrice)
+		}
+		if e.Type == types.DynamicFeeTxType && (tx.MaxFeePerGas.Cmp(e.MaxFeePerGas) != 0 || tx.MaxPriorityFeePerGas.Cmp(e.MaxPriorityFeePerGas) != 0) {
+			t.Errorf("%s fees are %s/%s, expected %s/%s", test.name, tx.MaxFeePerGas, tx.MaxPriorityFeePerGas, e.MaxFeePerGas, e.MaxPriorityFeePerGas)
+		}
+	}
+
+	ts := newReplaceServer(t, map[string]interface{}{"hash": "0xaa", "type": "0", "nonce": "7", "from": TestAddress.String(), "gasPrice": "1000", "value": "0"}, nil)
+	defer ts.Close()
+	ew, _ := wallet.ImportEthereumWallet(TestWalletAuth.auth, TestWalletAuth.path, types.Config{ServerUrl: ts.URL, Network: TestNetwork})
+	if _, _, err := ew.SpeedUpTransaction("0xaa", big.NewInt(1050), nil, nil); err == nil {
+		t.Errorf("gasprice lower than 10%% bump is accepted")
+	}
+}
@@ -3,6 +3,7 @@ package tests
 import (
 	"bytes"
 	"github.com/tn606024/ethwallet/rlp"
+	"github.com/tn606024/ethwallet/utils"
 	"testing"
 )
 
@@ -14,4 +15,45 @@ func TestEncodeList(t *testing.T) {
 		}
 	}
 
-}
\ No newline at end of file
+}
+
+func TestEncodeAccessList(t *testing.T) {
+	res := testAccessList.ToRLP()
+	if bytes.Compare(res, utils.HexStrToBytes(testAccessListRlp)) != 0 {
+		t.Errorf("the ans is %s, but we got %x", testAccessListRlp, res)
+	}
+	res = rlp.EncodeAccessList(nil, nil)
+	if bytes.Compare(res, []byte{0xc0}) != 0 {
+		t.Errorf("the ans is c0, but we got %x", res)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	for _, test := range testRlpArray {
+		list, err := rlp.DecodeList(test.res)
+		if err != nil {
+			t.Fatalf("DecodeList error: %s", err)
+		}
+		if len(list) != len(test.arr) {
+			t.Fatalf("the ans has %d items, but we got %d", len(test.arr), len(list))
+		}
+		for i, item := range list {
+			if bytes.Compare(item.([]byte), test.arr[i]) != 0 {
+				t.Errorf("the ans is %x, but we got %x", test.arr[i], item)
+			}
+		}
+	}
+	item, err := rlp.Decode(utils.HexStrToBytes(testAccessListRlp))
+	if err != nil {
+		t.Fatalf("Decode error: %s", err)
+	}
+	if bytes.Compare(rlp.Encode(item), utils.HexStrToBytes(testAccessListRlp)) != 0 {
+		t.Errorf("nested list is not encoded back to %s", testAccessListRlp)
+	}
+	for _, test := range testRlpDecodeIllegal {
+		_, err := rlp.Decode(utils.HexStrToBytes(test))
+		if err == nil {
+			t.Errorf("%s should be rejected", test)
+		}
+	}
+}
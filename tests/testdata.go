@@ -1,8 +1,15 @@
 package tests
 
 import (
+	"encoding/json"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/abi"
 	"github.com/tn606024/ethwallet/types"
+	"github.com/tn606024/ethwallet/utils"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
 )
 var (
 	TestNetwork = types.RopstenNet
@@ -209,4 +216,278 @@ var (
 		},
 	}
 
-)
\ No newline at end of file
+	testHDWallet = []struct{
+		mnemonic   string
+		passphrase string
+		index      uint32
+		address    string
+	}{
+		{
+			"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			"",
+			0,
+			"0x9858EfFD232B4033E47d90003D41EC34EcaEda94",
+		},
+		{
+			"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			"",
+			1,
+			"0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0",
+		},
+	}
+	TestPrivateKey = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+
+	TestPrivateKeyAddress = common.HexToAddress("0x2c7536E3605D9C16a7a3D7b1898e529396a65c23")
+
+	testRlpDecodeIllegal = []string{
+		"0x8105",
+		"0xb80100",
+		"0xb90000",
+		"0xc5820102",
+		"0x820102ff",
+		"",
+	}
+
+	testSignTx = []struct{
+		tx      types.Transaction
+		network *types.Network
+		raw     string
+	}{
+		{
+			types.Transaction{
+				Nonce:    160,
+				GasPrice: big.NewInt(2000000000),
+				GasLimit: 21000,
+				To:       &common.Address{
+					0xe5,0x66,0x4b,0x93,0xad,0x26,0x83,0x93,
+					0xd1,0xf6,0x95,0xc4,0x18,0x09,0x93,0xe6,
+					0x0c,0x59,0xfc,0x3e,
+				},
+				Value:    big.NewInt(1000000000000),
+			},
+			types.RopstenNet,
+			"0xf86981a0847735940082520894e5664b93ad268393d1f695c4180993e60c59fc3e85e8d4a51000802aa0be7b352d73f3fe4e705ebe8abfd4ab4e0921e7bfa1763898bb68fb132caf9d05a04480b6fff676df4aa3eec969f30272ab6184b20652fddfd7165fbd0cd2639958",
+		},
+		{
+			types.Transaction{
+				Type:                 types.DynamicFeeTxType,
+				Nonce:                160,
+				MaxFeePerGas:         big.NewInt(30000000000),
+				MaxPriorityFeePerGas: big.NewInt(1500000000),
+				GasLimit:             21000,
+				To:       &common.Address{
+					0xe5,0x66,0x4b,0x93,0xad,0x26,0x83,0x93,
+					0xd1,0xf6,0x95,0xc4,0x18,0x09,0x93,0xe6,
+					0x0c,0x59,0xfc,0x3e,
+				},
+				Value:    big.NewInt(1000000000000),
+			},
+			types.RopstenNet,
+			"0x02f8710381a08459682f008506fc23ac0082520894e5664b93ad268393d1f695c4180993e60c59fc3e85e8d4a5100080c080a0ba308faf73d79de6f4d5605063b2688579c0b2c3c962ea9189fc654cb0bae89fa010fd65dac398b17be319208f5ec757bd237cb7b08c344cb050ebbd263be9f08b",
+		},
+		{
+			types.Transaction{
+				Type:       types.AccessListTxType,
+				Nonce:      7,
+				GasPrice:   big.NewInt(2000000000),
+				GasLimit:   60000,
+				To:         &testAccessListTo,
+				Value:      big.NewInt(0),
+				Data:       utils.HexStrToBytes(testAccessListData),
+				AccessList: testAccessList,
+			},
+			types.RopstenNet,
+			"0x01f901060307847735940082ea6094e5664b93ad268393d1f695c4180993e60c59fc3e80b844a9059cbb00000000000000000000000051bf0b41ba5b034f158cf1233f16ba5450f9355b0000000000000000000000000000000000000000000000000de0b6b3a7640000f85bf85994101848d5c5bbca18e6b4431eedf6b95e9adf82faf842a00000000000000000000000000000000000000000000000000000000000000001a0000000000000000000000000000000000000000000000000000000000000000280a069b9bb0ce1a5c959715a3d65787de7afcb2ae19a6356f618a23194f9c63fdaafa02855ee55322ca211295a0100ce8e508df30d3e5b99b4c3e7e861f371000a57f3",
+		},
+		{
+			types.Transaction{
+				Type:                 types.DynamicFeeTxType,
+				Nonce:                8,
+				MaxFeePerGas:         big.NewInt(30000000000),
+				MaxPriorityFeePerGas: big.NewInt(1500000000),
+				GasLimit:             60000,
+				To:                   &testAccessListTo,
+				Value:                big.NewInt(0),
+				Data:                 utils.HexStrToBytes(testAccessListData),
+				AccessList:           testAccessList,
+			},
+			types.RopstenNet,
+			"0x02f9010c03088459682f008506fc23ac0082ea6094e5664b93ad268393d1f695c4180993e60c59fc3e80b844a9059cbb00000000000000000000000051bf0b41ba5b034f158cf1233f16ba5450f9355b0000000000000000000000000000000000000000000000000de0b6b3a7640000f85bf85994101848d5c5bbca18e6b4431eedf6b95e9adf82faf842a00000000000000000000000000000000000000000000000000000000000000001a0000000000000000000000000000000000000000000000000000000000000000201a0b7b1fb51fb6e39b39c5958047817907423c4daff8f01cbf6634a76b70436f325a05b6f7e676477145021a192aa25bfe507ea05137d86be33b37b6f97b592073abe",
+		},
+		{
+			types.Transaction{
+				Nonce:    160,
+				GasPrice: big.NewInt(2000000000),
+				GasLimit: 21000,
+				To:       &testAccessListTo,
+				Value:    big.NewInt(1000000000000),
+			},
+			types.ArbitrumNet,
+			"0xf86c81a0847735940082520894e5664b93ad268393d1f695c4180993e60c59fc3e85e8d4a510008083014986a069001e63b90c7d72df72f91898d543f03e575200c608482320a4eb9e1380c6c1a040f4fd0ae16281c754ab1a2d7d636426a7dc805e9158a54f3f9c417fa3b07e45",
+		},
+		{
+			types.Transaction{
+				Type:                 types.DynamicFeeTxType,
+				Nonce:                2,
+				MaxFeePerGas:         big.NewInt(30000000000),
+				MaxPriorityFeePerGas: big.NewInt(1500000000),
+				GasLimit:             100000,
+				Value:                big.NewInt(0),
+				Data:                 utils.HexStrToBytes("0x6080604052348015600f57600080fd5b50603f80601d6000396000f3fe6080604052600080fdfea164736f6c6343000813000a"),
+			},
+			types.RopstenNet,
+			"0x02f88b03028459682f008506fc23ac00830186a08080b36080604052348015600f57600080fd5b50603f80601d6000396000f3fe6080604052600080fdfea164736f6c6343000813000ac080a0c294b86fbc7fc20a8411ca6945c5e0bd0fb27716b72b3814ff570a6d43fd0cd9a0043780d72e014720c3b5fa21102a22668c8c17d4883057163410af0e24e0b323",
+		},
+	}
+
+	testCreateAddress = struct{
+		nonce   uint64
+		address common.Address
+	}{
+		2,
+		common.HexToAddress("0xB737a04E639E9498cec7020d6D82c80D88853131"),
+	}
+
+	testAccessListTo = common.HexToAddress("0xe5664b93ad268393d1f695c4180993e60c59fc3e")
+
+	testAccessListData = "0xa9059cbb00000000000000000000000051bf0b41ba5b034f158cf1233f16ba5450f9355b0000000000000000000000000000000000000000000000000de0b6b3a7640000"
+
+	testAccessList = types.AccessList{
+		{
+			Address:     common.HexToAddress("0x101848d5c5bbca18e6b4431eedf6b95e9adf82fa"),
+			StorageKeys: []common.Hash{common.HexToHash("0x01"), common.HexToHash("0x02")},
+		},
+	}
+
+	testAccessListRlp = "0xf85bf85994101848d5c5bbca18e6b4431eedf6b95e9adf82faf842a00000000000000000000000000000000000000000000000000000000000000001a00000000000000000000000000000000000000000000000000000000000000002"
+	testNetworksConfig = `{
+		"network": "privnet",
+		"networks": {
+			"privnet": {
+				"node_url": "http://127.0.0.1:8545",
+				"explorer_url": "http://127.0.0.1:4000",
+				"chain_id": 99999999999
+			},
+			"sepolia": {
+				"node_url": "https://sepolia.infura.io/v3/key",
+				"etherscan_api_url": "https://api-sepolia.etherscan.io/api"
+			}
+		}
+	}`
+	testTypedData = []struct{
+		data            string
+		encodeType      string
+		domainSeparator string
+		hash            string
+		signature       string
+	}{
+		{
+			data:            `{"types":{"EIP712Domain":[{"name":"name","type":"string"},{"name":"version","type":"string"},{"name":"chainId","type":"uint256"},{"name":"verifyingContract","type":"address"}],"Person":[{"name":"name","type":"string"},{"name":"wallet","type":"address"}],"Mail":[{"name":"from","type":"Person"},{"name":"to","type":"Person"},{"name":"contents","type":"string"}]},"primaryType":"Mail","domain":{"name":"Ether Mail","version":"1","chainId":1,"verifyingContract":"0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"},"message":{"from":{"name":"Cow","wallet":"0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},"to":{"name":"Bob","wallet":"0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},"contents":"Hello, Bob!"}}`,
+			encodeType:      "Mail(Person from,Person to,string contents)Person(string name,address wallet)",
+			domainSeparator: "0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f",
+			hash:            "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2",
+			signature:       "0x19e4c5dcf77c10f4b30df3d4cb5822a99a69ec18e6f5d7b9a889a5a4815740732656ebdcc5a9c8eee63e684a5ed282d6b59ffe2914076b025a77df76559403111c",
+		},
+		{
+			data:            `{"types":{"EIP712Domain":[{"name":"name","type":"string"},{"name":"version","type":"string"},{"name":"chainId","type":"uint256"},{"name":"verifyingContract","type":"address"}],"Person":[{"name":"name","type":"string"},{"name":"wallets","type":"address[]"}],"Group":[{"name":"name","type":"string"},{"name":"members","type":"Person[]"},{"name":"id","type":"int64"},{"name":"tag","type":"bytes4"},{"name":"data","type":"bytes"},{"name":"active","type":"bool"},{"name":"amount","type":"uint256"}]},"primaryType":"Group","domain":{"name":"Group Mail","version":"2","chainId":"11155111","verifyingContract":"0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"},"message":{"name":"team","members":[{"name":"Cow","wallets":["0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826","0xDeaDbeefdEAdbeefdEadbEEFdeadbeEFdEaDbeeF"]},{"name":"Bob","wallets":[]}],"id":"-42","tag":"0x12345678","data":"0xdeadbeef","active":true,"amount":"115792089237316195423570985008687907853269984665640564039457584007913129639935"}}`,
+			encodeType:      "Group(string name,Person[] members,int64 id,bytes4 tag,bytes data,bool active,uint256 amount)Person(string name,address[] wallets)",
+			domainSeparator: "0xf5f89170aacd6637900c519962f7e0abd53859b312923adfb86d058596115f0f",
+			hash:            "0x5ca2453ba14f6dd11949dae68545a2f620690e9269369a585503afb9fed5ba1c",
+			signature:       "0xcac5fa1cd06249023be2525d6a738c582a41b4b76c8e88ab7ec25d1d582192132effea83eb181fa0b4dd5fa430163702219d33ca0c5448660e4cf1f74cc97e4d1c",
+		},
+	}
+	testAbiJson = `[
+		{"type":"function","name":"f","stateMutability":"nonpayable","inputs":[{"name":"items","type":"tuple[]","components":[{"name":"id","type":"uint256"},{"name":"tags","type":"string[]"}]},{"name":"x","type":"int8"},{"name":"b","type":"bytes3"},{"name":"a","type":"address"},{"name":"data","type":"bytes"},{"name":"flags","type":"bool[2]"},{"name":"s","type":"string"}],"outputs":[]},
+		{"type":"function","name":"balanceOf","constant":true,"inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+		{"type":"event","name":"Transfer","anonymous":false,"inputs":[{"indexed":true,"name":"from","type":"address"},{"indexed":true,"name":"to","type":"address"},{"indexed":false,"name":"value","type":"uint256"}]}
+	]`
+	testAbiEncode = struct{
+		method    string
+		signature string
+		args      string
+		data      string
+		formatted string
+	}{
+		method:    "f",
+		signature: "f((uint256,string[])[],int8,bytes3,address,bytes,bool[2],string)",
+		args:      `[[{"id": 1, "tags": ["a", "bc"]}, ["0x2", []]], -5, "0x010203", "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23", "0xdeadbeef", [true, false], "hello world"]`,
+		data:      "0x94c010d80000000000000000000000000000000000000000000000000000000000000100fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffb01020300000000000000000000000000000000000000000000000000000000000000000000000000000000002c7536e3605d9c16a7a3d7b1898e529396a65c2300000000000000000000000000000000000000000000000000000000000002e00000000000000000000000000000000000000000000000000000000000000001000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000003200000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000004000000000000000000000000000000000000000000000000000000000000001600000000000000000000000000000000000000000000000000000000000000001000000000000000000000000000000000000000000000000000000000000004000000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000040000000000000000000000000000000000000000000000000000000000000008000000000000000000000000000000000000000000000000000000000000000016100000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000262630000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000004000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000004deadbeef00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000b68656c6c6f20776f726c64000000000000000000000000000000000000000000",
+		formatted: `[[{"id":"1","tags":["a","bc"]},{"id":"2","tags":[]}],"-5","0x010203","0x2c7536E3605D9C16a7a3D7b1898e529396a65c23","0xdeadbeef",[true,false],"hello world"]`,
+	}
+	testAbiTransferLog = struct{
+		topics []common.Hash
+		data   string
+		from   common.Address
+		to     common.Address
+		value  string
+	}{
+		topics: []common.Hash{
+			common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"),
+			common.HexToHash("0x0000000000000000000000002c7536e3605d9c16a7a3d7b1898e529396a65c23"),
+			common.HexToHash("0x00000000000000000000000051bf0b41ba5b034f158cf1233f16ba5450f9355b"),
+		},
+		data:  "0x0000000000000000000000000000000000000000000000000de0b6b3a7640000",
+		from:  common.HexToAddress("0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"),
+		to:    common.HexToAddress("0x51bf0b41Ba5B034f158CF1233f16bA5450F9355B"),
+		value: "1000000000000000000",
+	}
+)
+
+type rpcRequest struct {
+	ID     int               `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+// newRPCNode is a fake json-rpc node, answer is called for a single request and every request of a batch
+func newRPCNode(t *testing.T, answer func(req rpcRequest) map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var raw json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&raw); err != nil || len(raw) == 0 {
+			t.Errorf("json-rpc request can't be decoded: %v", err)
+			return
+		}
+		if raw[0] == '[' {
+			var reqs []rpcRequest
+			json.Unmarshal(raw, &reqs)
+			var responses []map[string]interface{}
+			for _, req := range reqs {
+				responses = append(responses, answer(req))
+			}
+			json.NewEncoder(w).Encode(responses)
+			return
+		}
+		var req rpcRequest
+		json.Unmarshal(raw, &req)
+		json.NewEncoder(w).Encode(answer(req))
+	}))
+}
+
+// testLatestBlock is the latest block of fake nodes which scan logs, logs from 0 are got in 3 windows
+const testLatestBlock = 12000
+
+// logsInRange returns logs of the block range of eth_getLogs query, a range bigger than a window of the client is an error
+func logsInRange(t *testing.T, query json.RawMessage, logs []map[string]interface{}) []interface{} {
+	var r struct {
+		FromBlock string `json:"fromBlock"`
+		ToBlock   string `json:"toBlock"`
+	}
+	json.Unmarshal(query, &r)
+	from, to := utils.HexStrToUInt64(r.FromBlock), utils.HexStrToUInt64(r.ToBlock)
+	if to < from || to-from >= 5000 || to > testLatestBlock {
+		t.Errorf("eth_getLogs range %s-%s isn't a window", r.FromBlock, r.ToBlock)
+	}
+	res := []interface{}{}
+	for _, log := range logs {
+		if block := utils.HexStrToUInt64(log["blockNumber"].(string)); block >= from && block <= to {
+			res = append(res, log)
+		}
+	}
+	return res
+}
+
+// abiString is s abi encoded as the hex result of an eth_call
+func abiString(s string) string {
+	b, _ := abi.Arguments{{Type: "string"}}.Pack(s)
+	return utils.BytesToHexStr(b)
+}
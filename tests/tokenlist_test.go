@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/types"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testTokenList(name string, tokens ...string) string {
+	return fmt.Sprintf(`{"name": "%s", "timestamp": "2021-01-01T00:00:00Z", "version": {"major": 1, "minor": 0, "patch": 0}, "tokens": [%s]}`, name, strings.Join(tokens, ","))
+}
+
+func testTokenListToken(chainId int, address string, symbol string) string {
+	return fmt.Sprintf(`{"chainId": %d, "address": "%s", "name": "%s token", "symbol": "%s", "decimals": 6, "logoURI": "https://example.com/%s.png"}`, chainId, address, symbol, symbol, symbol)
+}
+
+func TestParseTokenList(t *testing.T) {
+	list, err := types.ParseTokenList([]byte(testTokenList("test", testTokenListToken(3, TestContractAddress.Hex(), "USDC"), testTokenListToken(1, TestAddress.Hex(), "USDC"))))
+	if err != nil {
+		t.Fatalf("ParseTokenList error: %s", err)
+	}
+	tokens := list.Erc20Tokens(TestNetwork.ChainId)
+	if len(tokens) != 1 || *tokens[0].Address != TestContractAddress || tokens[0].Decimals != 6 || tokens[0].Symbol != "USDC" {
+		t.Errorf("unexpected tokens of chain 3: %+v", tokens)
+	}
+	for _, invalid := range []string{
+		`{"name": "test"}`,
+		testTokenList("test", `{"address": "`+TestContractAddress.Hex()+`", "symbol": "USDC", "decimals": 6}`),
+		testTokenList("test", testTokenListToken(3, "0x1234", "USDC")),
+		testTokenList("test", testTokenListToken(3, TestContractAddress.Hex(), "")),
+	} {
+		if _, err := types.ParseTokenList([]byte(invalid)); err == nil {
+			t.Errorf("token list should be invalid: %s", invalid)
+		}
+	}
+}
+
+func TestConfig_LoadErc20List(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tokenlists")
+	if err != nil {
+		t.Fatalf("TempDir error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	usdc := "0x" + strings.Repeat("11", 20)
+	fakeUsdc := "0x" + strings.Repeat("22", 20)
+	file := filepath.Join(dir, "local.json")
+	err = ioutil.WriteFile(file, []byte(testTokenList("local", testTokenListToken(3, usdc, "USDC"), testTokenListToken(1, usdc, "USDT"))), 0644)
+	if err != nil {
+		t.Fatalf("WriteFile error: %s", err)
+	}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, testTokenList("remote", testTokenListToken(3, fakeUsdc, "USDC"), testTokenListToken(3, usdc, "USDC")))
+	}))
+	mainnetDai := common.HexToAddress("0x6b175474e89094c44da98b954eedeac495271d0f")
+	config := types.Config{
+		Network: TestNetwork,
+		Erc20List: []*types.Erc20Token{
+			{Symbol: "TST", Decimals: 2, Address: &TestContractAddress},
+			{ChainId: 1, Symbol: "DAI", Decimals: 18, Address: &mainnetDai},
+		},
+		TokenLists:        []string{file, server.URL},
+		TokenListCacheDir: filepath.Join(dir, "cache"),
+	}
+	if err := config.LoadErc20List(); err != nil {
+		t.Fatalf("LoadErc20List error: %s", err)
+	}
+	var symbols []string
+	for _, token := range config.Erc20List {
+		symbols = append(symbols, token.Symbol+":"+strings.ToLower(token.Address.Hex()))
+	}
+	expected := []string{"TST:" + strings.ToLower(TestContractAddress.Hex()), "USDC:" + usdc, "USDC:" + fakeUsdc}
+	if strings.Join(symbols, ",") != strings.Join(expected, ",") {
+		t.Errorf("erc20 list is %v, expected %v", symbols, expected)
+	}
+	if _, err := types.FindErc20Token(config.Erc20List, "USDC"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("USDC should be ambiguous, got error %v", err)
+	}
+	if token, err := types.FindErc20Token(config.Erc20List, "TST"); err != nil || *token.Address != TestContractAddress {
+		t.Errorf("FindErc20Token of TST is %+v, %v", token, err)
+	}
+
+	// the url is cached, and the cache is used when the url can't be fetched
+	server.Close()
+	for _, expiry := range []bool{false, true} {
+		if expiry {
+			files, _ := filepath.Glob(filepath.Join(dir, "cache", "*.json"))
+			for _, f := range files {
+				os.Chtimes(f, time.Now().Add(-48*time.Hour), time.Now().Add(-48*time.Hour))
+			}
+		}
+		config.TokenLists = []string{server.URL}
+		config.Erc20List = nil
+		if err := config.LoadErc20List(); err != nil || len(config.Erc20List) != 2 {
+			t.Errorf("LoadErc20List from cache got %d tokens, %v", len(config.Erc20List), err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("token list is fetched %d times, expected 1", requests)
+	}
+
+	// a url which can't be fetched and has no cache is skipped, other lists are still loaded
+	config.TokenLists = []string{server.URL + "/other", file}
+	config.Erc20List = nil
+	err = config.LoadErc20List()
+	if err == nil || !strings.Contains(err.Error(), "/other") || len(config.Erc20List) != 1 {
+		t.Errorf("LoadErc20List with a broken url got %d tokens, %v", len(config.Erc20List), err)
+	}
+}
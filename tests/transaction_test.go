@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"github.com/tn606024/ethwallet/crypto"
+	"github.com/tn606024/ethwallet/types"
+	"github.com/tn606024/ethwallet/utils"
+	"github.com/tn606024/ethwallet/wallet"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func newTestWallet(t *testing.T, network *types.Network) *wallet.Wallet {
+	privateKeyECDSA, err := crypto.ToECDSA(utils.HexStrToBytes(TestPrivateKey))
+	if err != nil {
+		t.Fatalf("ToECDSA error: %s", err)
+	}
+	return &wallet.Wallet{
+		Key:     wallet.NewKeyFromECDSA(privateKeyECDSA),
+		Network: network,
+	}
+}
+
+func TestWallet_SignTxToRawTx(t *testing.T) {
+	for _, test := range testSignTx {
+		w := newTestWallet(t, test.network)
+		tx := test.tx
+		raw, err := w.SignTxToRawTx(&tx)
+		if err != nil {
+			t.Fatalf("SignTxToRawTx error: %s", err)
+		}
+		if raw != test.raw {
+			t.Errorf("the ans is %s, but we got %s", test.raw, raw)
+		}
+	}
+}
+
+func TestDecodeRawTx(t *testing.T) {
+	for _, test := range testSignTx {
+		tx, err := types.DecodeRawTx(test.raw)
+		if err != nil {
+			t.Fatalf("DecodeRawTx error: %s", err)
+		}
+		if *tx.From != TestPrivateKeyAddress {
+			t.Errorf("the ans is %s, but we got %s", TestPrivateKeyAddress.String(), tx.From.String())
+		}
+		if tx.ChainId.Cmp(test.network.ChainId) != 0 {
+			t.Errorf("the ans is %s, but we got %s", test.network.ChainId.String(), tx.ChainId.String())
+		}
+		if tx.ToRawTx() != test.raw {
+			t.Errorf("the ans is %s, but we got %s", test.raw, tx.ToRawTx())
+		}
+	}
+}
+
+func TestCreateAddress(t *testing.T) {
+	res := crypto.CreateAddress(TestPrivateKeyAddress, testCreateAddress.nonce)
+	if res != testCreateAddress.address {
+		t.Errorf("the ans is %s, but we got %s", testCreateAddress.address.String(), res.String())
+	}
+}
+
+func TestEthereumWallet_TransferEther_TipWithoutBaseFee(t *testing.T) {
+	// the node of newPayoutServer has no base fee, so only a legacy tx can be sent
+	sent := make(chan string, 1)
+	ts := newPayoutServer(t, nil, sent)
+	defer ts.Close()
+	ew, err := wallet.ImportEthereumWallet(TestWalletAuth.auth, TestWalletAuth.path, types.Config{ServerUrl: ts.URL, Network: TestNetwork})
+	if err != nil {
+		t.Fatalf("ImportEthereumWallet error: %s", err)
+	}
+	_, err = ew.TransferEther(&TestAddress, big.NewInt(1), []byte{}, nil, nil, big.NewInt(2), 21000)
+	if err == nil || !strings.Contains(err.Error(), "no base fee") {
+		t.Errorf("tip without base fee got error %v", err)
+	}
+	if len(sent) != 0 {
+		t.Errorf("a transaction is sent")
+	}
+}
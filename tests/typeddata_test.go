@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"github.com/tn606024/ethwallet/types"
+	"github.com/tn606024/ethwallet/utils"
+	"github.com/tn606024/ethwallet/wallet"
+	"testing"
+)
+
+func TestTypedData_SignHash(t *testing.T) {
+	for _, test := range testTypedData {
+		td, err := types.ParseTypedData([]byte(test.data))
+		if err != nil {
+			t.Fatalf("ParseTypedData error: %s", err)
+		}
+		encodeType, err := td.EncodeType(td.PrimaryType)
+		if err != nil {
+			t.Fatalf("EncodeType error: %s", err)
+		}
+		if encodeType != test.encodeType {
+			t.Errorf("the ans is %s, but we got %s", test.encodeType, encodeType)
+		}
+		domainSeparator, err := td.DomainSeparator()
+		if err != nil {
+			t.Fatalf("DomainSeparator error: %s", err)
+		}
+		if utils.BytesToHexStr(domainSeparator) != test.domainSeparator {
+			t.Errorf("the ans is %s, but we got %s", test.domainSeparator, utils.BytesToHexStr(domainSeparator))
+		}
+		hash, err := td.SignHash()
+		if err != nil {
+			t.Fatalf("SignHash error: %s", err)
+		}
+		if utils.BytesToHexStr(hash) != test.hash {
+			t.Errorf("the ans is %s, but we got %s", test.hash, utils.BytesToHexStr(hash))
+		}
+	}
+}
+
+func TestWallet_SignTypedData(t *testing.T) {
+	w := newTestWallet(t, types.EthereumNet)
+	for _, test := range testTypedData {
+		td, err := types.ParseTypedData([]byte(test.data))
+		if err != nil {
+			t.Fatalf("ParseTypedData error: %s", err)
+		}
+		sig, err := w.SignTypedData(td)
+		if err != nil {
+			t.Fatalf("SignTypedData error: %s", err)
+		}
+		if sig != test.signature {
+			t.Errorf("the ans is %s, but we got %s", test.signature, sig)
+		}
+		ok, err := wallet.VerifyTypedData(TestPrivateKeyAddress, utils.HexStrToBytes(sig), td)
+		if err != nil || !ok {
+			t.Errorf("VerifyTypedData failed: %v", err)
+		}
+	}
+}
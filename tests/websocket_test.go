@@ -0,0 +1,204 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/websocket"
+	"github.com/tn606024/ethwallet/ethclient"
+	"github.com/tn606024/ethwallet/types"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newWsNode answers eth_subscribe and sends a notification for every subscription, the first
+// connection is dropped after its notification to test reconnecting.
+func newWsNode(t *testing.T, filters chan<- json.RawMessage) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	connections := 0
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade error: %s", err)
+			return
+		}
+		defer conn.Close()
+		connections++
+		drop := connections == 1
+		for {
+			var req struct {
+				ID     int               `json:"id"`
+				Method string            `json:"method"`
+				Params []json.RawMessage `json:"params"`
+			}
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			var kind string
+			json.Unmarshal(req.Params[0], &kind)
+			subId := fmt.Sprintf("0x%x%d", connections, req.ID)
+			if req.Method == "eth_unsubscribe" {
+				conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": true})
+				continue
+			}
+			conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": subId})
+			var result interface{}
+			switch kind {
+			case "newHeads":
+				result = map[string]interface{}{"number": fmt.Sprintf("0x%x", connections), "hash": "0x01"}
+			case "logs":
+				filters <- req.Params[1]
+				result = map[string]interface{}{"address": "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23", "blockNumber": "0x10", "removed": true}
+			case "newPendingTransactions":
+				result = "0xabcd"
+			}
+			conn.WriteJSON(map[string]interface{}{
+				"jsonrpc": "2.0",
+				"method":  "eth_subscription",
+				"params":  map[string]interface{}{"subscription": subId, "result": result},
+			})
+			if drop {
+				return
+			}
+		}
+	}))
+}
+
+func TestWsClient_SubscribeNewHeads(t *testing.T) {
+	node := newWsNode(t, nil)
+	defer node.Close()
+	client, err := ethclient.DialWebsocket("ws" + strings.TrimPrefix(node.URL, "http"))
+	if err != nil {
+		t.Fatalf("DialWebsocket error: %s", err)
+	}
+	defer client.Close()
+	heads, sub, err := client.SubscribeNewHeads()
+	if err != nil {
+		t.Fatalf("SubscribeNewHeads error: %s", err)
+	}
+	// the node drops the first connection, the second head comes after resubscribing
+	for i := 1; i <= 2; i++ {
+		select {
+		case head := <-heads:
+			if int(head.Number) != i {
+				t.Errorf("head %d has number %d", i, head.Number)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("head %d timeout", i)
+		}
+	}
+	select {
+	case err := <-sub.Err():
+		if !strings.Contains(err.Error(), "reconnected") {
+			t.Errorf("unexpected subscription error: %s", err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("reconnect is not notified")
+	}
+	if err := sub.Unsubscribe(); err != nil {
+		t.Errorf("Unsubscribe error: %s", err)
+	}
+	if _, ok := <-heads; ok {
+		t.Errorf("heads is not closed after unsubscribe")
+	}
+}
+
+func TestWsClient_SubscribeLogs(t *testing.T) {
+	filters := make(chan json.RawMessage, 2)
+	node := newWsNode(t, filters)
+	defer node.Close()
+	client, err := ethclient.DialWebsocket("ws" + strings.TrimPrefix(node.URL, "http"))
+	if err != nil {
+		t.Fatalf("DialWebsocket error: %s", err)
+	}
+	defer client.Close()
+	transfer := common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+	filter := types.LogFilter{
+		Address: []common.Address{TestPrivateKeyAddress},
+		Topics:  [][]common.Hash{{transfer}, nil},
+	}
+	logs, _, err := client.SubscribeLogs(filter)
+	if err != nil {
+		t.Fatalf("SubscribeLogs error: %s", err)
+	}
+	expected := `{"address":["0x2c7536e3605d9c16a7a3d7b1898e529396a65c23"],"topics":[["` + transfer.Hex() + `"],null]}`
+	if got := strings.ToLower(string(<-filters)); got != expected {
+		t.Errorf("filter is %s, expected %s", got, expected)
+	}
+	select {
+	case log := <-logs:
+		if int(log.BlockNumber) != 16 || !log.Removed {
+			t.Errorf("unexpected log: %+v", log)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("log timeout")
+	}
+}
+
+func TestWsClient_SlowSubscriber(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade error: %s", err)
+			return
+		}
+		defer conn.Close()
+		for {
+			var req struct {
+				ID     int    `json:"id"`
+				Method string `json:"method"`
+			}
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			if req.Method != "eth_subscribe" {
+				conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": "0x10"})
+				continue
+			}
+			conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": "0x1"})
+			// more notifications than the subscription buffers, nobody reads them
+			for i := 0; i < 300; i++ {
+				conn.WriteJSON(map[string]interface{}{
+					"jsonrpc": "2.0",
+					"method":  "eth_subscription",
+					"params":  map[string]interface{}{"subscription": "0x1", "result": map[string]interface{}{"number": fmt.Sprintf("0x%x", i)}},
+				})
+			}
+		}
+	}))
+	defer node.Close()
+	client, err := ethclient.DialWebsocket("ws" + strings.TrimPrefix(node.URL, "http"))
+	if err != nil {
+		t.Fatalf("DialWebsocket error: %s", err)
+	}
+	defer client.Close()
+	_, sub, err := client.SubscribeNewHeads()
+	if err != nil {
+		t.Fatalf("SubscribeNewHeads error: %s", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		var number string
+		done <- client.Call("eth_blockNumber", nil, &number)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Call error: %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Call is blocked by a slow subscriber")
+	}
+	select {
+	case err := <-sub.Err():
+		if !strings.Contains(err.Error(), "dropped") {
+			t.Errorf("unexpected notice: %s", err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("no notice of dropped notifications")
+	}
+}
@@ -0,0 +1,156 @@
+package types
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// EtherUnits are the unit suffixes ParseEtherAmount accepts and their decimals
+var EtherUnits = map[string]int{
+	"wei":   0,
+	"gwei":  9,
+	"ether": 18,
+	"eth":   18,
+}
+
+// Amount is a fixed-point number, Value is the integer in the smallest unit and Decimals is how many
+// digits are after the point, so wei and any token's amount are exact.
+type Amount struct {
+	Value    *big.Int
+	Decimals int
+}
+
+func NewAmount(value *big.Int, decimals int) *Amount {
+	if value == nil {
+		value = big.NewInt(0)
+	}
+	return &Amount{Value: new(big.Int).Set(value), Decimals: decimals}
+}
+
+// ParseAmount parses a decimal like 1.25 into an amount of decimals, more digits after the point are an error
+func ParseAmount(s string, decimals int) (*Amount, error) {
+	s = strings.TrimSpace(s)
+	integer, frac := s, ""
+	if i := strings.Index(s, "."); i >= 0 {
+		integer, frac = s[:i], s[i+1:]
+	}
+	if integer == "" && frac == "" || !isDigits(integer) || !isDigits(frac) {
+		return nil, fmt.Errorf("amount %s is not a number", s)
+	}
+	if len(frac) > decimals {
+		return nil, fmt.Errorf("amount %s has more than %d decimals", s, decimals)
+	}
+	value, _ := new(big.Int).SetString("0"+integer+frac+strings.Repeat("0", decimals-len(frac)), 10)
+	return &Amount{Value: value, Decimals: decimals}, nil
+}
+
+// ParseEtherAmount parses an ether amount with an optional unit like 1.25ether, 30gwei or 100wei,
+// an amount without unit is in unit. The amount is in wei.
+func ParseEtherAmount(s string, unit string) (*Amount, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for u := range EtherUnits {
+		if strings.HasSuffix(s, u) && !strings.HasSuffix(s, "g"+u) {
+			s, unit = strings.TrimSpace(strings.TrimSuffix(s, u)), u
+			break
+		}
+	}
+	decimals, ok := EtherUnits[unit]
+	if !ok {
+		return nil, fmt.Errorf("unit %s is unknown", unit)
+	}
+	// s in unit multiplied by 10^decimals of unit is wei
+	a, err := ParseAmount(s, decimals)
+	if err != nil {
+		return nil, fmt.Errorf("%s in %s", err, unit)
+	}
+	return &Amount{Value: a.Value, Decimals: 18}, nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Rescale returns the same amount with decimals, it fails if digits would be lost
+func (a *Amount) Rescale(decimals int) (*Amount, error) {
+	value := new(big.Int).Set(a.Value)
+	if decimals >= a.Decimals {
+		value.Mul(value, pow10(decimals-a.Decimals))
+		return &Amount{Value: value, Decimals: decimals}, nil
+	}
+	value, rem := value.QuoRem(value, pow10(a.Decimals-decimals), new(big.Int))
+	if rem.Sign() != 0 {
+		return nil, fmt.Errorf("amount %s has more than %d decimals", a.String(), decimals)
+	}
+	return &Amount{Value: value, Decimals: decimals}, nil
+}
+
+func (a *Amount) Cmp(b *Amount) int {
+	decimals := a.Decimals
+	if b.Decimals > decimals {
+		decimals = b.Decimals
+	}
+	x, _ := a.Rescale(decimals)
+	y, _ := b.Rescale(decimals)
+	return x.Value.Cmp(y.Value)
+}
+
+// String shows the exact amount without trailing zeros after the point
+func (a *Amount) String() string {
+	if a == nil || a.Value == nil {
+		return "0"
+	}
+	abs := new(big.Int).Abs(a.Value)
+	integer, frac := new(big.Int).QuoRem(abs, pow10(a.Decimals), new(big.Int))
+	res := integer.String()
+	if frac.Sign() != 0 {
+		res += "." + strings.TrimRight(fmt.Sprintf("%0*s", a.Decimals, frac.String()), "0")
+	}
+	if a.Value.Sign() < 0 {
+		res = "-" + res
+	}
+	return res
+}
+
+func (a *Amount) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText takes as many decimals as digits after the point, use Rescale to get the token's smallest unit
+func (a *Amount) UnmarshalText(text []byte) error {
+	s := string(text)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	decimals := 0
+	if i := strings.Index(s, "."); i >= 0 {
+		decimals = len(s) - i - 1
+	}
+	res, err := ParseAmount(s, decimals)
+	if err != nil {
+		return err
+	}
+	if negative {
+		res.Value.Neg(res.Value)
+	}
+	*a = *res
+	return nil
+}
+
+// EtherString shows wei in ether
+func EtherString(wei *big.Int) string {
+	return NewAmount(wei, 18).String()
+}
+
+// GweiString shows wei in gwei
+func GweiString(wei *big.Int) string {
+	return NewAmount(wei, 9).String()
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,40 @@
+package types
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// MaxBalancesAddresses limits the addresses of one POST /balances
+const MaxBalancesAddresses = 10000
+
+// BalancesRequest is the body of POST /balances, assets are ETH, symbols or addresses of tokens and ETH is the default.
+// Block is latest, earliest, pending or a block number in hex or decimal, latest is the default.
+type BalancesRequest struct {
+	Addresses []string `json:"addresses"`
+	Assets    []string `json:"assets,omitempty"`
+	Block     string   `json:"block,omitempty"`
+}
+
+// Balances are amounts of every asset keyed by eip55 checksummed address and then by asset as it's requested
+type Balances map[string]map[string]*Amount
+
+func (r *BalancesRequest) BlockParam() (BlockParam, error) {
+	block := strings.TrimSpace(r.Block)
+	switch {
+	case block == "":
+		return Latest, nil
+	case isPredefinedBlockParam(block):
+		return BlockParam(block), nil
+	case strings.HasPrefix(block, "0x"):
+		if n, ok := new(big.Int).SetString(block[2:], 16); ok && n.IsUint64() {
+			return BlockParam(fmt.Sprintf("0x%x", n)), nil
+		}
+	default:
+		if n, ok := new(big.Int).SetString(block, 10); ok && n.IsUint64() {
+			return BlockParam(fmt.Sprintf("0x%x", n)), nil
+		}
+	}
+	return "", fmt.Errorf("block %s is not latest, earliest, pending or a block number", r.Block)
+}
@@ -0,0 +1,94 @@
+package types
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"strings"
+)
+
+// EnsRegistryAddress is the ens registry, it has the same address on every network ens is deployed
+var EnsRegistryAddress = common.HexToAddress("0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e")
+
+var ensChainIds = map[int64]bool{1: true, 3: true, 4: true, 5: true, 11155111: true, 17000: true}
+
+var EnsFunctionInterface = struct {
+	Resolver Method
+	Addr     Method
+	Name     Method
+}{
+	Resolver: Method{
+		FunctionSignature: "resolver(bytes32)",
+		MethodId: []byte{
+			0x01, 0x78, 0xb8, 0xbf,
+		},
+	},
+	Addr: Method{
+		FunctionSignature: "addr(bytes32)",
+		MethodId: []byte{
+			0x3b, 0x3b, 0x57, 0xde,
+		},
+	},
+	Name: Method{
+		FunctionSignature: "name(bytes32)",
+		MethodId: []byte{
+			0x69, 0x1f, 0x34, 0x31,
+		},
+	},
+}
+
+// HasEns tells whether the ens registry is deployed on network
+func (n *Network) HasEns() bool {
+	return n != nil && n.ChainId != nil && ensChainIds[n.ChainId.Int64()]
+}
+
+// IsEnsName tells an ens name like vitalik.eth from an address
+func IsEnsName(s string) bool {
+	return strings.Contains(s, ".") && !common.IsHexAddress(s)
+}
+
+// NormalizeEnsName lowercases name, full uts46 normalization isn't supported so only ascii names are accepted
+func NormalizeEnsName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, label := range strings.Split(name, ".") {
+		if label == "" {
+			return "", fmt.Errorf("ens name %s has an empty label", name)
+		}
+	}
+	for _, r := range name {
+		if r > 0x7f {
+			return "", fmt.Errorf("ens name %s isn't ascii, which is not supported", name)
+		}
+	}
+	return name, nil
+}
+
+// Namehash is eip137's namehash of a normalized name
+func Namehash(name string) common.Hash {
+	var node common.Hash
+	if name == "" {
+		return node
+	}
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		node = crypto.Keccak256Hash(node.Bytes(), crypto.Keccak256([]byte(labels[i])))
+	}
+	return node
+}
+
+// EnsReverseNode is the node of <address>.addr.reverse whose resolver has the address's primary name
+func EnsReverseNode(address common.Address) common.Hash {
+	return Namehash(strings.ToLower(strings.TrimPrefix(address.Hex(), "0x")) + ".addr.reverse")
+}
+
+func GenerateEnsResolverData(node common.Hash) []byte {
+	return append(append([]byte{}, EnsFunctionInterface.Resolver.MethodId...), node.Bytes()...)
+}
+
+func GenerateEnsAddrData(node common.Hash) []byte {
+	return append(append([]byte{}, EnsFunctionInterface.Addr.MethodId...), node.Bytes()...)
+}
+
+func GenerateEnsNameData(node common.Hash) []byte {
+	return append(append([]byte{}, EnsFunctionInterface.Name.MethodId...), node.Bytes()...)
+}
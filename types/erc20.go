@@ -11,12 +11,12 @@ var Erc20FunctionInterface = struct {
 	Name         Method
 	Symbol       Method
 	Decimals     Method
-	totalSupply  Method
+	TotalSupply  Method
 	BalanceOf    Method
-	tranfer      Method
-	transferFrom Method
-	approve      Method
-	allowance    Method
+	Transfer     Method
+	TransferFrom Method
+	Approve      Method
+	Allowance    Method
 }{
 	Name: Method{
 		FunctionSignature: "name()",
@@ -36,7 +36,7 @@ var Erc20FunctionInterface = struct {
 			0x31, 0x3c, 0xe5, 0x67,
 		},
 	},
-	totalSupply: Method{
+	TotalSupply: Method{
 		FunctionSignature: "totalSupply()",
 		MethodId: []byte{
 			0x18, 0x16, 0x0d, 0xdd,
@@ -48,25 +48,25 @@ var Erc20FunctionInterface = struct {
 			0x70, 0xa0, 0x82, 0x31,
 		},
 	},
-	tranfer: Method{
+	Transfer: Method{
 		FunctionSignature: "transfer(address,uint256)",
 		MethodId: []byte{
 			0xa9, 0x05, 0x9c, 0xbb,
 		},
 	},
-	transferFrom: Method{
+	TransferFrom: Method{
 		FunctionSignature: "transferFrom(address,address,uint256)",
 		MethodId: []byte{
 			0x23, 0xb8, 0x72, 0xdd,
 		},
 	},
-	approve: Method{
+	Approve: Method{
 		FunctionSignature: "approve(address,uint256)",
 		MethodId: []byte{
 			0x09, 0x5e, 0xa7, 0xb3,
 		},
 	},
-	allowance: Method{
+	Allowance: Method{
 		FunctionSignature: "allowance(address,address)",
 		MethodId: []byte{
 			0xdd, 0x62, 0xed, 0x3e,
@@ -74,12 +74,23 @@ var Erc20FunctionInterface = struct {
 	},
 }
 
+// Erc20TransferTopic is the first topic of Transfer(address,address,uint256) logs
+var Erc20TransferTopic = common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+
+// Erc20ApprovalTopic is the first topic of Approval(address,address,uint256) logs
+var Erc20ApprovalTopic = common.HexToHash("0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925")
+
+// MaxAllowance is the allowance of an unlimited approval
+var MaxAllowance = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
 type Method struct {
 	FunctionSignature string
 	MethodId []byte
 }
 
+// Erc20Token of erc20_list without ChainId is used on every network
 type Erc20Token struct {
+	ChainId		int64	 `json:"chainId,omitempty"`
 	Decimals 	int      `json:"decimals"`
 	Name		string   `json:"name"`
 	Symbol		string `json:"symbol"`
@@ -94,15 +105,50 @@ func (t *Erc20Token) String() (string, error) {
 	return string(ts) + "\n", nil
 }
 
-func (t *Erc20Token) GenerateTransferData(value *big.Int, from *common.Address) []byte{
-	data := utils.EncodeABI(Erc20FunctionInterface.tranfer.MethodId, from.Bytes(), TokenToWei(value, t.Decimals).Bytes())
+// GenerateTransferData encodes transfer(to, value), value is in token's smallest unit
+func (t *Erc20Token) GenerateTransferData(value *big.Int, to *common.Address) []byte{
+	data := utils.EncodeABI(Erc20FunctionInterface.Transfer.MethodId, to.Bytes(), value.Bytes())
 	return data
 }
 
-func TokenToWei(value *big.Int, decimals int) *big.Int{
-	return value.Mul(value,big.NewInt(1).Exp(big.NewInt(10), big.NewInt(int64(decimals)),nil))
+// GenerateApproveData encodes approve(spender, value), value is in token's smallest unit
+func (t *Erc20Token) GenerateApproveData(spender *common.Address, value *big.Int) []byte{
+	return utils.EncodeABI(Erc20FunctionInterface.Approve.MethodId, spender.Bytes(), value.Bytes())
+}
+
+// GenerateTransferFromData encodes transferFrom(from, to, value), value is in token's smallest unit
+func (t *Erc20Token) GenerateTransferFromData(from *common.Address, to *common.Address, value *big.Int) []byte{
+	return utils.EncodeABI(Erc20FunctionInterface.TransferFrom.MethodId, from.Bytes(), to.Bytes(), value.Bytes())
+}
+
+func (t *Erc20Token) GenerateAllowanceData(owner *common.Address, spender *common.Address) []byte{
+	return utils.EncodeABI(Erc20FunctionInterface.Allowance.MethodId, owner.Bytes(), spender.Bytes())
 }
 
-func WeiToToken(value *big.Int, decimals int) *big.Int {
-	return value.Div(value,big.NewInt(1).Exp(big.NewInt(10), big.NewInt(int64(decimals)),nil))
-}
\ No newline at end of file
+// FormatAllowance shows allowance in token's unit, MaxAllowance is shown as unlimited
+func (t *Erc20Token) FormatAllowance(allowance *big.Int) string {
+	if allowance.Cmp(MaxAllowance) == 0 {
+		return "unlimited"
+	}
+	return NewAmount(allowance, t.Decimals).String()
+}
+
+// ParseAmount parses an amount in token's unit like 0.5
+func (t *Erc20Token) ParseAmount(s string) (*Amount, error) {
+	return ParseAmount(s, t.Decimals)
+}
+// Erc20Approval is a live allowance owner gave spender, found from Approval logs. Token only has
+// the address if the contract doesn't implement name, symbol or decimals.
+type Erc20Approval struct {
+	Token           *Erc20Token    `json:"token"`
+	Spender         common.Address `json:"spender"`
+	Allowance       *BigIntHex     `json:"allowance"`
+	BlockNumber     IntHex         `json:"blocknumber"`
+	TransactionHash string         `json:"txid"`
+}
+
+// Erc20Balance is the balance of a token which isn't looked up by symbol, symbols of discovered tokens may repeat
+type Erc20Balance struct {
+	Token   *Erc20Token `json:"token"`
+	Balance *Amount     `json:"balance"`
+}
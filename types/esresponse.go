@@ -30,6 +30,8 @@ type EsNormalTransaction struct {
 	CumulativeGasUsed int    `json:"cumulativeGasUsed,string"`
 	GasUsed           int    `json:"gasUsed,string"`
 	Confirmations     int    `json:"confirmations,string"`
+	FromName          string `json:"fromName,omitempty"`
+	ToName            string `json:"toName,omitempty"`
 }
 
 func (t *EsNormalTransaction) String() (string, error){
@@ -55,6 +57,8 @@ type EsInternalTansaction struct {
 	GasUsed         int    `json:"gasUsed,string"`
 	IsError         int    `json:"isError,string"`
 	ErrCode         string `json:"errCode"`
+	FromName        string `json:"fromName,omitempty"`
+	ToName          string `json:"toName,omitempty"`
 }
 
 func (t *EsInternalTansaction) String() (string, error){
@@ -85,6 +89,8 @@ type EsErc20TokenTransaction struct {
 	CumulativeGasUsed int    `json:"cumulativeGasUsed,string"`
 	Input             string `json:"input"`
 	Confirmations     int    `json:"confirmations,string"`
+	FromName          string `json:"fromName,omitempty"`
+	ToName            string `json:"toName,omitempty"`
 }
 
 func (t *EsErc20TokenTransaction) String() (string, error){
@@ -0,0 +1,80 @@
+package types
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/abi"
+	"github.com/tn606024/ethwallet/utils"
+)
+
+// Multicall3Address is multicall3, it has the same address on every chain it's deployed
+var Multicall3Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+var Multicall3FunctionInterface = struct {
+	Aggregate3    Method
+	GetEthBalance Method
+}{
+	Aggregate3: Method{
+		FunctionSignature: "aggregate3((address,bool,bytes)[])",
+		MethodId: []byte{
+			0x82, 0xad, 0x56, 0xcb,
+		},
+	},
+	GetEthBalance: Method{
+		FunctionSignature: "getEthBalance(address)",
+		MethodId: []byte{
+			0x4d, 0x23, 0x01, 0xcc,
+		},
+	},
+}
+
+var (
+	aggregate3Args = abi.Arguments{{Name: "calls", Type: "tuple[]", Components: []abi.Argument{
+		{Name: "target", Type: "address"}, {Name: "allowFailure", Type: "bool"}, {Name: "callData", Type: "bytes"},
+	}}}
+	aggregate3Results = abi.Arguments{{Name: "returnData", Type: "tuple[]", Components: []abi.Argument{
+		{Name: "success", Type: "bool"}, {Name: "returnData", Type: "bytes"},
+	}}}
+)
+
+// MulticallCall is a call of aggregate3, a failed call with AllowFailure false reverts every call
+type MulticallCall struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+type MulticallResult struct {
+	Success    bool
+	ReturnData []byte
+}
+
+func GenerateAggregate3Data(calls []MulticallCall) ([]byte, error) {
+	items := make([]interface{}, len(calls))
+	for i, call := range calls {
+		items[i] = []interface{}{call.Target, call.AllowFailure, call.CallData}
+	}
+	encoded, err := aggregate3Args.Pack(items)
+	if err != nil {
+		return nil, fmt.Errorf("encode aggregate3 occured error: %s", err)
+	}
+	return append(append([]byte{}, Multicall3FunctionInterface.Aggregate3.MethodId...), encoded...), nil
+}
+
+func DecodeAggregate3Result(data []byte) ([]MulticallResult, error) {
+	decoded, err := aggregate3Results.Unpack(data)
+	if err != nil {
+		return nil, fmt.Errorf("decode aggregate3 result error: %s", err)
+	}
+	items := decoded[0].([]interface{})
+	results := make([]MulticallResult, len(items))
+	for i, item := range items {
+		fields := item.([]interface{})
+		results[i] = MulticallResult{Success: fields[0].(bool), ReturnData: fields[1].([]byte)}
+	}
+	return results, nil
+}
+
+func GenerateGetEthBalanceData(address common.Address) []byte {
+	return utils.EncodeABI(Multicall3FunctionInterface.GetEthBalance.MethodId, address.Bytes())
+}
@@ -0,0 +1,202 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/abi"
+	"github.com/tn606024/ethwallet/utils"
+	"math/big"
+	"strings"
+)
+
+const (
+	Erc721  = "erc721"
+	Erc1155 = "erc1155"
+)
+
+var Erc721FunctionInterface = struct {
+	BalanceOf        Method
+	OwnerOf          Method
+	TokenURI         Method
+	SafeTransferFrom Method
+}{
+	BalanceOf: Method{
+		FunctionSignature: "balanceOf(address)",
+		MethodId: []byte{
+			0x70, 0xa0, 0x82, 0x31,
+		},
+	},
+	OwnerOf: Method{
+		FunctionSignature: "ownerOf(uint256)",
+		MethodId: []byte{
+			0x63, 0x52, 0x21, 0x1e,
+		},
+	},
+	TokenURI: Method{
+		FunctionSignature: "tokenURI(uint256)",
+		MethodId: []byte{
+			0xc8, 0x7b, 0x56, 0xdd,
+		},
+	},
+	SafeTransferFrom: Method{
+		FunctionSignature: "safeTransferFrom(address,address,uint256)",
+		MethodId: []byte{
+			0x42, 0x84, 0x2e, 0x0e,
+		},
+	},
+}
+
+var Erc1155FunctionInterface = struct {
+	BalanceOf             Method
+	Uri                   Method
+	SafeTransferFrom      Method
+	SafeBatchTransferFrom Method
+}{
+	BalanceOf: Method{
+		FunctionSignature: "balanceOf(address,uint256)",
+		MethodId: []byte{
+			0x00, 0xfd, 0xd5, 0x8e,
+		},
+	},
+	Uri: Method{
+		FunctionSignature: "uri(uint256)",
+		MethodId: []byte{
+			0x0e, 0x89, 0x34, 0x1c,
+		},
+	},
+	SafeTransferFrom: Method{
+		FunctionSignature: "safeTransferFrom(address,address,uint256,uint256,bytes)",
+		MethodId: []byte{
+			0xf2, 0x42, 0x43, 0x2a,
+		},
+	},
+	SafeBatchTransferFrom: Method{
+		FunctionSignature: "safeBatchTransferFrom(address,address,uint256[],uint256[],bytes)",
+		MethodId: []byte{
+			0x2e, 0xb2, 0xc2, 0xd6,
+		},
+	},
+}
+
+// SupportsInterface is erc165's supportsInterface(bytes4), it tells erc721 from erc1155
+var SupportsInterface = Method{
+	FunctionSignature: "supportsInterface(bytes4)",
+	MethodId: []byte{
+		0x01, 0xff, 0xc9, 0xa7,
+	},
+}
+
+// erc165 interface ids of erc721 and erc1155
+var (
+	Erc721InterfaceId  = []byte{0x80, 0xac, 0x58, 0xcd}
+	Erc1155InterfaceId = []byte{0xd9, 0xb6, 0x7a, 0x26}
+)
+
+// Transfer of erc721 has the same topic as erc20's Erc20TransferTopic, with the token id as the 4th topic
+var (
+	Erc1155TransferSingleTopic = common.HexToHash("0xc3d58168c5ae7397731d063d5bbf3d657854427343f4c083240f7aacaa2d0f62")
+	Erc1155TransferBatchTopic  = common.HexToHash("0x4a39dc06d4c0dbc64b70af90fd698a233a518aa5d07e595d983b8c0526c8f7fb")
+)
+
+var erc1155TransferBatchData = abi.Arguments{{Name: "ids", Type: "uint256[]"}, {Name: "values", Type: "uint256[]"}}
+
+// Nft is a token an address owns, Balance is 1 for erc721
+// Nft is a token of an owner, Owner is the owner of an erc721 token and only set when it's asked for one token
+type Nft struct {
+	Contract common.Address  `json:"contract"`
+	Standard string          `json:"standard"`
+	TokenId  *BigIntHex      `json:"tokenid"`
+	Balance  *BigIntHex      `json:"balance"`
+	Owner    *common.Address `json:"owner,omitempty"`
+	Uri      string          `json:"uri"`
+}
+
+func (n *Nft) String() (string, error) {
+	b, err := json.MarshalIndent(n, "", "	")
+	if err != nil {
+		return "", err
+	}
+	return string(b) + "\n", nil
+}
+
+// GenerateSupportsInterfaceData encodes supportsInterface(interfaceId)
+func GenerateSupportsInterfaceData(interfaceId []byte) []byte {
+	return append(append([]byte{}, SupportsInterface.MethodId...), common.RightPadBytes(interfaceId, 32)...)
+}
+
+func GenerateOwnerOfData(tokenId *big.Int) []byte {
+	return utils.EncodeABI(Erc721FunctionInterface.OwnerOf.MethodId, tokenId.Bytes())
+}
+
+// GenerateNftUriData encodes tokenURI(tokenId) of erc721 or uri(tokenId) of erc1155
+func GenerateNftUriData(standard string, tokenId *big.Int) []byte {
+	if standard == Erc1155 {
+		return utils.EncodeABI(Erc1155FunctionInterface.Uri.MethodId, tokenId.Bytes())
+	}
+	return utils.EncodeABI(Erc721FunctionInterface.TokenURI.MethodId, tokenId.Bytes())
+}
+
+// GenerateNftBalanceOfData encodes balanceOf(owner) of erc721, which counts owner's tokens, or balanceOf(owner, tokenId) of erc1155
+func GenerateNftBalanceOfData(standard string, owner *common.Address, tokenId *big.Int) []byte {
+	if standard == Erc1155 {
+		return utils.EncodeABI(Erc1155FunctionInterface.BalanceOf.MethodId, owner.Bytes(), tokenId.Bytes())
+	}
+	return utils.EncodeABI(Erc721FunctionInterface.BalanceOf.MethodId, owner.Bytes())
+}
+
+// GenerateErc721SafeTransferFromData encodes safeTransferFrom(from, to, tokenId)
+func GenerateErc721SafeTransferFromData(from *common.Address, to *common.Address, tokenId *big.Int) []byte {
+	return utils.EncodeABI(Erc721FunctionInterface.SafeTransferFrom.MethodId, from.Bytes(), to.Bytes(), tokenId.Bytes())
+}
+
+// GenerateErc1155SafeTransferFromData encodes safeTransferFrom(from, to, tokenId, amount, data)
+func GenerateErc1155SafeTransferFromData(from *common.Address, to *common.Address, tokenId *big.Int, amount *big.Int, data []byte) ([]byte, error) {
+	args := abi.Arguments{{Type: "address"}, {Type: "address"}, {Type: "uint256"}, {Type: "uint256"}, {Type: "bytes"}}
+	encoded, err := args.Pack(*from, *to, tokenId, amount, data)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte{}, Erc1155FunctionInterface.SafeTransferFrom.MethodId...), encoded...), nil
+}
+
+// GenerateErc1155SafeBatchTransferFromData encodes safeBatchTransferFrom(from, to, tokenIds, amounts, data)
+func GenerateErc1155SafeBatchTransferFromData(from *common.Address, to *common.Address, tokenIds []*big.Int, amounts []*big.Int, data []byte) ([]byte, error) {
+	if len(tokenIds) != len(amounts) {
+		return nil, fmt.Errorf("%d token ids and %d amounts don't match", len(tokenIds), len(amounts))
+	}
+	args := abi.Arguments{{Type: "address"}, {Type: "address"}, {Type: "uint256[]"}, {Type: "uint256[]"}, {Type: "bytes"}}
+	encoded, err := args.Pack(*from, *to, tokenIds, amounts, data)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte{}, Erc1155FunctionInterface.SafeBatchTransferFrom.MethodId...), encoded...), nil
+}
+
+// DecodeErc1155TransferData returns token ids and values of TransferSingle or TransferBatch log's data
+func DecodeErc1155TransferData(topic common.Hash, data []byte) (ids []*big.Int, values []*big.Int, err error) {
+	if topic == Erc1155TransferSingleTopic {
+		if len(data) != 64 {
+			return nil, nil, fmt.Errorf("TransferSingle data must be 64 bytes, got %d", len(data))
+		}
+		return []*big.Int{new(big.Int).SetBytes(data[:32])}, []*big.Int{new(big.Int).SetBytes(data[32:])}, nil
+	}
+	decoded, err := erc1155TransferBatchData.Unpack(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decode TransferBatch data error: %s", err)
+	}
+	rawIds, rawValues := decoded[0].([]interface{}), decoded[1].([]interface{})
+	if len(rawIds) != len(rawValues) {
+		return nil, nil, fmt.Errorf("TransferBatch has %d ids and %d values", len(rawIds), len(rawValues))
+	}
+	for i := range rawIds {
+		ids = append(ids, rawIds[i].(*big.Int))
+		values = append(values, rawValues[i].(*big.Int))
+	}
+	return ids, values, nil
+}
+
+// Erc1155Uri replaces {id} in uri of erc1155 with the token id in 64 hex digits as the standard requires
+func Erc1155Uri(uri string, tokenId *big.Int) string {
+	return strings.Replace(uri, "{id}", fmt.Sprintf("%064x", tokenId), -1)
+}
@@ -0,0 +1,182 @@
+package types
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/utils"
+	"math/big"
+	"strings"
+)
+
+const OfflineTxVersion = 1
+
+// OfflineTx is the portable file of offline signing, nodewallet prepare fills Transaction on the online machine,
+// wallet sign fills Raw and Hash on the offline machine and node broadcast sends Raw.
+type OfflineTx struct {
+	Version     int          `json:"version"`
+	Network     string       `json:"network"`
+	ChainId     *big.Int     `json:"chainid"`
+	Transaction *Transaction `json:"transaction"`
+	Token       *Erc20Token  `json:"token,omitempty"`
+	Raw         string       `json:"raw,omitempty"`
+	Hash        string       `json:"hash,omitempty"`
+}
+
+func NewOfflineTx(network *Network, tx *Transaction, token *Erc20Token) *OfflineTx {
+	tx.ChainId = new(big.Int).Set(network.ChainId)
+	return &OfflineTx{
+		Version:     OfflineTxVersion,
+		Network:     network.Name,
+		ChainId:     new(big.Int).Set(network.ChainId),
+		Transaction: tx,
+		Token:       token,
+	}
+}
+
+// ParseOfflineTx accepts the json file or its base64 payload
+func ParseOfflineTx(b []byte) (*OfflineTx, error) {
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] != '{' {
+		decoded, err := base64.StdEncoding.DecodeString(string(b))
+		if err != nil {
+			return nil, fmt.Errorf("offline tx is neither json nor base64: %s", err)
+		}
+		b = decoded
+	}
+	var o OfflineTx
+	if err := json.Unmarshal(b, &o); err != nil {
+		return nil, fmt.Errorf("offline tx unmarshal error: %s", err)
+	}
+	if o.Version != OfflineTxVersion {
+		return nil, fmt.Errorf("offline tx version %d is not supported", o.Version)
+	}
+	if o.Transaction == nil || o.Transaction.From == nil || o.ChainId == nil {
+		return nil, fmt.Errorf("offline tx needs chainid, transaction and its from")
+	}
+	if o.Transaction.ChainId == nil || o.Transaction.ChainId.Cmp(o.ChainId) != 0 {
+		return nil, fmt.Errorf("transaction's chainid doesn't match chainid %s", o.ChainId.String())
+	}
+	return &o, nil
+}
+
+func (o *OfflineTx) JSON() ([]byte, error) {
+	return json.MarshalIndent(o, "", "	")
+}
+
+// Base64 is the payload of the json file in one line, it's easy to copy or turn into a qr code
+func (o *OfflineTx) Base64() (string, error) {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// Verify checks Raw is the signed Transaction, so Raw can't be swapped after prepared
+func (o *OfflineTx) Verify() (*Transaction, error) {
+	if o.Raw == "" {
+		return nil, fmt.Errorf("offline tx is not signed")
+	}
+	signed, err := DecodeRawTx(o.Raw)
+	if err != nil {
+		return nil, err
+	}
+	tx := o.Transaction
+	switch {
+	case *signed.From != *tx.From:
+		return nil, fmt.Errorf("raw is signed by %s, not %s", signed.From.String(), tx.From.String())
+	case signed.ChainId == nil || signed.ChainId.Cmp(o.ChainId) != 0:
+		return nil, fmt.Errorf("raw is not signed for chain id %s", o.ChainId.String())
+	case signed.Type != tx.Type || signed.Nonce != tx.Nonce || signed.GasLimit != tx.GasLimit:
+		return nil, fmt.Errorf("raw's type, nonce or gas limit is different from transaction")
+	case !sameAddress(signed.To, tx.To) || !sameBigInt(signed.Value, tx.Value) || !bytes.Equal(signed.Data, tx.Data):
+		return nil, fmt.Errorf("raw's to, value or data is different from transaction")
+	case !sameBigInt(signed.FeeCap(), tx.FeeCap()) || (tx.Type == DynamicFeeTxType && !sameBigInt(signed.MaxPriorityFeePerGas, tx.MaxPriorityFeePerGas)):
+		return nil, fmt.Errorf("raw's fees are different from transaction")
+	case !sameAccessList(signed.AccessList, tx.AccessList):
+		return nil, fmt.Errorf("raw's access list is different from transaction")
+	}
+	if hash := utils.BytesToHexStr(signed.Hash()); o.Hash != "" && !strings.EqualFold(hash, o.Hash) {
+		return nil, fmt.Errorf("raw's hash %s is different from hash %s", hash, o.Hash)
+	}
+	return signed, nil
+}
+
+// Summary describes the transaction for people to check before signing or broadcasting
+func (o *OfflineTx) Summary() string {
+	tx := o.Transaction
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "network: %s(chain id %s)\n", o.Network, o.ChainId.String())
+	fmt.Fprintf(&sb, "from: %s\n", tx.From.String())
+	if o.Token != nil && o.Token.Address != nil && sameAddress(tx.To, o.Token.Address) && len(tx.Data) == 68 &&
+		bytes.Equal(tx.Data[:4], Erc20FunctionInterface.Transfer.MethodId) {
+		to := common.BytesToAddress(tx.Data[4:36])
+		amount := new(big.Int).SetBytes(tx.Data[36:68])
+		fmt.Fprintf(&sb, "send: %s %s(%s)\n", NewAmount(amount, o.Token.Decimals).String(), o.Token.Symbol, o.Token.Address.String())
+		fmt.Fprintf(&sb, "to: %s\n", to.String())
+	} else {
+		if tx.To == nil {
+			fmt.Fprintf(&sb, "to: contract creation\n")
+		} else {
+			fmt.Fprintf(&sb, "to: %s\n", tx.To.String())
+		}
+		if len(tx.Data) > 0 {
+			fmt.Fprintf(&sb, "data: %d bytes\n", len(tx.Data))
+		}
+	}
+	value := tx.Value
+	if value == nil {
+		value = big.NewInt(0)
+	}
+	fmt.Fprintf(&sb, "value: %s ether\n", EtherString(value))
+	fmt.Fprintf(&sb, "nonce: %d\n", tx.Nonce)
+	fmt.Fprintf(&sb, "gas limit: %d\n", tx.GasLimit)
+	if tx.Type == DynamicFeeTxType {
+		fmt.Fprintf(&sb, "max fee: %s gwei, tip: %s gwei\n", GweiString(tx.MaxFeePerGas), GweiString(tx.MaxPriorityFeePerGas))
+	} else {
+		fmt.Fprintf(&sb, "gas price: %s gwei\n", GweiString(tx.GasPrice))
+	}
+	if fee := tx.FeeCap(); fee != nil {
+		cost := new(big.Int).Mul(fee, new(big.Int).SetUint64(tx.GasLimit))
+		cost.Add(cost, value)
+		fmt.Fprintf(&sb, "max cost: %s ether\n", EtherString(cost))
+	}
+	return sb.String()
+}
+
+func sameAddress(a, b *common.Address) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func sameAccessList(a, b AccessList) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Address != b[i].Address || len(a[i].StorageKeys) != len(b[i].StorageKeys) {
+			return false
+		}
+		for j := range a[i].StorageKeys {
+			if a[i].StorageKeys[j] != b[i].StorageKeys[j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func sameBigInt(a, b *big.Int) bool {
+	if a == nil {
+		a = big.NewInt(0)
+	}
+	if b == nil {
+		b = big.NewInt(0)
+	}
+	return a.Cmp(b) == 0
+}
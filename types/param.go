@@ -2,7 +2,9 @@ package types
 
 import (
 	"fmt"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/tn606024/ethwallet/utils"
+	"math/big"
 )
 
 type BlockParam string
@@ -28,8 +30,9 @@ func NewBlockParam(input string) (BlockParam, error) {
 }
 
 type Network struct {
-	ChainId byte
-	Name    string
+	ChainId     *big.Int
+	Name        string
+	ExplorerUrl string
 }
 
 func (n *Network) UnmarshalText(text []byte) (err error) {
@@ -46,35 +49,74 @@ func (n Network) MarshalText() (text []byte, err error){
 	return []byte(n.Name), nil
 }
 
+// networks contains every network NewNetwork can resolve, user-defined networks in config.json
+// are added by RegisterNetwork when config is imported.
+var networks = map[string]*Network{}
+
 func NewNetwork(input string) (*Network, error) {
-	switch input {
-	case EthereumNet.Name:
-		return EthereumNet, nil
-	case RopstenNet.Name:
-		return RopstenNet, nil
-	case RinkebyNet.Name:
-		return RinkebyNet, nil
-	default:
+	network, ok := networks[input]
+	if !ok {
 		return nil, fmt.Errorf("input is not match any network")
 	}
+	return network, nil
 }
 
-var EthereumNet = &Network{
-	ChainId: 1,
-	Name:    "mainnet",
+// RegisterNetwork adds a named network, a predefined network can't be registered with another chain id
+func RegisterNetwork(name string, chainId *big.Int) (*Network, error) {
+	if name == "" || chainId == nil || chainId.Sign() <= 0 {
+		return nil, fmt.Errorf("network %s needs a name and a positive chain_id", name)
+	}
+	if network, ok := networks[name]; ok {
+		if network.ChainId.Cmp(chainId) != 0 {
+			return nil, fmt.Errorf("network %s already has chain id %s", name, network.ChainId.String())
+		}
+		return network, nil
+	}
+	network := &Network{
+		ChainId: new(big.Int).Set(chainId),
+		Name:    name,
+	}
+	networks[name] = network
+	return network, nil
 }
 
-var RopstenNet = &Network{
-	ChainId: 3,
-	Name:    "ropsten",
+func newNetwork(name string, chainId int64, explorerUrl string) *Network {
+	network, err := RegisterNetwork(name, big.NewInt(chainId))
+	if err != nil {
+		panic(err)
+	}
+	network.ExplorerUrl = explorerUrl
+	return network
 }
 
-var RinkebyNet =&Network{
-	ChainId: 4,
-	Name:    "rinkeby",
-}
+var EthereumNet = newNetwork("mainnet", 1, "https://etherscan.io")
+
+var RopstenNet = newNetwork("ropsten", 3, "https://ropsten.etherscan.io")
+
+var RinkebyNet = newNetwork("rinkeby", 4, "https://rinkeby.etherscan.io")
+
+var GoerliNet = newNetwork("goerli", 5, "https://goerli.etherscan.io")
+
+var SepoliaNet = newNetwork("sepolia", 11155111, "https://sepolia.etherscan.io")
+
+var HoleskyNet = newNetwork("holesky", 17000, "https://holesky.etherscan.io")
+
+var PolygonNet = newNetwork("polygon", 137, "https://polygonscan.com")
+
+var ArbitrumNet = newNetwork("arbitrum", 42161, "https://arbiscan.io")
+
+var OptimismNet = newNetwork("optimism", 10, "https://optimistic.etherscan.io")
 
 type NetworkUrl struct {
 	NodeUrl 		  string 	`json:"node_url"`
 	EtherscanApiUrl   string	`json:"etherscan_api_url"`
-}
\ No newline at end of file
+	ExplorerUrl       string    `json:"explorer_url,omitempty"`
+	ChainId           *big.Int  `json:"chain_id,omitempty"`
+	WsUrl             string    `json:"ws_url,omitempty"`
+}
+
+// LogFilter is the filter of eth_subscribe logs, an empty Topics position matches any topic
+type LogFilter struct {
+	Address []common.Address `json:"address,omitempty"`
+	Topics  [][]common.Hash  `json:"topics,omitempty"`
+}
@@ -0,0 +1,193 @@
+package types
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/utils"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// EtherSymbol is the symbol of ether in payout csv, other symbols are looked up in erc20_list
+const EtherSymbol = "ETH"
+
+const (
+	PayoutSigned = "signed"
+	PayoutSent   = "sent"
+	PayoutFailed = "failed"
+)
+
+var payoutResultHeader = []string{"line", "recipient", "symbol", "amount", "nonce", "txid", "status", "error", "raw"}
+
+// Payout is a line of payout csv and its result. Value is in wei or token's smallest unit,
+// Token is nil for ether. Status is empty before the payout is signed.
+type Payout struct {
+	Line      int
+	Recipient common.Address
+	Symbol    string
+	Amount    string
+	Value     *big.Int
+	Token     *Erc20Token
+	Nonce     uint64
+	Txid      string
+	Status    string
+	Error     string
+	Raw       string
+}
+
+// ReadPayoutCsv reads lines of recipient, symbol and amount, amount is in ether or token's unit and can have decimals.
+// The symbol can be the token's address if several tokens have it.
+// A header line is skipped and Line of a payout is its record number in the csv. Every line is validated,
+// errors of all lines are returned together. Addresses are checked with eip1191 checksums of chainId if it's given.
+func ReadPayoutCsv(r io.Reader, tokens []*Erc20Token, chainId *big.Int) ([]*Payout, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	var payouts []*Payout
+	var errs []string
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read payout csv error: %s", err)
+		}
+		if line == 1 && isPayoutHeader(record[0]) {
+			continue
+		}
+		p, err := parsePayout(line, record, tokens, chainId)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("line %d: %s", line, err))
+			continue
+		}
+		payouts = append(payouts, p)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("payout csv is invalid:\n%s", strings.Join(errs, "\n"))
+	}
+	if len(payouts) == 0 {
+		return nil, fmt.Errorf("payout csv has no payout")
+	}
+	return payouts, nil
+}
+
+func isPayoutHeader(field string) bool {
+	switch strings.ToLower(strings.TrimSpace(field)) {
+	case "recipient", "address", "to":
+		return true
+	}
+	return false
+}
+
+func parsePayout(line int, record []string, tokens []*Erc20Token, chainId *big.Int) (*Payout, error) {
+	if len(record) != 3 {
+		return nil, fmt.Errorf("needs recipient, symbol and amount, got %d fields", len(record))
+	}
+	recipient, err := utils.ParseAddress(strings.TrimSpace(record[0]), chainId)
+	if err != nil {
+		return nil, fmt.Errorf("recipient is illegal: %s", err)
+	}
+	p := &Payout{
+		Line:      line,
+		Recipient: recipient,
+		Symbol:    strings.TrimSpace(record[1]),
+		Amount:    strings.TrimSpace(record[2]),
+	}
+	if p.Recipient == (common.Address{}) {
+		return nil, fmt.Errorf("recipient is the zero address")
+	}
+	decimals := 18
+	switch {
+	case strings.EqualFold(p.Symbol, EtherSymbol):
+	case strings.HasPrefix(p.Symbol, "0x"):
+		address, err := utils.ParseAddress(p.Symbol, chainId)
+		if err != nil {
+			return nil, fmt.Errorf("token is illegal: %s", err)
+		}
+		p.Token = Erc20TokenByAddress(tokens, address)
+		if p.Token == nil {
+			return nil, fmt.Errorf("token %s is not in erc20_list or token_lists", p.Symbol)
+		}
+	default:
+		token, err := FindErc20Token(tokens, p.Symbol)
+		if err != nil {
+			return nil, err
+		}
+		p.Token = token
+	}
+	if p.Token != nil {
+		decimals = p.Token.Decimals
+	}
+	amount, err := ParseAmount(p.Amount, decimals)
+	if err != nil {
+		return nil, err
+	}
+	if amount.Value.Sign() <= 0 {
+		return nil, fmt.Errorf("amount %s is not positive", p.Amount)
+	}
+	p.Value = amount.Value
+	return p, nil
+}
+
+// ReadPayoutResults reads the results csv written by WritePayoutResults and copies the results into payouts,
+// the results must be of the same payout csv.
+func ReadPayoutResults(r io.Reader, payouts []*Payout) error {
+	reader := csv.NewReader(r)
+	records, err := reader.ReadAll()
+	if err != nil {
+		return fmt.Errorf("read payout results error: %s", err)
+	}
+	byLine := make(map[int]*Payout, len(payouts))
+	for _, p := range payouts {
+		byLine[p.Line] = p
+	}
+	for i, record := range records {
+		if i == 0 {
+			continue
+		}
+		if len(record) != len(payoutResultHeader) {
+			return fmt.Errorf("payout results line %d has %d fields", i+1, len(record))
+		}
+		line, err := strconv.Atoi(record[0])
+		if err != nil {
+			return fmt.Errorf("payout results line %d: %s", i+1, err)
+		}
+		p, ok := byLine[line]
+		if !ok || !strings.EqualFold(record[1], p.Recipient.String()) || record[2] != p.Symbol || record[3] != p.Amount {
+			return fmt.Errorf("payout results line %d doesn't match line %d of payout csv, the csv was changed after it's sent", i+1, line)
+		}
+		if record[4] != "" {
+			p.Nonce, err = strconv.ParseUint(record[4], 10, 64)
+			if err != nil {
+				return fmt.Errorf("payout results line %d: %s", i+1, err)
+			}
+		}
+		p.Txid, p.Status, p.Error, p.Raw = record[5], record[6], record[7], record[8]
+	}
+	return nil
+}
+
+// WritePayoutResults writes payouts with nonce, txid, status and error, raw is kept so a signed payout is sent
+// again as the same transaction when resuming.
+func WritePayoutResults(w io.Writer, payouts []*Payout) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(payoutResultHeader); err != nil {
+		return err
+	}
+	for _, p := range payouts {
+		nonce := ""
+		if p.Status != "" {
+			nonce = strconv.FormatUint(p.Nonce, 10)
+		}
+		record := []string{strconv.Itoa(p.Line), p.Recipient.String(), p.Symbol, p.Amount, nonce, p.Txid, p.Status, p.Error, p.Raw}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
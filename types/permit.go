@@ -0,0 +1,191 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/utils"
+	"math/big"
+)
+
+var PermitFunctionInterface = struct {
+	Permit          Method
+	Nonces          Method
+	DomainSeparator Method
+	Version         Method
+}{
+	Permit: Method{
+		FunctionSignature: "permit(address,address,uint256,uint256,uint8,bytes32,bytes32)",
+		MethodId: []byte{
+			0xd5, 0x05, 0xac, 0xcf,
+		},
+	},
+	Nonces: Method{
+		FunctionSignature: "nonces(address)",
+		MethodId: []byte{
+			0x7e, 0xce, 0xbe, 0x00,
+		},
+	},
+	DomainSeparator: Method{
+		FunctionSignature: "DOMAIN_SEPARATOR()",
+		MethodId: []byte{
+			0x36, 0x44, 0xe5, 0x15,
+		},
+	},
+	Version: Method{
+		FunctionSignature: "version()",
+		MethodId: []byte{
+			0x54, 0xfd, 0x4d, 0x50,
+		},
+	},
+}
+
+const PermitType = "Permit"
+
+var permitFields = []TypedDataField{
+	{Name: "owner", Type: "address"},
+	{Name: "spender", Type: "address"},
+	{Name: "value", Type: "uint256"},
+	{Name: "nonce", Type: "uint256"},
+	{Name: "deadline", Type: "uint256"},
+}
+
+// permitVersions are tried in order when the token doesn't implement version()
+var permitVersions = []string{"1", "2"}
+
+// PermitDomain is what the token declares for eip2612 permits and the owner's current nonce,
+// Version is empty if the token doesn't implement version().
+type PermitDomain struct {
+	Name              string         `json:"name"`
+	Version           string         `json:"version"`
+	ChainId           *BigIntHex     `json:"chainid"`
+	VerifyingContract common.Address `json:"verifyingcontract"`
+	DomainSeparator   string         `json:"domainseparator"`
+	Owner             common.Address `json:"owner"`
+	Nonce             *BigIntHex     `json:"nonce"`
+}
+
+// Permit is the eip2612 message, value and deadline are in token's smallest unit and unix seconds
+type Permit struct {
+	Owner    common.Address
+	Spender  common.Address
+	Value    *big.Int
+	Nonce    *big.Int
+	Deadline *big.Int
+}
+
+// NewPermit creates the permit for the owner's current nonce of domain
+func (d *PermitDomain) NewPermit(spender common.Address, value *big.Int, deadline *big.Int) *Permit {
+	return &Permit{
+		Owner:    d.Owner,
+		Spender:  spender,
+		Value:    new(big.Int).Set(value),
+		Nonce:    new(big.Int).Set((*big.Int)(d.Nonce)),
+		Deadline: new(big.Int).Set(deadline),
+	}
+}
+
+// TypedData builds the eip712 payload of permit. Tokens don't agree on the domain's version, so the reported
+// version and then the common ones are tried until the domain hashes to the token's DOMAIN_SEPARATOR.
+func (d *PermitDomain) TypedData(permit *Permit) (*TypedData, error) {
+	separator := utils.HexStrToBytes(d.DomainSeparator)
+	if len(separator) != 32 {
+		return nil, fmt.Errorf("token %s has no DOMAIN_SEPARATOR, it may not support permit", d.VerifyingContract.String())
+	}
+	versions := permitVersions
+	if d.Version != "" {
+		versions = append([]string{d.Version}, permitVersions...)
+	}
+	// the last try is a domain without version
+	for _, version := range append(versions, "") {
+		td := &TypedData{
+			Types: map[string][]TypedDataField{
+				PermitType: permitFields,
+			},
+			PrimaryType: PermitType,
+			Domain: map[string]interface{}{
+				"name":              d.Name,
+				"chainId":           (*big.Int)(d.ChainId),
+				"verifyingContract": d.VerifyingContract.String(),
+			},
+			Message: map[string]interface{}{
+				"owner":    permit.Owner.String(),
+				"spender":  permit.Spender.String(),
+				"value":    permit.Value,
+				"nonce":    permit.Nonce,
+				"deadline": permit.Deadline,
+			},
+		}
+		fields := []TypedDataField{}
+		for _, field := range eip712DomainFields {
+			switch field.Name {
+			case "version":
+				if version == "" {
+					continue
+				}
+				td.Domain["version"] = version
+			case "salt":
+				continue
+			}
+			fields = append(fields, field)
+		}
+		td.Types[EIP712DomainType] = fields
+		hash, err := td.DomainSeparator()
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(hash, separator) {
+			return td, nil
+		}
+	}
+	return nil, fmt.Errorf("DOMAIN_SEPARATOR of token %s doesn't match name %s and chain id %s with any version", d.VerifyingContract.String(), d.Name, (*big.Int)(d.ChainId).String())
+}
+
+// SignedPermit is what a relayer needs to submit permit, Data is the calldata of permit to the token
+type SignedPermit struct {
+	Token     common.Address `json:"token"`
+	ChainId   *BigIntHex     `json:"chainid"`
+	Owner     common.Address `json:"owner"`
+	Spender   common.Address `json:"spender"`
+	Value     *BigIntHex     `json:"value"`
+	Nonce     *BigIntHex     `json:"nonce"`
+	Deadline  *BigIntHex     `json:"deadline"`
+	V         uint8          `json:"v"`
+	R         string         `json:"r"`
+	S         string         `json:"s"`
+	Signature string         `json:"signature"`
+	Data      string         `json:"data"`
+}
+
+// NewSignedPermit splits signature which v is 27 or 28 into v, r and s
+func NewSignedPermit(domain *PermitDomain, permit *Permit, signature []byte) (*SignedPermit, error) {
+	if len(signature) != 65 {
+		return nil, fmt.Errorf("signature must be 65 bytes, got %d", len(signature))
+	}
+	v, r, s := signature[64], signature[:32], signature[32:64]
+	data := utils.EncodeABI(PermitFunctionInterface.Permit.MethodId, permit.Owner.Bytes(), permit.Spender.Bytes(),
+		permit.Value.Bytes(), permit.Deadline.Bytes(), []byte{v}, r, s)
+	return &SignedPermit{
+		Token:     domain.VerifyingContract,
+		ChainId:   domain.ChainId,
+		Owner:     permit.Owner,
+		Spender:   permit.Spender,
+		Value:     (*BigIntHex)(permit.Value),
+		Nonce:     (*BigIntHex)(permit.Nonce),
+		Deadline:  (*BigIntHex)(permit.Deadline),
+		V:         v,
+		R:         utils.BytesToHexStr(r),
+		S:         utils.BytesToHexStr(s),
+		Signature: utils.BytesToHexStr(signature),
+		Data:      utils.BytesToHexStr(data),
+	}, nil
+}
+
+func (p *SignedPermit) String() (string, error) {
+	b, err := json.MarshalIndent(p, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	return string(b) + "\n", nil
+}
@@ -0,0 +1,206 @@
+package types
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/rlp"
+	"github.com/tn606024/ethwallet/utils"
+	"math/big"
+)
+
+// DecodeRawTx parses a signed raw transaction in hex, legacy and typed(eip2718) envelopes are supported.
+// The sender is recovered from V, R, S and stored in From.
+func DecodeRawTx(raw string) (*Transaction, error) {
+	if !utils.IsHexStr(utils.TrimHexPrefix(raw)) {
+		return nil, fmt.Errorf("raw transaction is not a hex string")
+	}
+	return DecodeTransaction(utils.HexStrToBytes(raw))
+}
+
+func DecodeTransaction(b []byte) (*Transaction, error) {
+	if len(b) == 0 {
+		return nil, fmt.Errorf("raw transaction is empty")
+	}
+	var tx *Transaction
+	var err error
+	switch {
+	case b[0] >= 0xc0:
+		tx, err = decodeLegacyTx(b)
+	case b[0] == AccessListTxType || b[0] == DynamicFeeTxType:
+		tx, err = decodeTypedTx(b[0], b[1:])
+	default:
+		return nil, fmt.Errorf("transaction type %d is not supported", b[0])
+	}
+	if err != nil {
+		return nil, err
+	}
+	sender, err := tx.Sender()
+	if err != nil {
+		return nil, err
+	}
+	tx.From = &sender
+	return tx, nil
+}
+
+func decodeLegacyTx(b []byte) (*Transaction, error) {
+	fields, err := rlp.DecodeList(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) != 9 {
+		return nil, fmt.Errorf("legacy transaction must have 9 fields, got %d", len(fields))
+	}
+	d := &txDecoder{fields: fields}
+	tx := &Transaction{
+		Type:     LegacyTxType,
+		Nonce:    d.uint64(),
+		GasPrice: d.bigInt(),
+		GasLimit: d.uint64(),
+		To:       d.address(),
+		Value:    d.bigInt(),
+		Data:     d.bytes(),
+		V:        d.bigInt(),
+		R:        d.bigInt(),
+		S:        d.bigInt(),
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	if tx.V.Cmp(big.NewInt(35)) >= 0 {
+		chainId := new(big.Int).Sub(tx.V, big.NewInt(35))
+		tx.ChainId = chainId.Div(chainId, big.NewInt(2))
+	}
+	return tx, nil
+}
+
+func decodeTypedTx(typ uint8, b []byte) (*Transaction, error) {
+	fields, err := rlp.DecodeList(b)
+	if err != nil {
+		return nil, err
+	}
+	size := 11
+	if typ == DynamicFeeTxType {
+		size = 12
+	}
+	if len(fields) != size {
+		return nil, fmt.Errorf("type %d transaction must have %d fields, got %d", typ, size, len(fields))
+	}
+	d := &txDecoder{fields: fields}
+	tx := &Transaction{
+		Type:    typ,
+		ChainId: d.bigInt(),
+		Nonce:   d.uint64(),
+	}
+	if typ == DynamicFeeTxType {
+		tx.MaxPriorityFeePerGas = d.bigInt()
+		tx.MaxFeePerGas = d.bigInt()
+	} else {
+		tx.GasPrice = d.bigInt()
+	}
+	tx.GasLimit = d.uint64()
+	tx.To = d.address()
+	tx.Value = d.bigInt()
+	tx.Data = d.bytes()
+	tx.AccessList = d.accessList()
+	tx.V = d.bigInt()
+	tx.R = d.bigInt()
+	tx.S = d.bigInt()
+	if d.err != nil {
+		return nil, d.err
+	}
+	return tx, nil
+}
+
+// txDecoder reads rlp fields in order and keeps the first error
+type txDecoder struct {
+	fields []interface{}
+	pos    int
+	err    error
+}
+
+func (d *txDecoder) next() interface{} {
+	item := d.fields[d.pos]
+	d.pos++
+	return item
+}
+
+func (d *txDecoder) bytes() []byte {
+	b, ok := d.next().([]byte)
+	if !ok && d.err == nil {
+		d.err = fmt.Errorf("field %d: expected string, got list", d.pos-1)
+	}
+	return b
+}
+
+func (d *txDecoder) bigInt() *big.Int {
+	b := d.bytes()
+	if len(b) > 0 && b[0] == 0 && d.err == nil {
+		d.err = fmt.Errorf("field %d: integer has leading zero bytes", d.pos-1)
+	}
+	if len(b) > 32 && d.err == nil {
+		d.err = fmt.Errorf("field %d: integer is bigger than 256 bits", d.pos-1)
+	}
+	return new(big.Int).SetBytes(b)
+}
+
+func (d *txDecoder) uint64() uint64 {
+	i := d.bigInt()
+	if !i.IsUint64() && d.err == nil {
+		d.err = fmt.Errorf("field %d: integer overflows uint64", d.pos-1)
+	}
+	return i.Uint64()
+}
+
+// address returns nil for an empty string, which means contract creation
+func (d *txDecoder) address() *common.Address {
+	b := d.bytes()
+	if len(b) == 0 {
+		return nil
+	}
+	if len(b) != common.AddressLength && d.err == nil {
+		d.err = fmt.Errorf("field %d: address must be %d bytes, got %d", d.pos-1, common.AddressLength, len(b))
+	}
+	address := common.BytesToAddress(b)
+	return &address
+}
+
+func (d *txDecoder) accessList() AccessList {
+	items, ok := d.next().([]interface{})
+	if !ok {
+		if d.err == nil {
+			d.err = fmt.Errorf("field %d: access list must be a list", d.pos-1)
+		}
+		return nil
+	}
+	accessList := AccessList{}
+	for _, item := range items {
+		tuple, ok := item.([]interface{})
+		if !ok || len(tuple) != 2 {
+			if d.err == nil {
+				d.err = fmt.Errorf("field %d: access tuple must be [address, storageKeys]", d.pos-1)
+			}
+			return nil
+		}
+		address, ok := tuple[0].([]byte)
+		keys, ok2 := tuple[1].([]interface{})
+		if !ok || !ok2 || len(address) != common.AddressLength {
+			if d.err == nil {
+				d.err = fmt.Errorf("field %d: access tuple is illegal", d.pos-1)
+			}
+			return nil
+		}
+		entry := AccessTuple{Address: common.BytesToAddress(address), StorageKeys: []common.Hash{}}
+		for _, key := range keys {
+			kb, ok := key.([]byte)
+			if !ok || len(kb) != common.HashLength {
+				if d.err == nil {
+					d.err = fmt.Errorf("field %d: storage key must be %d bytes", d.pos-1, common.HashLength)
+				}
+				return nil
+			}
+			entry.StorageKeys = append(entry.StorageKeys, common.BytesToHash(kb))
+		}
+		accessList = append(accessList, entry)
+	}
+	return accessList
+}
@@ -2,6 +2,7 @@ package types
 
 import (
 	"encoding/json"
+	"math/big"
 )
 
 type Response struct {
@@ -21,16 +22,83 @@ type EthereumError struct {
 	Message		string	`json:"message"`
 }
 
+// NodeTransaction's BlockHash is empty while it's pending, fee caps are only set for eip1559 transactions
 type NodeTransaction struct {
-	BlockNumber      IntHex    `json:"blockNumber"`
-	Hash             string    `json:"hash"`
-	Nonce            IntHex    `json:"nonce"`
-	BlockHash        string    `json:"blockHash"`
-	TransactionIndex IntHex    `json:"transactionIndex"`
-	From             string    `json:"from"`
-	To               string    `json:"to"`
-	Value            BigIntHex `json:"value"`
-	Gas              IntHex    `json:"gas"`
-	GasPrice         BigIntHex `json:"gasPrice"`
-	Input            string    `json:"input"`
-}
\ No newline at end of file
+	BlockNumber          IntHex     `json:"blockNumber"`
+	Hash                 string     `json:"hash"`
+	Type                 IntHex     `json:"type"`
+	Nonce                IntHex     `json:"nonce"`
+	BlockHash            string     `json:"blockHash"`
+	TransactionIndex     IntHex     `json:"transactionIndex"`
+	From                 string     `json:"from"`
+	To                   string     `json:"to"`
+	Value                BigIntHex  `json:"value"`
+	Gas                  IntHex     `json:"gas"`
+	GasPrice             BigIntHex  `json:"gasPrice"`
+	MaxFeePerGas         *BigIntHex `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *BigIntHex `json:"maxPriorityFeePerGas,omitempty"`
+	AccessList           AccessList `json:"accessList,omitempty"`
+	Input                string     `json:"input"`
+}
+
+type NodeBlock struct {
+	Number        IntHex     `json:"number"`
+	Hash          string     `json:"hash"`
+	ParentHash    string     `json:"parentHash"`
+	Timestamp     IntHex     `json:"timestamp"`
+	GasLimit      IntHex     `json:"gasLimit"`
+	GasUsed       IntHex     `json:"gasUsed"`
+	BaseFeePerGas *BigIntHex `json:"baseFeePerGas,omitempty"`
+	Transactions  []string   `json:"transactions"`
+}
+
+type AccessListResult struct {
+	AccessList AccessList `json:"accessList"`
+	GasUsed    IntHex     `json:"gasUsed"`
+	Error      string     `json:"error,omitempty"`
+}
+
+type NodeLog struct {
+	Address          string   `json:"address"`
+	Topics           []string `json:"topics"`
+	Data             string   `json:"data"`
+	BlockNumber      IntHex   `json:"blockNumber"`
+	BlockHash        string   `json:"blockHash"`
+	TransactionHash  string   `json:"transactionHash"`
+	TransactionIndex IntHex   `json:"transactionIndex"`
+	LogIndex         IntHex   `json:"logIndex"`
+	Removed          bool     `json:"removed"`
+}
+
+type NodeReceipt struct {
+	TransactionHash   string     `json:"transactionHash"`
+	TransactionIndex  IntHex     `json:"transactionIndex"`
+	BlockHash         string     `json:"blockHash"`
+	BlockNumber       IntHex     `json:"blockNumber"`
+	From              string     `json:"from"`
+	To                string     `json:"to"`
+	ContractAddress   string     `json:"contractAddress"`
+	CumulativeGasUsed IntHex     `json:"cumulativeGasUsed"`
+	GasUsed           IntHex     `json:"gasUsed"`
+	EffectiveGasPrice *BigIntHex `json:"effectiveGasPrice,omitempty"`
+	Status            IntHex     `json:"status"`
+	Logs              []NodeLog  `json:"logs"`
+}
+
+func (r *NodeReceipt) String() (string, error) {
+	rs, err := json.MarshalIndent(r, "", "	")
+	if err != nil {
+		return "", err
+	}
+	return string(rs) + "\n", nil
+}
+
+// TransactionParams is what a transaction needs from node, BaseFee and MaxPriorityFee are nil if
+// the node doesn't support eip1559 and GasLimit is 0 if gas is not estimated.
+type TransactionParams struct {
+	Nonce          uint64   `json:"nonce,string"`
+	GasPrice       *big.Int `json:"gasprice"`
+	BaseFee        *big.Int `json:"basefee"`
+	MaxPriorityFee *big.Int `json:"maxpriorityfee"`
+	GasLimit       uint64   `json:"gaslimit,string"`
+}
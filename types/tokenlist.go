@@ -0,0 +1,186 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// tokenListCacheExpiry is how long a token list fetched from url is used before it's fetched again,
+// an expired cache is still used if the url can't be fetched
+var tokenListCacheExpiry = 24 * time.Hour
+
+// TokenList is a token list of the tokenlists.org schema, tokens of every chain are in one list
+type TokenList struct {
+	Name      string        `json:"name"`
+	Timestamp string        `json:"timestamp"`
+	Tokens    []*Erc20Token `json:"tokens"`
+}
+
+// ParseTokenList decodes and validates a token list, a token without chainId or symbol makes the list invalid
+func ParseTokenList(b []byte) (*TokenList, error) {
+	var list TokenList
+	if err := json.Unmarshal(b, &list); err != nil {
+		return nil, fmt.Errorf("token list unmarshal error: %s", err)
+	}
+	if list.Tokens == nil {
+		return nil, fmt.Errorf("token list has no tokens")
+	}
+	for i, token := range list.Tokens {
+		switch {
+		case token == nil || token.Address == nil:
+			return nil, fmt.Errorf("token %d of token list %s has no address", i, list.Name)
+		case token.ChainId <= 0:
+			return nil, fmt.Errorf("token %s of token list %s has no chainId", token.Address.String(), list.Name)
+		case token.Symbol == "":
+			return nil, fmt.Errorf("token %s of token list %s has no symbol", token.Address.String(), list.Name)
+		case token.Decimals < 0 || token.Decimals > 255:
+			return nil, fmt.Errorf("token %s of token list %s has illegal decimals %d", token.Address.String(), list.Name, token.Decimals)
+		}
+	}
+	return &list, nil
+}
+
+// Erc20Tokens returns tokens of the list on chainId
+func (l *TokenList) Erc20Tokens(chainId *big.Int) []*Erc20Token {
+	var tokens []*Erc20Token
+	for _, token := range l.Tokens {
+		if token.ChainId == chainId.Int64() {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// LoadErc20List keeps tokens of erc20_list without chainId or on the network, then adds tokens on the network
+// of every token list in token_lists which are not in erc20_list yet. A token list which can't be loaded is
+// skipped, the error of it is returned after the other lists are loaded.
+func (c *Config) LoadErc20List() error {
+	if c.Network == nil {
+		return nil
+	}
+	chainId := c.Network.ChainId
+	var tokens []*Erc20Token
+	for _, token := range c.Erc20List {
+		if token.ChainId == 0 || token.ChainId == chainId.Int64() {
+			tokens = append(tokens, token)
+		}
+	}
+	var errs []string
+	for _, source := range c.TokenLists {
+		list, err := LoadTokenList(source, c.TokenListCacheDir)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		for _, token := range list.Erc20Tokens(chainId) {
+			if Erc20TokenByAddress(tokens, *token.Address) == nil {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	c.Erc20List = tokens
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// LoadTokenList reads a token list from a file, or from a http(s) url through a cache file in cacheDir
+func LoadTokenList(source string, cacheDir string) (*TokenList, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		b, err := ioutil.ReadFile(source)
+		if err != nil {
+			return nil, fmt.Errorf("read token list error: %s", err)
+		}
+		return ParseTokenList(b)
+	}
+	sum := sha256.Sum256([]byte(source))
+	cachePath := filepath.Join(cacheDir, hex.EncodeToString(sum[:8])+".json")
+	info, err := os.Stat(cachePath)
+	if err == nil && time.Since(info.ModTime()) < tokenListCacheExpiry {
+		if b, err := ioutil.ReadFile(cachePath); err == nil {
+			if list, err := ParseTokenList(b); err == nil {
+				return list, nil
+			}
+		}
+	}
+	b, fetchErr := fetchTokenList(source)
+	if fetchErr == nil {
+		list, err := ParseTokenList(b)
+		if err != nil {
+			return nil, fmt.Errorf("token list of %s is invalid: %s", source, err)
+		}
+		if err := os.MkdirAll(cacheDir, 0700); err != nil {
+			return nil, fmt.Errorf("create token list cache dir error: %s", err)
+		}
+		tmp := cachePath + ".tmp"
+		if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+			return nil, fmt.Errorf("write token list cache error: %s", err)
+		}
+		return list, os.Rename(tmp, cachePath)
+	}
+	b, err = ioutil.ReadFile(cachePath)
+	if err != nil {
+		return nil, fmt.Errorf("fetch token list %s error: %s", source, fetchErr)
+	}
+	return ParseTokenList(b)
+}
+
+func fetchTokenList(url string) ([]byte, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	res, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %s", res.Status)
+	}
+	return ioutil.ReadAll(res.Body)
+}
+
+// Erc20TokensBySymbol returns every token of symbol, tokens with the same address are counted once
+func Erc20TokensBySymbol(tokens []*Erc20Token, symbol string) []*Erc20Token {
+	var matches []*Erc20Token
+	for _, token := range tokens {
+		if token.Symbol == symbol && token.Address != nil && Erc20TokenByAddress(matches, *token.Address) == nil {
+			matches = append(matches, token)
+		}
+	}
+	return matches
+}
+
+func Erc20TokenByAddress(tokens []*Erc20Token, address common.Address) *Erc20Token {
+	for _, token := range tokens {
+		if token.Address != nil && *token.Address == address {
+			return token
+		}
+	}
+	return nil
+}
+
+// FindErc20Token finds the only token of symbol, a symbol of several tokens needs the token's address
+func FindErc20Token(tokens []*Erc20Token, symbol string) (*Erc20Token, error) {
+	matches := Erc20TokensBySymbol(tokens, symbol)
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("erc20 symbol %s can't find", symbol)
+	case 1:
+		return matches[0], nil
+	}
+	addresses := make([]string, len(matches))
+	for i, token := range matches {
+		addresses[i] = token.Address.String()
+	}
+	return nil, fmt.Errorf("erc20 symbol %s is ambiguous, it's the symbol of %s, specify the token's address", symbol, strings.Join(addresses, ", "))
+}
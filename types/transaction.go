@@ -2,6 +2,7 @@ package types
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/tn606024/ethwallet/crypto"
 	"github.com/tn606024/ethwallet/rlp"
@@ -10,13 +11,44 @@ import (
 )
 
 
+const (
+	LegacyTxType     uint8 = 0x00
+	AccessListTxType uint8 = 0x01
+	DynamicFeeTxType uint8 = 0x02
+)
+
+// AccessList is the eip2930 list of addresses and storage keys a transaction plans to access
+type AccessList []AccessTuple
+
+type AccessTuple struct {
+	Address     common.Address `json:"address"`
+	StorageKeys []common.Hash  `json:"storageKeys"`
+}
+
+func (al AccessList) ToRLP() []byte {
+	addresses := make([][]byte, 0, len(al))
+	storageKeys := make([][][]byte, 0, len(al))
+	for _, tuple := range al {
+		keys := make([][]byte, 0, len(tuple.StorageKeys))
+		for _, key := range tuple.StorageKeys {
+			keys = append(keys, key.Bytes())
+		}
+		addresses = append(addresses, tuple.Address.Bytes())
+		storageKeys = append(storageKeys, keys)
+	}
+	return rlp.EncodeAccessList(addresses, storageKeys)
+}
+
 type TransactionRequest struct {
-	From     string `json:"from,omitempty"`
-	To       string `json:"to,omitempty"`
-	Gas      string `json:"gas,omitempty"`
-	GasPrice string `json:"gasPrice,omitempty"`
-	Value    string `json:"value,omitempty"`
-	Data     string `json:"data,omitempty"`
+	From                 string `json:"from,omitempty"`
+	To                   string `json:"to,omitempty"`
+	Gas                  string `json:"gas,omitempty"`
+	GasPrice             string `json:"gasPrice,omitempty"`
+	MaxFeePerGas         string `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas string `json:"maxPriorityFeePerGas,omitempty"`
+	Value                string `json:"value,omitempty"`
+	Data                 string `json:"data,omitempty"`
+	AccessList           AccessList `json:"accessList,omitempty"`
 }
 
 func (t *TransactionRequest) String() (string, error){
@@ -28,13 +60,18 @@ func (t *TransactionRequest) String() (string, error){
 }
 
 type Transaction struct {
-	Nonce    uint64   `json:"nonce"`
-	GasPrice *big.Int `json:"gasprice"`
-	GasLimit uint64   `json:"gaslimit"`
+	Type                 uint8    `json:"type,omitempty"`
+	ChainId              *big.Int `json:"chainid,omitempty"`
+	Nonce                uint64   `json:"nonce"`
+	GasPrice             *big.Int `json:"gasprice,omitempty"`
+	MaxFeePerGas         *big.Int `json:"maxfeepergas,omitempty"`
+	MaxPriorityFeePerGas *big.Int `json:"maxpriorityfeepergas,omitempty"`
+	GasLimit             uint64   `json:"gaslimit"`
 	From     *common.Address `json:"from,omitempty"`
 	To       *common.Address `json:"to"`
 	Value    *big.Int `json:"value"`
 	Data     Data     `json:"data"`
+	AccessList AccessList `json:"accesslist,omitempty"`
 	V        *big.Int `json:"v"`
 	R        *big.Int `json:"r"`
 	S        *big.Int `json:"s"`
@@ -63,15 +100,43 @@ func NewTransaction(nonce uint64, gasprice *big.Int, gaslimit uint64, to *common
 	}
 }
 
+func NewDynamicFeeTransaction(nonce uint64, maxFeePerGas *big.Int, maxPriorityFeePerGas *big.Int, gaslimit uint64, to *common.Address, value *big.Int, data []byte) *Transaction {
+	return &Transaction{
+		Type:                 DynamicFeeTxType,
+		Nonce:                nonce,
+		MaxFeePerGas:         maxFeePerGas,
+		MaxPriorityFeePerGas: maxPriorityFeePerGas,
+		GasLimit:             gaslimit,
+		To:                   to,
+		Value:                value,
+		Data:                 data,
+	}
+}
+
+// FeeCap returns the highest price per gas the transaction may pay
+func (t *Transaction) FeeCap() *big.Int {
+	if t.Type == DynamicFeeTxType {
+		return t.MaxFeePerGas
+	}
+	return t.GasPrice
+}
+
 func (t *Transaction) ToTransactionRequest()  *TransactionRequest {
 	txr := &TransactionRequest{}
 	txr.From = t.From.String()
-	txr.To = t.To.String()
+	if t.To != nil {
+		txr.To = t.To.String()
+	}
 	if t.GasPrice == big.NewInt(0) || t.GasPrice == nil {
 		txr.GasPrice = ""
 	}else {
 		txr.GasPrice = utils.BigIntToHex(t.GasPrice)
 	}
+	if t.Type == DynamicFeeTxType {
+		txr.MaxFeePerGas = utils.BigIntToHex(t.MaxFeePerGas)
+		txr.MaxPriorityFeePerGas = utils.BigIntToHex(t.MaxPriorityFeePerGas)
+	}
+	txr.AccessList = t.AccessList
 	if t.Value == nil {
 		txr.Value = "0"
 	}
@@ -90,7 +155,7 @@ func (t *Transaction) ToByteArray() (res [][]byte) {
 		utils.Uint64ToBytes(t.Nonce),
 		t.GasPrice.Bytes(),
 		utils.Uint64ToBytes(t.GasLimit),
-		t.To.Bytes(),
+		addressToBytes(t.To),
 		t.Value.Bytes(),
 		t.Data,
 		t.V.Bytes(),
@@ -100,17 +165,28 @@ func (t *Transaction) ToByteArray() (res [][]byte) {
 }
 
 func (t *Transaction) ToSignHash(network *Network) (res []byte) {
-	tx := utils.ConcatToArray(
+	return t.signHash(network.ChainId)
+}
+
+// signHash returns the hash need to sign, a legacy transaction with nil chainId is hashed
+// without eip155 replay protection.
+func (t *Transaction) signHash(chainId *big.Int) (res []byte) {
+	if t.Type != LegacyTxType {
+		msgb := append([]byte{t.Type}, rlp.Encode(t.typedPayload(chainId))...)
+		return crypto.Keccak256(msgb)
+	}
+	fields := [][]byte{
 		utils.Uint64ToBytes(t.Nonce),
 		t.GasPrice.Bytes(),
 		utils.Uint64ToBytes(t.GasLimit),
-		t.To.Bytes(),
+		addressToBytes(t.To),
 		t.Value.Bytes(),
 		t.Data,
-		[]byte{network.ChainId},
-		[]byte{},
-		[]byte{},
-	)
+	}
+	if chainId != nil {
+		fields = append(fields, chainId.Bytes(), []byte{}, []byte{})
+	}
+	tx := utils.ConcatToArray(fields...)
 
 	msgb := rlp.EncodeList(tx)
 	msghash := crypto.Keccak256(msgb)
@@ -118,7 +194,51 @@ func (t *Transaction) ToSignHash(network *Network) (res []byte) {
 	return
 }
 
+// typedPayload returns the rlp items of a typed transaction, signature values are appended
+// only if they are passed in.
+func (t *Transaction) typedPayload(chainId *big.Int, sig ...*big.Int) []interface{} {
+	payload := []interface{}{
+		bigIntToBytes(chainId),
+		bigIntToBytes(new(big.Int).SetUint64(t.Nonce)),
+	}
+	if t.Type == DynamicFeeTxType {
+		payload = append(payload, bigIntToBytes(t.MaxPriorityFeePerGas), bigIntToBytes(t.MaxFeePerGas))
+	} else {
+		payload = append(payload, bigIntToBytes(t.GasPrice))
+	}
+	payload = append(payload,
+		bigIntToBytes(new(big.Int).SetUint64(t.GasLimit)),
+		addressToBytes(t.To),
+		bigIntToBytes(t.Value),
+		[]byte(t.Data),
+		rlp.RawValue(t.AccessList.ToRLP()),
+	)
+	for _, i := range sig {
+		payload = append(payload, bigIntToBytes(i))
+	}
+	return payload
+}
+
+// addressToBytes returns an empty string for nil, which is the recipient of a contract creation
+func addressToBytes(address *common.Address) []byte {
+	if address == nil {
+		return []byte{}
+	}
+	return address.Bytes()
+}
+
+func bigIntToBytes(i *big.Int) []byte {
+	if i == nil {
+		return []byte{}
+	}
+	return i.Bytes()
+}
+
 func (t *Transaction) ToRLP() (res []byte){
+	if t.Type != LegacyTxType {
+		payload := rlp.Encode(t.typedPayload(t.ChainId, t.V, t.R, t.S))
+		return append([]byte{t.Type}, payload...)
+	}
 	tx := t.ToByteArray()
 	res = rlp.EncodeList(tx)
 	return
@@ -130,6 +250,44 @@ func (t *Transaction) ToRawTx() (res string){
 	return
 }
 
+// Hash returns the transaction hash, the keccak256 of the signed raw transaction
+func (t *Transaction) Hash() []byte {
+	return crypto.Keccak256(t.ToRLP())
+}
+
+// Sender recovers the address which signed the transaction from V, R, S
+func (t *Transaction) Sender() (common.Address, error) {
+	if t.V == nil || t.R == nil || t.S == nil {
+		return common.Address{}, fmt.Errorf("transaction is not signed")
+	}
+	chainId := t.ChainId
+	recid := new(big.Int).Set(t.V)
+	if t.Type == LegacyTxType {
+		if t.V.Cmp(big.NewInt(35)) >= 0 {
+			chainId = new(big.Int).Sub(t.V, big.NewInt(35))
+			chainId.Div(chainId, big.NewInt(2))
+			recid.Sub(recid, new(big.Int).Mul(chainId, big.NewInt(2)))
+			recid.Sub(recid, big.NewInt(35))
+		} else {
+			chainId = nil
+			recid.Sub(recid, big.NewInt(27))
+		}
+	}
+	if recid.Cmp(big.NewInt(1)) > 0 || recid.Sign() < 0 {
+		return common.Address{}, fmt.Errorf("invalid signature v: %s", t.V.String())
+	}
+	if t.R.BitLen() > 256 || t.S.BitLen() > 256 {
+		return common.Address{}, fmt.Errorf("invalid signature r, s")
+	}
+	sig := append(utils.AddPrefixZero(t.R.Bytes(), 32), utils.AddPrefixZero(t.S.Bytes(), 32)...)
+	sig = append(sig, byte(recid.Uint64()))
+	pub, err := crypto.SigToPub(t.signHash(chainId), sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("recover sender error: %s", err)
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
 func (t *Transaction) String() (string, error){
 	ts, err := json.MarshalIndent(t,"","	")
 	if err != nil {
@@ -0,0 +1,325 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/crypto"
+	"github.com/tn606024/ethwallet/utils"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const EIP712DomainType = "EIP712Domain"
+
+// eip712DomainFields is the order of domain fields defined in eip712,
+// it's used when the payload doesn't declare EIP712Domain in types
+var eip712DomainFields = []TypedDataField{
+	{Name: "name", Type: "string"},
+	{Name: "version", Type: "string"},
+	{Name: "chainId", Type: "uint256"},
+	{Name: "verifyingContract", Type: "address"},
+	{Name: "salt", Type: "bytes32"},
+}
+
+var (
+	typedDataArrayRegexp   = regexp.MustCompile(`^(.+)\[([0-9]*)\]$`)
+	typedDataIntegerRegexp = regexp.MustCompile(`^(u?)int([0-9]*)$`)
+	typedDataBytesRegexp   = regexp.MustCompile(`^bytes([0-9]+)$`)
+)
+
+type TypedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TypedData is the json payload of eth_signTypedData_v4
+type TypedData struct {
+	Types       map[string][]TypedDataField `json:"types"`
+	PrimaryType string                      `json:"primaryType"`
+	Domain      map[string]interface{}      `json:"domain"`
+	Message     map[string]interface{}      `json:"message"`
+}
+
+// ParseTypedData decodes the json payload, numbers are kept as json.Number so uint256 values don't lose precision
+func ParseTypedData(b []byte) (*TypedData, error) {
+	var td TypedData
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	if err := decoder.Decode(&td); err != nil {
+		return nil, fmt.Errorf("typed data unmarshal error: %s", err)
+	}
+	if td.PrimaryType == "" {
+		return nil, fmt.Errorf("typed data primaryType is empty")
+	}
+	if td.Types == nil {
+		td.Types = map[string][]TypedDataField{}
+	}
+	if _, ok := td.Types[EIP712DomainType]; !ok {
+		fields := []TypedDataField{}
+		for _, field := range eip712DomainFields {
+			if _, ok := td.Domain[field.Name]; ok {
+				fields = append(fields, field)
+			}
+		}
+		td.Types[EIP712DomainType] = fields
+	}
+	if _, ok := td.Types[td.PrimaryType]; !ok {
+		return nil, fmt.Errorf("primaryType %s is not defined in types", td.PrimaryType)
+	}
+	return &td, nil
+}
+
+func (td *TypedData) String() (string, error) {
+	b, err := json.MarshalIndent(td, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	return string(b) + "\n", nil
+}
+
+// SignHash returns keccak256("\x19\x01" ‖ domainSeparator ‖ hashStruct(message))
+func (td *TypedData) SignHash() ([]byte, error) {
+	domainSeparator, err := td.DomainSeparator()
+	if err != nil {
+		return nil, err
+	}
+	data := append([]byte{0x19, 0x01}, domainSeparator...)
+	// eip712 omits the message hash when the primary type is the domain itself
+	if td.PrimaryType != EIP712DomainType {
+		messageHash, err := td.HashStruct(td.PrimaryType, td.Message)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, messageHash...)
+	}
+	return crypto.Keccak256(data), nil
+}
+
+func (td *TypedData) DomainSeparator() ([]byte, error) {
+	hash, err := td.HashStruct(EIP712DomainType, td.Domain)
+	if err != nil {
+		return nil, fmt.Errorf("hash domain occured error: %s", err)
+	}
+	return hash, nil
+}
+
+func (td *TypedData) HashStruct(primaryType string, data map[string]interface{}) ([]byte, error) {
+	encoded, err := td.EncodeData(primaryType, data)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256(encoded), nil
+}
+
+func (td *TypedData) TypeHash(primaryType string) ([]byte, error) {
+	encodedType, err := td.EncodeType(primaryType)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256([]byte(encodedType)), nil
+}
+
+// EncodeType returns primaryType's signature followed by its referenced struct types sorted by name,
+// e.g. Mail(Person from,Person to,string contents)Person(string name,address wallet)
+func (td *TypedData) EncodeType(primaryType string) (string, error) {
+	if _, ok := td.Types[primaryType]; !ok {
+		return "", fmt.Errorf("type %s is not defined", primaryType)
+	}
+	deps := map[string]bool{}
+	td.dependencies(primaryType, deps)
+	delete(deps, primaryType)
+	sorted := make([]string, 0, len(deps))
+	for dep := range deps {
+		sorted = append(sorted, dep)
+	}
+	sort.Strings(sorted)
+
+	var buf strings.Builder
+	for _, typ := range append([]string{primaryType}, sorted...) {
+		fields := make([]string, 0, len(td.Types[typ]))
+		for _, field := range td.Types[typ] {
+			fields = append(fields, field.Type+" "+field.Name)
+		}
+		buf.WriteString(typ + "(" + strings.Join(fields, ",") + ")")
+	}
+	return buf.String(), nil
+}
+
+func (td *TypedData) dependencies(typ string, found map[string]bool) {
+	typ = baseType(typ)
+	if found[typ] {
+		return
+	}
+	if _, ok := td.Types[typ]; !ok {
+		return
+	}
+	found[typ] = true
+	for _, field := range td.Types[typ] {
+		td.dependencies(field.Type, found)
+	}
+}
+
+// EncodeData returns typeHash ‖ encodeData(field0) ‖ encodeData(field1) ...
+func (td *TypedData) EncodeData(primaryType string, data map[string]interface{}) ([]byte, error) {
+	typeHash, err := td.TypeHash(primaryType)
+	if err != nil {
+		return nil, err
+	}
+	encoded := typeHash
+	for _, field := range td.Types[primaryType] {
+		value, ok := data[field.Name]
+		if !ok {
+			return nil, fmt.Errorf("%s.%s is missing", primaryType, field.Name)
+		}
+		word, err := td.encodeValue(field.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %s", primaryType, field.Name, err)
+		}
+		encoded = append(encoded, word...)
+	}
+	return encoded, nil
+}
+
+func (td *TypedData) encodeValue(typ string, value interface{}) ([]byte, error) {
+	if match := typedDataArrayRegexp.FindStringSubmatch(typ); match != nil {
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s expects an array, got %T", typ, value)
+		}
+		if match[2] != "" {
+			size, _ := strconv.Atoi(match[2])
+			if len(items) != size {
+				return nil, fmt.Errorf("%s expects %d items, got %d", typ, size, len(items))
+			}
+		}
+		var encoded []byte
+		for _, item := range items {
+			word, err := td.encodeValue(match[1], item)
+			if err != nil {
+				return nil, err
+			}
+			encoded = append(encoded, word...)
+		}
+		return crypto.Keccak256(encoded), nil
+	}
+	if _, ok := td.Types[typ]; ok {
+		data, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s expects an object, got %T", typ, value)
+		}
+		return td.HashStruct(typ, data)
+	}
+	return encodeAtomicValue(typ, value)
+}
+
+func encodeAtomicValue(typ string, value interface{}) ([]byte, error) {
+	switch typ {
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("string expects a string, got %T", value)
+		}
+		return crypto.Keccak256([]byte(str)), nil
+	case "bytes":
+		b, err := typedDataBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Keccak256(b), nil
+	case "bool":
+		var b bool
+		switch v := value.(type) {
+		case bool:
+			b = v
+		case string:
+			parsed, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("bool expects true or false, got %s", v)
+			}
+			b = parsed
+		default:
+			return nil, fmt.Errorf("bool expects a boolean, got %T", value)
+		}
+		if b {
+			return utils.AddPrefixZero([]byte{1}, 32), nil
+		}
+		return make([]byte, 32), nil
+	case "address":
+		str, ok := value.(string)
+		if !ok || !common.IsHexAddress(str) {
+			return nil, fmt.Errorf("address expects a hex address, got %v", value)
+		}
+		return common.HexToAddress(str).Hash().Bytes(), nil
+	}
+	if match := typedDataBytesRegexp.FindStringSubmatch(typ); match != nil {
+		size, _ := strconv.Atoi(match[1])
+		if size < 1 || size > 32 {
+			return nil, fmt.Errorf("type %s is illegal", typ)
+		}
+		b, err := typedDataBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) > size {
+			return nil, fmt.Errorf("%s expects at most %d bytes, got %d", typ, size, len(b))
+		}
+		return common.RightPadBytes(b, 32), nil
+	}
+	if match := typedDataIntegerRegexp.FindStringSubmatch(typ); match != nil {
+		bits := 256
+		if match[2] != "" {
+			bits, _ = strconv.Atoi(match[2])
+		}
+		if bits < 8 || bits > 256 || bits%8 != 0 {
+			return nil, fmt.Errorf("type %s is illegal", typ)
+		}
+		i, err := utils.ToBigInt(value)
+		if err != nil {
+			return nil, err
+		}
+		return encodeTypedDataInteger(i, bits, match[1] == "u")
+	}
+	return nil, fmt.Errorf("type %s is not supported", typ)
+}
+
+// encodeTypedDataInteger checks the range of intN/uintN and returns the 32 bytes two's complement
+func encodeTypedDataInteger(i *big.Int, bits int, unsigned bool) ([]byte, error) {
+	var min, max *big.Int
+	if unsigned {
+		min = big.NewInt(0)
+		max = new(big.Int).Lsh(big.NewInt(1), uint(bits))
+	} else {
+		max = new(big.Int).Lsh(big.NewInt(1), uint(bits-1))
+		min = new(big.Int).Neg(max)
+	}
+	if i.Cmp(min) < 0 || i.Cmp(max) >= 0 {
+		return nil, fmt.Errorf("%s is out of range for %d bits", i.String(), bits)
+	}
+	if i.Sign() < 0 {
+		i = new(big.Int).Add(i, new(big.Int).Lsh(big.NewInt(1), 256))
+	}
+	return utils.AddPrefixZero(i.Bytes(), 32), nil
+}
+
+func typedDataBytes(value interface{}) ([]byte, error) {
+	str, ok := value.(string)
+	if !ok || !strings.HasPrefix(str, "0x") || !utils.IsHexStr(str[2:]) || len(str)%2 != 0 {
+		return nil, fmt.Errorf("bytes expects a 0x prefixed hex string, got %v", value)
+	}
+	return utils.HexStrToBytes(str), nil
+}
+
+func baseType(typ string) string {
+	for {
+		match := typedDataArrayRegexp.FindStringSubmatch(typ)
+		if match == nil {
+			return typ
+		}
+		typ = match[1]
+	}
+}
@@ -17,14 +17,27 @@ type Raw struct {
 	Hex string
 }
 
+// BigIntHex is decoded from the node's 0x prefixed hex, it's encoded in decimal for the api server,
+// so both forms are accepted when decoding.
 type BigIntHex big.Int
 
 func (b *BigIntHex) UnmarshalText(text []byte) (err error) {
 	res := utils.HexStrToBigInt(string(text))
+	if !isHexText(text) {
+		var ok bool
+		res, ok = new(big.Int).SetString(string(text), 10)
+		if !ok {
+			return fmt.Errorf("%s is not a legal integer", string(text))
+		}
+	}
 	*b = BigIntHex(*res)
 	return
 }
 
+func isHexText(text []byte) bool {
+	return len(text) >= 2 && text[0] == '0' && (text[1] == 'x' || text[1] == 'X')
+}
+
 func (b BigIntHex) MarshalText() (text []byte, err error){
 	//return []byte(utils.BigIntToHex((*big.Int)(&b))), nil
 	return []byte((*big.Int)(&b).String()), nil
@@ -34,6 +47,12 @@ type IntHex	int
 
 func (i *IntHex) UnmarshalText(text []byte) (err error) {
 	res := int(int64(utils.HexStrToUInt64(string(text))))
+	if !isHexText(text) {
+		res, err = strconv.Atoi(string(text))
+		if err != nil {
+			return err
+		}
+	}
 	*i = IntHex(res)
 	return
 }
@@ -113,12 +132,17 @@ type Config struct {
 	Rinkeby			*NetworkUrl		 `json:"rinkeby"`
 	Ropsten			*NetworkUrl		 `json:"ropsten"`
 	Mainnet			*NetworkUrl		 `json:"mainnet"`
+	Networks		map[string]*NetworkUrl `json:"networks"`
 	ServerUrl		string		 	 `json:"server_url"`
 	Keyfile			string			 `json:"keyfile"`
 	Passphrase		string			 `json:"passphrase"`
 	Address			string			 `json:"address"`
 	EtherscanApiKey	string      	 `json:"etherscan_api_Key"`
 	Erc20List 		[]*Erc20Token 	 `json:"erc20_list"`
+	NonceFile		string			 `json:"nonce_file"`
+	TokenCacheFile	string			 `json:"token_cache_file"`
+	TokenLists		[]string		 `json:"token_lists"`
+	TokenListCacheDir	string		 `json:"token_list_cache_dir"`
 }
 
 func LoadConfigPath() string{
@@ -138,6 +162,27 @@ func LoadConfigPath() string{
 	return path
 }
 
+// GetNetworkUrl returns the urls of network name, networks declared in "networks" take precedence
+// over the legacy mainnet, ropsten and rinkeby fields.
+func (c Config) GetNetworkUrl(name string) (*NetworkUrl, error){
+	if networkUrl, ok := c.Networks[name]; ok && networkUrl != nil {
+		return networkUrl, nil
+	}
+	var networkUrl *NetworkUrl
+	switch name {
+	case EthereumNet.Name:
+		networkUrl = c.Mainnet
+	case RopstenNet.Name:
+		networkUrl = c.Ropsten
+	case RinkebyNet.Name:
+		networkUrl = c.Rinkeby
+	}
+	if networkUrl == nil {
+		return nil, fmt.Errorf("can't find network %s's url in config", name)
+	}
+	return networkUrl, nil
+}
+
 func ImportConfig(path string)(Config, error){
 	var config Config
 	path, err := filepath.Abs(path)
@@ -148,10 +193,42 @@ func ImportConfig(path string)(Config, error){
 	if err != nil {
 		return Config{}, err
 	}
+	// networks need to be registered before "network" can be resolved
+	var declared struct {
+		Networks map[string]*NetworkUrl `json:"networks"`
+	}
+	err = json.Unmarshal(b, &declared)
+	if err != nil {
+		return Config{}, err
+	}
+	for name, networkUrl := range declared.Networks {
+		if networkUrl == nil {
+			continue
+		}
+		if networkUrl.ChainId == nil {
+			if _, err := NewNetwork(name); err != nil {
+				return Config{}, fmt.Errorf("network %s needs chain_id in config", name)
+			}
+			continue
+		}
+		_, err = RegisterNetwork(name, networkUrl.ChainId)
+		if err != nil {
+			return Config{}, err
+		}
+	}
 	err = json.Unmarshal(b, &config)
 	if err != nil {
 		return Config{}, err
 	}
+	if config.NonceFile == "" {
+		config.NonceFile = filepath.Join(filepath.Dir(path), "nonces.json")
+	}
+	if config.TokenCacheFile == "" {
+		config.TokenCacheFile = filepath.Join(filepath.Dir(path), "tokens.json")
+	}
+	if config.TokenListCacheDir == "" {
+		config.TokenListCacheDir = filepath.Join(filepath.Dir(path), "tokenlists")
+	}
 	return config, nil
 }
 
@@ -24,15 +24,15 @@ func EncodeABI(funcSig []byte,  inputs ...[]byte) (res []byte) {
 func DecodeSingle(str string, typ string ) (res interface{}, err error) {
 	b := HexStrToBytes(str)
 	typs := fmt.Sprintf(`[{ "type": "%s" }]`, typ)
-	def := fmt.Sprintf(`[{ "name" : "method", "outputs": %s}]`, typs)
+	def := fmt.Sprintf(`[{ "type": "function", "name" : "method", "outputs": %s}]`, typs)
 	abi, err := abi.JSON(strings.NewReader(def))
 	if err != nil {
-		return "", nil
+		return "", err
 	}
 	outptr := reflect.New(reflect.TypeOf(""));
 	err = abi.Unpack(outptr.Interface(), "method", b)
 	if err != nil {
-		return "", nil
+		return "", err
 	}
 	out := outptr.Elem().Interface()
 	return out, nil
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/crypto"
+	"math/big"
+	"strings"
+)
+
+// eip1191ChainIds are chains whose checksum has the chain id in it as eip1191 describes, rsk mainnet and testnet
+var eip1191ChainIds = map[int64]bool{30: true, 31: true}
+
+// ChecksumAddress returns the eip55 checksummed hex of address, or the eip1191 one on a chain using eip1191
+func ChecksumAddress(address common.Address, chainId *big.Int) string {
+	lower := hex.EncodeToString(address.Bytes())
+	prefix := ""
+	if chainId != nil && eip1191ChainIds[chainId.Int64()] {
+		prefix = chainId.String() + "0x"
+	}
+	hash := crypto.Keccak256([]byte(prefix + lower))
+	result := []byte(lower)
+	for i := range result {
+		nibble := hash[i/2] >> 4
+		if i%2 == 1 {
+			nibble = hash[i/2] & 0xf
+		}
+		if result[i] > '9' && nibble >= 8 {
+			result[i] -= 'a' - 'A'
+		}
+	}
+	return "0x" + string(result)
+}
+
+// ParseAddress parses a 0x prefixed hex address, unlike HexToAddress it rejects a wrong length, non-hex characters
+// and a mixed-case address with a wrong checksum. An all lowercase or uppercase address has no checksum to verify.
+// On a chain using eip1191 both eip1191 and eip55 checksums are accepted since addresses from other tools are eip55.
+func ParseAddress(s string, chainId *big.Int) (common.Address, error) {
+	if s == "" {
+		return common.Address{}, fmt.Errorf("address is empty")
+	}
+	if !strings.HasPrefix(s, "0x") && !strings.HasPrefix(s, "0X") {
+		return common.Address{}, fmt.Errorf("address %s doesn't start with 0x", s)
+	}
+	digits := s[2:]
+	for i, r := range digits {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return common.Address{}, fmt.Errorf("address %s has a non-hex character %q at %d", s, r, i+2)
+		}
+	}
+	if len(digits) != 2*common.AddressLength {
+		return common.Address{}, fmt.Errorf("address %s has %d hex digits, an address has %d", s, len(digits), 2*common.AddressLength)
+	}
+	address := common.HexToAddress(digits)
+	if digits == strings.ToLower(digits) || digits == strings.ToUpper(digits) {
+		return address, nil
+	}
+	if "0x"+digits == ChecksumAddress(address, nil) {
+		return address, nil
+	}
+	if chainId != nil && eip1191ChainIds[chainId.Int64()] {
+		if "0x"+digits == ChecksumAddress(address, chainId) {
+			return address, nil
+		}
+		return common.Address{}, fmt.Errorf("address %s has an invalid checksum, neither eip55 nor eip1191 of chain %s, it may be mistyped", s, chainId.String())
+	}
+	return common.Address{}, fmt.Errorf("address %s has an invalid eip55 checksum, it may be mistyped", s)
+}
@@ -4,12 +4,15 @@ import (
 	"bytes"
 	"encoding/binary"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/ethereum/go-ethereum/common"
 	"math/big"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 )
 const (
 	// number of bits in a big.Word
@@ -26,12 +29,20 @@ func IsHexStr(str string) bool{
 	return true
 }
 
+func TrimHexPrefix(str string) string{
+	if len(str) >= 2 && (str[0:2] == "0x" || str[0:2] == "0X") {
+		return str[2:]
+	}
+	return str
+}
+
 func BytesToHexStr(b []byte) string{
 	s := hex.EncodeToString(b)
 	res := PaddingHex(s)
 	return res
 }
 
+// HexToAddress converts hex without validation, use ParseAddress for addresses from users
 func HexToAddress(hex string) common.Address{
 	return common.HexToAddress(hex)
 }
@@ -52,6 +63,49 @@ func HexStrToBigInt(hexStr string) (res *big.Int){
 }
 
 
+// ToBigInt converts a json decoded number, a decimal string or a 0x prefixed hex string to big.Int
+func ToBigInt(input interface{}) (*big.Int, error) {
+	switch v := input.(type) {
+	case *big.Int:
+		return new(big.Int).Set(v), nil
+	case int:
+		return big.NewInt(int64(v)), nil
+	case int64:
+		return big.NewInt(v), nil
+	case uint64:
+		return new(big.Int).SetUint64(v), nil
+	case float64:
+		i, accuracy := big.NewFloat(v).Int(nil)
+		if accuracy != big.Exact {
+			return nil, fmt.Errorf("%v is not an integer", v)
+		}
+		return i, nil
+	case json.Number:
+		return ToBigInt(string(v))
+	case string:
+		str := strings.TrimSpace(v)
+		var i *big.Int
+		var ok bool
+		switch {
+		case strings.HasPrefix(str, "0x") || strings.HasPrefix(str, "0X"):
+			i, ok = new(big.Int).SetString(str[2:], 16)
+		case strings.HasPrefix(str, "-0x") || strings.HasPrefix(str, "-0X"):
+			i, ok = new(big.Int).SetString(str[3:], 16)
+			if ok {
+				i.Neg(i)
+			}
+		default:
+			i, ok = new(big.Int).SetString(str, 10)
+		}
+		if !ok {
+			return nil, fmt.Errorf("%s is not an integer", v)
+		}
+		return i, nil
+	default:
+		return nil, fmt.Errorf("%v(%T) is not an integer", input, input)
+	}
+}
+
 func HexStrToBytes(hexStr string) []byte {
 	if len(hexStr) == 0 {
 		return []byte{}
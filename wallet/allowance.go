@@ -0,0 +1,44 @@
+package wallet
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/types"
+	"github.com/tn606024/ethwallet/utils"
+	"math/big"
+)
+
+// GetAllowance returns how much of token spender can transfer from wallet's address, in token's smallest unit
+func (ew *EthereumWallet) GetAllowance(token *types.Erc20Token, spender *common.Address) (*big.Int, error) {
+	return ew.getAllowance(token, &ew.Wallet.Key.Address, spender)
+}
+
+func (ew *EthereumWallet) getAllowance(token *types.Erc20Token, owner *common.Address, spender *common.Address) (*big.Int, error) {
+	txr := types.TransactionRequest{
+		To:   token.Address.String(),
+		Data: utils.BytesToHexStr(token.GenerateAllowanceData(owner, spender)),
+	}
+	res, err := ew.conn.Call(txr)
+	if err != nil {
+		return nil, fmt.Errorf("call allowance occured error: %s\n", err)
+	}
+	return utils.HexStrToBigInt(res), nil
+}
+
+// ApproveErc20 lets spender transfer value of token from wallet's address, value is in token's smallest unit
+// and 0 revokes the allowance.
+func (ew *EthereumWallet) ApproveErc20(token *types.Erc20Token, spender *common.Address, value *big.Int, gasPrice *big.Int, maxFee *big.Int, tip *big.Int, gasLimit uint64) (txid string, err error) {
+	return ew.TransferEther(token.Address, big.NewInt(0), token.GenerateApproveData(spender, value), gasPrice, maxFee, tip, gasLimit)
+}
+
+// TransferFromErc20 transfers value of token from from to to with the allowance from gave wallet's address
+func (ew *EthereumWallet) TransferFromErc20(token *types.Erc20Token, from *common.Address, to *common.Address, value *big.Int, gasPrice *big.Int, maxFee *big.Int, tip *big.Int, gasLimit uint64) (txid string, err error) {
+	allowance, err := ew.getAllowance(token, from, &ew.Wallet.Key.Address)
+	if err != nil {
+		return "", err
+	}
+	if allowance.Cmp(value) < 0 {
+		return "", fmt.Errorf("allowance %s %s from %s is less than %s", token.FormatAllowance(allowance), token.Symbol, from.String(), types.NewAmount(value, token.Decimals).String())
+	}
+	return ew.TransferEther(token.Address, big.NewInt(0), token.GenerateTransferFromData(from, to, value), gasPrice, maxFee, tip, gasLimit)
+}
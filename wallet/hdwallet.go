@@ -0,0 +1,225 @@
+package wallet
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/pborman/uuid"
+	"github.com/tn606024/ethwallet/crypto"
+	"github.com/tn606024/ethwallet/types"
+	"github.com/tn606024/ethwallet/utils"
+	"github.com/tyler-smith/go-bip39"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+)
+
+// seedFileSuffix is appended to the keystore path of account 0 to get the path of the encrypted seed
+const seedFileSuffix = ".seed"
+
+var DefaultBaseDerivationPath = accounts.DefaultBaseDerivationPath
+
+type HDWallet struct {
+	Path     string
+	Seed     []byte
+	BasePath accounts.DerivationPath
+	Network  *types.Network
+}
+
+type seedJSON struct {
+	Address string              `json:"address"`
+	Crypto  keystore.CryptoJSON `json:"crypto"`
+	Path    string              `json:"path"`
+	Id      string              `json:"id"`
+	Version int                 `json:"version"`
+}
+
+func NewMnemonic(words int) (string, error) {
+	if words%3 != 0 || words < 12 || words > 24 {
+		return "", fmt.Errorf("mnemonic words must be one of 12, 15, 18, 21, 24, got %d", words)
+	}
+	entropy, err := bip39.NewEntropy(words / 3 * 32)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+func NewHDWallet(mnemonic, passphrase string, config types.Config) (*HDWallet, error) {
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("mnemonic is invalid: %s", err)
+	}
+	return &HDWallet{
+		Seed:     seed,
+		BasePath: DefaultBaseDerivationPath,
+		Network:  config.Network,
+	}, nil
+}
+
+// CreateHDWallet stores account 0 as a normal keystore file at keyfilepath and
+// the encrypted seed next to it, so the keyfile can still be used by every wallet command.
+func CreateHDWallet(mnemonic, passphrase, auth, keyfilepath string, config types.Config) (*HDWallet, *Wallet, error) {
+	hd, err := NewHDWallet(mnemonic, passphrase, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	wallet, err := hd.Derive(0)
+	if err != nil {
+		return nil, nil, err
+	}
+	path, err := StoreKey(keyfilepath, wallet.Key, auth)
+	if err != nil {
+		return nil, nil, err
+	}
+	wallet.Path = path
+	hd.Path = SeedPath(path)
+	err = StoreSeed(hd.Path, hd.Seed, hd.BasePath, wallet.Key.Address.String(), auth)
+	if err != nil {
+		os.Remove(path)
+		return nil, nil, err
+	}
+	return hd, wallet, nil
+}
+
+func ImportHDWallet(auth, keyfilepath string, config types.Config) (*HDWallet, error) {
+	path := SeedPath(keyfilepath)
+	seed, basePath, err := GetSeed(path, auth)
+	if err != nil {
+		return nil, err
+	}
+	return &HDWallet{
+		Path:     path,
+		Seed:     seed,
+		BasePath: basePath,
+		Network:  config.Network,
+	}, nil
+}
+
+// Derive returns the wallet of account index, the index replaces the last component of BasePath
+func (hd *HDWallet) Derive(index uint32) (*Wallet, error) {
+	path := make(accounts.DerivationPath, len(hd.BasePath))
+	copy(path, hd.BasePath)
+	path[len(path)-1] = index
+	return hd.DerivePath(path)
+}
+
+func (hd *HDWallet) DerivePath(path accounts.DerivationPath) (*Wallet, error) {
+	key, chainCode := masterKey(hd.Seed)
+	var err error
+	for _, index := range path {
+		key, chainCode, err = childKey(key, chainCode, index)
+		if err != nil {
+			return nil, fmt.Errorf("derive %s occured error: %s", path.String(), err)
+		}
+	}
+	privateKeyECDSA, err := crypto.ToECDSA(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Wallet{
+		Path:    "",
+		Key:     NewKeyFromECDSA(privateKeyECDSA),
+		Network: hd.Network,
+	}, nil
+}
+
+func masterKey(seed []byte) (key []byte, chainCode []byte) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	return sum[:32], sum[32:]
+}
+
+// childKey implements BIP-32 private parent key to private child key derivation
+func childKey(key []byte, chainCode []byte, index uint32) ([]byte, []byte, error) {
+	var data []byte
+	if index >= 0x80000000 {
+		data = append([]byte{0x00}, key...)
+	} else {
+		privateKeyECDSA, err := crypto.ToECDSA(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		data = crypto.CompressPubkey(&privateKeyECDSA.PublicKey)
+	}
+	indexBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(indexBytes, index)
+	data = append(data, indexBytes...)
+
+	mac := hmac.New(sha512.New, chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	n := crypto.S256().Params().N
+	il := new(big.Int).SetBytes(sum[:32])
+	if il.Cmp(n) >= 0 {
+		return nil, nil, fmt.Errorf("invalid child key at index %d", index)
+	}
+	child := il.Add(il, new(big.Int).SetBytes(key))
+	child.Mod(child, n)
+	if child.Sign() == 0 {
+		return nil, nil, fmt.Errorf("invalid child key at index %d", index)
+	}
+	return utils.AddPrefixZero(child.Bytes(), 32), sum[32:], nil
+}
+
+func SeedPath(keyfilepath string) string {
+	return keyfilepath + seedFileSuffix
+}
+
+func StoreSeed(path string, seed []byte, basePath accounts.DerivationPath, address string, auth string) error {
+	cryptoJson, err := crypto.EncryptData(seed, auth, keystore.StandardScryptN, keystore.StandardScryptP)
+	if err != nil {
+		return fmt.Errorf("encryptData error: %v\n", err)
+	}
+	seedjson, err := json.Marshal(seedJSON{
+		Address: address,
+		Crypto:  cryptoJson,
+		Path:    basePath.String(),
+		Id:      uuid.NewRandom().String(),
+		Version: 3,
+	})
+	if err != nil {
+		return err
+	}
+	path, _ = filepath.Abs(path)
+	if utils.FileExists(path) {
+		return fmt.Errorf("this path already exist file")
+	}
+	err = ioutil.WriteFile(path, seedjson, 0600)
+	if err != nil {
+		return fmt.Errorf("write file error: %v", err)
+	}
+	return nil
+}
+
+func GetSeed(path, auth string) ([]byte, accounts.DerivationPath, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read file error: %v, path: %s\n", err, path)
+	}
+	var sj seedJSON
+	err = json.Unmarshal(b, &sj)
+	if err != nil {
+		return nil, nil, fmt.Errorf("seed file unmarshal error: %v\n", err)
+	}
+	seed, err := crypto.DecryptData(sj.Crypto, auth)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decryptData error: %v\n", err)
+	}
+	basePath, err := accounts.ParseDerivationPath(sj.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return seed, basePath, nil
+}
@@ -0,0 +1,76 @@
+package wallet
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/types"
+	"math/big"
+)
+
+// GetNfts lists erc721 and erc1155 tokens wallet's address owns, found from transfer logs since fromBlock
+func (ew *EthereumWallet) GetNfts(fromBlock uint64) ([]types.Nft, error) {
+	nfts, err := ew.conn.GetNfts(ew.Wallet.Key.Address, fromBlock)
+	if err != nil {
+		return nil, err
+	}
+	return nfts, nil
+}
+
+// GetNft returns tokenId of contract with the balance wallet's address has
+func (ew *EthereumWallet) GetNft(contract *common.Address, tokenId *big.Int) (*types.Nft, error) {
+	nft, err := ew.conn.GetNft(*contract, tokenId, ew.Wallet.Key.Address)
+	if err != nil {
+		return nil, err
+	}
+	return nft, nil
+}
+
+// SendNft transfers tokenIds of contract to to with safeTransferFrom, amounts are only used by erc1155 and
+// several token ids of erc1155 are sent in one safeBatchTransferFrom. Ownership is checked before sending.
+func (ew *EthereumWallet) SendNft(contract *common.Address, to *common.Address, tokenIds []*big.Int, amounts []*big.Int, gasPrice *big.Int, maxFee *big.Int, tip *big.Int, gasLimit uint64) (txid string, err error) {
+	if len(tokenIds) == 0 {
+		return "", fmt.Errorf("no token id to send")
+	}
+	from := ew.Wallet.Key.Address
+	nfts := make([]*types.Nft, len(tokenIds))
+	for i, tokenId := range tokenIds {
+		nfts[i], err = ew.GetNft(contract, tokenId)
+		if err != nil {
+			return "", err
+		}
+	}
+	var data []byte
+	switch nfts[0].Standard {
+	case types.Erc721:
+		if len(tokenIds) != 1 {
+			return "", fmt.Errorf("erc721 sends one token id at a time, got %d", len(tokenIds))
+		}
+		if owner := nfts[0].Owner; owner != nil && *owner != from {
+			return "", fmt.Errorf("token %s of %s is owned by %s, not %s", tokenIds[0].String(), contract.String(), owner.String(), from.String())
+		}
+		data = types.GenerateErc721SafeTransferFromData(&from, to, tokenIds[0])
+	case types.Erc1155:
+		if amounts == nil {
+			for range tokenIds {
+				amounts = append(amounts, big.NewInt(1))
+			}
+		}
+		if len(amounts) != len(tokenIds) {
+			return "", fmt.Errorf("%d token ids and %d amounts don't match", len(tokenIds), len(amounts))
+		}
+		for i, nft := range nfts {
+			if balance := (*big.Int)(nft.Balance); balance.Cmp(amounts[i]) < 0 {
+				return "", fmt.Errorf("balance %s of token %s of %s is less than %s", balance.String(), tokenIds[i].String(), contract.String(), amounts[i].String())
+			}
+		}
+		if len(tokenIds) == 1 {
+			data, err = types.GenerateErc1155SafeTransferFromData(&from, to, tokenIds[0], amounts[0], []byte{})
+		} else {
+			data, err = types.GenerateErc1155SafeBatchTransferFromData(&from, to, tokenIds, amounts, []byte{})
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return ew.TransferEther(contract, big.NewInt(0), data, gasPrice, maxFee, tip, gasLimit)
+}
@@ -0,0 +1,226 @@
+package wallet
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// nonceExpiry is how long a handed out nonce is kept, if node's pending count still doesn't reach it
+// after that, the transaction is regarded as dropped and the nonce becomes a gap.
+var nonceExpiry = 10 * time.Minute
+
+// nonceLockTimeout is how long to wait for another process holding the nonce file lock, a lock file older than
+// nonceLockStale is left by a killed process and removed.
+var (
+	nonceLockTimeout = 10 * time.Second
+	nonceLockStale   = 30 * time.Second
+)
+
+// NonceManager hands out nonces for local sends, so back-to-back or concurrent sends from one address
+// don't reuse a nonce before node counts it. Handed out nonces are saved in a json file keyed by chain id
+// and address, nonces below node's pending count are forgotten and a missing nonce in between is filled first.
+type NonceManager struct {
+	path     string
+	mux      sync.Mutex
+	accounts map[string]*nonceAccount
+}
+
+type nonceAccount struct {
+	Next     uint64           `json:"next"`
+	Reserved map[uint64]int64 `json:"reserved"`
+}
+
+var (
+	nonceManagers   = map[string]*NonceManager{}
+	nonceManagersMu sync.Mutex
+)
+
+// NewNonceManager returns the manager of path, managers of the same path are shared in a process.
+// An empty path keeps nonces in memory only.
+func NewNonceManager(path string) *NonceManager {
+	if path != "" {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+	}
+	nonceManagersMu.Lock()
+	defer nonceManagersMu.Unlock()
+	if m, ok := nonceManagers[path]; ok {
+		return m
+	}
+	m := &NonceManager{
+		path:     path,
+		accounts: map[string]*nonceAccount{},
+	}
+	nonceManagers[path] = m
+	return m
+}
+
+func nonceKey(chainId *big.Int, address common.Address) string {
+	return chainId.String() + ":" + strings.ToLower(address.String())
+}
+
+// Acquire returns the nonce address should use next, pending is node's transaction count at pending block
+func (m *NonceManager) Acquire(chainId *big.Int, address common.Address, pending uint64) (uint64, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	unlock, err := m.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	if err := m.load(); err != nil {
+		return 0, err
+	}
+	account := m.account(chainId, address)
+	now := time.Now()
+	for n, t := range account.Reserved {
+		if n < pending || now.Sub(time.Unix(t, 0)) > nonceExpiry {
+			delete(account.Reserved, n)
+		}
+	}
+	if account.Next < pending {
+		account.Next = pending
+	}
+	nonce := account.Next
+	for n := pending; n < account.Next; n++ {
+		if _, ok := account.Reserved[n]; !ok {
+			nonce = n
+			break
+		}
+	}
+	account.Reserved[nonce] = now.Unix()
+	if nonce == account.Next {
+		account.Next++
+	}
+	return nonce, m.save()
+}
+
+// Release gives back a nonce whose transaction was not sent, it will be handed out again
+func (m *NonceManager) Release(chainId *big.Int, address common.Address, nonce uint64) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := m.load(); err != nil {
+		return err
+	}
+	account := m.account(chainId, address)
+	delete(account.Reserved, nonce)
+	if nonce+1 == account.Next {
+		account.Next--
+	}
+	return m.save()
+}
+
+// Reserve marks nonce as handed out again, e.g. of a signed transaction which is sent again, so Acquire skips it
+func (m *NonceManager) Reserve(chainId *big.Int, address common.Address, nonce uint64) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := m.load(); err != nil {
+		return err
+	}
+	account := m.account(chainId, address)
+	account.Reserved[nonce] = time.Now().Unix()
+	if account.Next <= nonce {
+		account.Next = nonce + 1
+	}
+	return m.save()
+}
+
+func (m *NonceManager) account(chainId *big.Int, address common.Address) *nonceAccount {
+	key := nonceKey(chainId, address)
+	account, ok := m.accounts[key]
+	if !ok || account == nil {
+		account = &nonceAccount{}
+		m.accounts[key] = account
+	}
+	if account.Reserved == nil {
+		account.Reserved = map[uint64]int64{}
+	}
+	return account
+}
+
+// lock creates the lock file exclusively, so load, change and save of processes sharing the file don't interleave
+func (m *NonceManager) lock() (func(), error) {
+	if m.path == "" {
+		return func() {}, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0700); err != nil {
+		return nil, fmt.Errorf("create nonce file dir error: %s", err)
+	}
+	path := m.path + ".lock"
+	deadline := time.Now().Add(nonceLockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("lock nonce file error: %s", err)
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > nonceLockStale {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("nonce file %s is locked by another process, remove %s if no send is running", m.path, path)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// load reads the file again under the lock, so sends of another process in between are seen
+func (m *NonceManager) load() error {
+	if m.path == "" {
+		return nil
+	}
+	b, err := ioutil.ReadFile(m.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read nonce file error: %s", err)
+	}
+	accounts := map[string]*nonceAccount{}
+	if err := json.Unmarshal(b, &accounts); err != nil {
+		return fmt.Errorf("nonce file %s is broken: %s", m.path, err)
+	}
+	m.accounts = accounts
+	return nil
+}
+
+func (m *NonceManager) save() error {
+	if m.path == "" {
+		return nil
+	}
+	b, err := json.MarshalIndent(m.accounts, "", "	")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0700); err != nil {
+		return fmt.Errorf("create nonce file dir error: %s", err)
+	}
+	tmp := m.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return fmt.Errorf("write nonce file error: %s", err)
+	}
+	return os.Rename(tmp, m.path)
+}
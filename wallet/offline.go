@@ -0,0 +1,70 @@
+package wallet
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/types"
+	"github.com/tn606024/ethwallet/utils"
+	"math/big"
+)
+
+// PrepareTransaction fills nonce, fees and gas limit of an ether transfer from node without signing it,
+// token is not nil for an erc20 transfer and value is in token's smallest unit then.
+func (ew *EthereumWallet) PrepareTransaction(to *common.Address, value *big.Int, token *types.Erc20Token, gasPrice *big.Int, maxFee *big.Int, tip *big.Int, gasLimit uint64) (*types.OfflineTx, error) {
+	var tx *types.Transaction
+	var err error
+	if token != nil {
+		tx, err = ew.createErc20Transation(token, value, to, gasPrice, maxFee, tip, gasLimit)
+	} else {
+		tx, err = ew.createNormalTransaction(to, value, []byte{}, gasPrice, maxFee, tip, gasLimit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ether, err := ew.GetBalance()
+	if err != nil {
+		ew.releaseNonce(tx)
+		return nil, fmt.Errorf("get balance occured error: %s\n", err)
+	}
+	if err := checkValueEnough(tx.Value, tx.FeeCap(), tx.GasLimit, ether); err != nil {
+		ew.releaseNonce(tx)
+		return nil, err
+	}
+	return types.NewOfflineTx(ew.Wallet.Network, tx, token), nil
+}
+
+// SignOfflineTx signs the prepared transaction, it must be prepared for wallet's address and network
+func (w *Wallet) SignOfflineTx(o *types.OfflineTx) error {
+	if o.ChainId.Cmp(w.Network.ChainId) != 0 {
+		return fmt.Errorf("transaction is prepared for chain id %s, but wallet's network %s has chain id %s", o.ChainId.String(), w.Network.Name, w.Network.ChainId.String())
+	}
+	if *o.Transaction.From != w.Key.Address {
+		return fmt.Errorf("transaction is prepared for %s, not keyfile's address %s", o.Transaction.From.String(), w.Key.Address.String())
+	}
+	raw, err := w.SignTxToRawTx(o.Transaction)
+	if err != nil {
+		return err
+	}
+	o.Raw = raw
+	o.Hash = utils.BytesToHexStr(o.Transaction.Hash())
+	return nil
+}
+
+// BroadcastOfflineTx verifies the signed raw matches the prepared transaction and its nonce is not used yet, then sends it
+func (ew *EthereumWallet) BroadcastOfflineTx(o *types.OfflineTx) (string, error) {
+	if o.ChainId.Cmp(ew.Wallet.Network.ChainId) != 0 {
+		return "", fmt.Errorf("transaction is signed for chain id %s, but network %s has chain id %s", o.ChainId.String(), ew.Wallet.Network.Name, ew.Wallet.Network.ChainId.String())
+	}
+	signed, err := o.Verify()
+	if err != nil {
+		return "", fmt.Errorf("verify offline tx occured error: %s", err)
+	}
+	nonce, err := ew.conn.GetNonce(*signed.From, types.Latest)
+	if err != nil {
+		return "", fmt.Errorf("GetNonce occured error: %s\n", err)
+	}
+	if nonce > signed.Nonce {
+		return "", fmt.Errorf("nonce %d is already used, %s has sent %d transactions", signed.Nonce, signed.From.String(), nonce)
+	}
+	return ew.SendRawTransaction(o.Raw)
+}
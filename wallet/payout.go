@@ -0,0 +1,249 @@
+package wallet
+
+import (
+	"fmt"
+	"github.com/tn606024/ethwallet/types"
+	"github.com/tn606024/ethwallet/utils"
+	"math/big"
+	"sort"
+	"sync"
+)
+
+// SendPayouts sends payouts which are not sent yet, save is called whenever results change so a run can be resumed.
+// Payouts of a previous run are sorted out by resumePayouts first, payouts to sign are checked against balances,
+// get sequential nonces and are all signed before any is sent.
+func (ew *EthereumWallet) SendPayouts(payouts []*types.Payout, concurrency int, gasPrice *big.Int, maxFee *big.Int, tip *big.Int, save func() error) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	resend, todo, keep, err := ew.resumePayouts(payouts)
+	if err != nil {
+		return err
+	}
+	if err := save(); err != nil {
+		return err
+	}
+	// nonces of a previous run may have expired in the nonce manager, they are reserved again
+	// so a payout signed below doesn't get one of them
+	var txs []*types.Transaction
+	release := func() {
+		for i := len(txs) - 1; i >= 0; i-- {
+			ew.releaseNonce(txs[i])
+		}
+	}
+	for _, p := range payouts {
+		if p.Status != types.PayoutSigned && !keep[p] {
+			continue
+		}
+		if err := ew.reserveNonce(p.Nonce); err != nil {
+			release()
+			return fmt.Errorf("reserve nonce occured error: %s\n", err)
+		}
+		txs = append(txs, &types.Transaction{Nonce: p.Nonce})
+	}
+	if err := ew.checkTokenBalances(todo); err != nil {
+		release()
+		return err
+	}
+	created, err := ew.createPayoutTransactions(todo, keep, gasPrice, maxFee, tip)
+	if err != nil {
+		release()
+		return err
+	}
+	for i, p := range todo {
+		if !keep[p] {
+			txs = append(txs, created[i])
+		}
+	}
+	err = ew.signPayouts(todo, created)
+	if err == nil {
+		err = save()
+	}
+	if err != nil {
+		release()
+		return err
+	}
+
+	jobs := append(resend, todo...)
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Nonce < jobs[j].Nonce })
+	var wg sync.WaitGroup
+	var mux sync.Mutex
+	var saveErr error
+	sem := make(chan struct{}, concurrency)
+	for _, p := range jobs {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(p *types.Payout) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			_, err := ew.conn.SendRawTransaction(p.Raw)
+			if err != nil {
+				// the node may have got it before the error, e.g. a timeout
+				if tx, e := ew.conn.GetTransaction(p.Txid); e == nil && tx.Hash != "" {
+					err = nil
+				}
+			}
+			mux.Lock()
+			defer mux.Unlock()
+			if err != nil {
+				p.Status, p.Error = types.PayoutFailed, err.Error()
+				ew.releaseNonce(&types.Transaction{Nonce: p.Nonce})
+			} else {
+				p.Status, p.Error = types.PayoutSent, ""
+			}
+			if err := save(); err != nil && saveErr == nil {
+				saveErr = err
+			}
+		}(p)
+	}
+	wg.Wait()
+	return saveErr
+}
+
+// resumePayouts sorts out payouts of a previous run by their transactions on node. A payout whose transaction
+// is mined is sent, a signed one or one whose transaction is still pending is sent again as the same raw transaction.
+// A failed one is signed again at its old nonce, so at most one of both transactions is mined, or at a new nonce
+// only after the old nonce is used by a mined transaction and the old transaction can't be mined anymore.
+// keep marks payouts to sign at their old nonces.
+func (ew *EthereumWallet) resumePayouts(payouts []*types.Payout) (resend []*types.Payout, todo []*types.Payout, keep map[*types.Payout]bool, err error) {
+	keep = map[*types.Payout]bool{}
+	var latest, pending uint64
+	var nonceRead bool
+	for _, p := range payouts {
+		if p.Status == types.PayoutSent {
+			continue
+		}
+		if p.Txid == "" {
+			todo = append(todo, p)
+			continue
+		}
+		tx, err := ew.conn.GetTransaction(p.Txid)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("GetTransaction occured error: %s\n", err)
+		}
+		switch {
+		case tx.BlockHash != "":
+			p.Status, p.Error = types.PayoutSent, ""
+			continue
+		case tx.Hash != "" || p.Status == types.PayoutSigned:
+			p.Status = types.PayoutSigned
+			resend = append(resend, p)
+			continue
+		}
+		if !nonceRead {
+			latest, err = ew.conn.GetNonce(ew.Wallet.Key.Address, types.Latest)
+			if err == nil {
+				pending, err = ew.conn.GetNonce(ew.Wallet.Key.Address, types.Pending)
+			}
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("GetNonce occured error: %s\n", err)
+			}
+			nonceRead = true
+		}
+		switch {
+		case p.Nonce < latest:
+			todo = append(todo, p)
+		case p.Nonce < pending:
+			p.Error = fmt.Sprintf("nonce %d is used by another pending transaction, run again after it's mined", p.Nonce)
+		default:
+			keep[p] = true
+			todo = append(todo, p)
+		}
+	}
+	return resend, todo, keep, nil
+}
+
+// checkTokenBalances compares the total of every token in payouts with the balance in its smallest unit
+func (ew *EthereumWallet) checkTokenBalances(payouts []*types.Payout) error {
+	totals := map[*types.Erc20Token]*big.Int{}
+	for _, p := range payouts {
+		if p.Token == nil {
+			continue
+		}
+		if totals[p.Token] == nil {
+			totals[p.Token] = big.NewInt(0)
+		}
+		totals[p.Token].Add(totals[p.Token], p.Value)
+	}
+	for token, total := range totals {
+		data := utils.EncodeABI(types.Erc20FunctionInterface.BalanceOf.MethodId, ew.Wallet.Key.Address.Bytes())
+		res, err := ew.conn.Call(types.TransactionRequest{To: token.Address.String(), Data: utils.BytesToHexStr(data)})
+		if err != nil {
+			return fmt.Errorf("get %s balance occured error: %s\n", token.Symbol, err)
+		}
+		if balance := utils.HexStrToBigInt(res); balance.Cmp(total) < 0 {
+			return fmt.Errorf("%s balance %s is less than total %s of payouts", token.Symbol, types.NewAmount(balance, token.Decimals).String(), types.NewAmount(total, token.Decimals).String())
+		}
+	}
+	return nil
+}
+
+// createPayoutTransactions creates transactions in order so their nonces are sequential, a payout in keep is
+// created at its old nonce. Acquired nonces are released if any transaction can't be created or ethers can't
+// pay the total value and fees.
+func (ew *EthereumWallet) createPayoutTransactions(payouts []*types.Payout, keep map[*types.Payout]bool, gasPrice *big.Int, maxFee *big.Int, tip *big.Int) ([]*types.Transaction, error) {
+	txs := make([]*types.Transaction, 0, len(payouts))
+	release := func() {
+		for i := len(txs) - 1; i >= 0; i-- {
+			if !keep[payouts[i]] {
+				ew.releaseNonce(txs[i])
+			}
+		}
+	}
+	total := big.NewInt(0)
+	for _, p := range payouts {
+		to, value, data := &p.Recipient, p.Value, []byte{}
+		if p.Token != nil {
+			to, value, data = p.Token.Address, big.NewInt(0), p.Token.GenerateTransferData(p.Value, &p.Recipient)
+		}
+		var tx *types.Transaction
+		var err error
+		if keep[p] {
+			tx, _, err = ew.buildTransaction(to, value, data, gasPrice, maxFee, tip, 0)
+			if err == nil {
+				tx.Nonce = p.Nonce
+			}
+		} else {
+			tx, err = ew.createNormalTransaction(to, value, data, gasPrice, maxFee, tip, 0)
+		}
+		if err != nil {
+			release()
+			return nil, fmt.Errorf("create transaction of line %d occured error: %s\n", p.Line, err)
+		}
+		txs = append(txs, tx)
+		total.Add(total, tx.Value)
+		total.Add(total, new(big.Int).Mul(tx.FeeCap(), new(big.Int).SetUint64(tx.GasLimit)))
+	}
+	ether, err := ew.GetBalance()
+	if err != nil {
+		release()
+		return nil, fmt.Errorf("get balance occured error: %s\n", err)
+	}
+	if total.Cmp(ether) > 0 {
+		release()
+		return nil, fmt.Errorf("ether balance %s is less than total %s ether of payouts and fees", types.EtherString(ether), types.EtherString(total))
+	}
+	return txs, nil
+}
+
+// signPayouts signs all transactions first and then marks payouts as signed, so the results are saved
+// with every txid before anything is sent.
+func (ew *EthereumWallet) signPayouts(payouts []*types.Payout, txs []*types.Transaction) error {
+	raws := make([]string, len(txs))
+	for i, tx := range txs {
+		raw, err := ew.Wallet.SignTxToRawTx(tx)
+		if err != nil {
+			return fmt.Errorf("SignTxToRawTx occured error:%s \n", err)
+		}
+		raws[i] = raw
+	}
+	for i, p := range payouts {
+		p.Nonce = txs[i].Nonce
+		p.Txid = utils.BytesToHexStr(txs[i].Hash())
+		p.Raw = raws[i]
+		p.Status, p.Error = types.PayoutSigned, ""
+	}
+	return nil
+}
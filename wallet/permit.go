@@ -0,0 +1,36 @@
+package wallet
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/types"
+	"github.com/tn606024/ethwallet/utils"
+	"math/big"
+)
+
+// SignPermit signs an eip2612 permit letting spender transfer value of the token of domain until deadline,
+// domain must be got for the wallet's address so its nonce is the wallet's.
+func (w *Wallet) SignPermit(domain *types.PermitDomain, spender common.Address, value *big.Int, deadline *big.Int) (*types.SignedPermit, error) {
+	if domain.Owner != w.Key.Address {
+		return nil, fmt.Errorf("permit domain is of owner %s, not wallet's address %s", domain.Owner.String(), w.Key.Address.String())
+	}
+	permit := domain.NewPermit(spender, value, deadline)
+	typedData, err := domain.TypedData(permit)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := w.SignTypedData(typedData)
+	if err != nil {
+		return nil, fmt.Errorf("SignTypedData occured error: %s\n", err)
+	}
+	return types.NewSignedPermit(domain, permit, utils.HexStrToBytes(signature))
+}
+
+// SignPermit reads token's permit domain and wallet's nonce from node and signs the permit
+func (ew *EthereumWallet) SignPermit(token *types.Erc20Token, spender common.Address, value *big.Int, deadline *big.Int) (*types.SignedPermit, error) {
+	domain, err := ew.conn.GetPermitDomain(*token.Address, ew.Wallet.Key.Address)
+	if err != nil {
+		return nil, fmt.Errorf("GetPermitDomain occured error: %s\n", err)
+	}
+	return ew.Wallet.SignPermit(domain, spender, value, deadline)
+}
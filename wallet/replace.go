@@ -0,0 +1,135 @@
+package wallet
+
+import (
+	"fmt"
+	"github.com/tn606024/ethwallet/types"
+	"github.com/tn606024/ethwallet/utils"
+	"math/big"
+	"strings"
+)
+
+// replacementBump is the minimum fee increase in percent, nodes(geth's default price bump) reject
+// a replacement of a pending transaction which doesn't raise fees by it.
+const replacementBump = 10
+
+const cancelGasLimit = 21000
+
+// SpeedUpTransaction sends txid again with the same nonce and bumped fees
+func (ew *EthereumWallet) SpeedUpTransaction(txid string, gasPrice *big.Int, maxFee *big.Int, tip *big.Int) (string, *types.Transaction, error) {
+	return ew.replaceTransaction(txid, false, gasPrice, maxFee, tip)
+}
+
+// CancelTransaction replaces txid with a 0 ether transfer to self which has the same nonce and bumped fees
+func (ew *EthereumWallet) CancelTransaction(txid string, gasPrice *big.Int, maxFee *big.Int, tip *big.Int) (string, *types.Transaction, error) {
+	return ew.replaceTransaction(txid, true, gasPrice, maxFee, tip)
+}
+
+// replaceTransaction keeps the type of the original transaction, fees are at least 10% higher than the original
+// ones and not lower than node's current suggestion. Given fees which don't meet the bump are rejected.
+func (ew *EthereumWallet) replaceTransaction(txid string, cancel bool, gasPrice *big.Int, maxFee *big.Int, tip *big.Int) (string, *types.Transaction, error) {
+	orig, err := ew.conn.GetTransaction(txid)
+	if err != nil {
+		return "", nil, fmt.Errorf("GetTransaction occured error: %s\n", err)
+	}
+	if orig.Hash == "" {
+		return "", nil, fmt.Errorf("transaction %s is not found, it may be dropped from node's pool", txid)
+	}
+	if orig.BlockHash != "" {
+		return "", nil, fmt.Errorf("transaction %s is already mined in block %d", txid, orig.BlockNumber)
+	}
+	if !strings.EqualFold(orig.From, ew.Wallet.Key.Address.String()) {
+		return "", nil, fmt.Errorf("transaction %s is sent from %s, not your address", txid, orig.From)
+	}
+	tx := &types.Transaction{
+		Type:     uint8(orig.Type),
+		Nonce:    uint64(orig.Nonce),
+		From:     &ew.Wallet.Key.Address,
+		GasLimit: uint64(orig.Gas),
+	}
+	if cancel {
+		tx.To = &ew.Wallet.Key.Address
+		tx.Value = big.NewInt(0)
+		tx.Data = []byte{}
+		tx.GasLimit = cancelGasLimit
+		if tx.Type == types.AccessListTxType {
+			tx.Type = types.LegacyTxType
+		}
+	} else {
+		if orig.To != "" {
+			to := utils.HexToAddress(orig.To)
+			tx.To = &to
+		}
+		tx.Value = (*big.Int)(&orig.Value)
+		tx.Data = utils.HexStrToBytes(orig.Input)
+		tx.AccessList = orig.AccessList
+	}
+	params, err := ew.conn.GetTransactionParams(types.TransactionRequest{From: tx.From.String()}, types.Pending, false)
+	if err != nil {
+		return "", nil, fmt.Errorf("GetTransactionParams occured error:%s \n", err)
+	}
+	if tx.Type == types.DynamicFeeTxType {
+		if orig.MaxFeePerGas == nil || orig.MaxPriorityFeePerGas == nil {
+			return "", nil, fmt.Errorf("transaction %s has no eip1559 fees", txid)
+		}
+		oldTip := (*big.Int)(orig.MaxPriorityFeePerGas)
+		oldMaxFee := (*big.Int)(orig.MaxFeePerGas)
+		tip, err = bumpFee("tip", tip, oldTip, params.MaxPriorityFee)
+		if err != nil {
+			return "", nil, err
+		}
+		var suggestedMaxFee *big.Int
+		if params.BaseFee != nil {
+			suggestedMaxFee = new(big.Int).Mul(params.BaseFee, big.NewInt(2))
+			suggestedMaxFee.Add(suggestedMaxFee, tip)
+		}
+		maxFee, err = bumpFee("maxfee", maxFee, oldMaxFee, suggestedMaxFee)
+		if err != nil {
+			return "", nil, err
+		}
+		if tip.Cmp(maxFee) > 0 {
+			return "", nil, fmt.Errorf("tip %s is bigger than maxfee %s", tip.String(), maxFee.String())
+		}
+		tx.MaxFeePerGas = maxFee
+		tx.MaxPriorityFeePerGas = tip
+	} else {
+		gasPrice, err = bumpFee("gasprice", gasPrice, (*big.Int)(&orig.GasPrice), params.GasPrice)
+		if err != nil {
+			return "", nil, err
+		}
+		tx.GasPrice = gasPrice
+	}
+	ether, err := ew.GetBalance()
+	if err != nil {
+		return "", nil, fmt.Errorf("get balance occured error: %s\n", err)
+	}
+	if err := checkValueEnough(tx.Value, tx.FeeCap(), tx.GasLimit, ether); err != nil {
+		return "", nil, err
+	}
+	rawTx, err := ew.Wallet.SignTxToRawTx(tx)
+	if err != nil {
+		return "", nil, err
+	}
+	newTxid, err := ew.SendRawTransaction(rawTx)
+	if err != nil {
+		return "", nil, err
+	}
+	return newTxid, tx, nil
+}
+
+// bumpFee returns given fee if it's at least the minimum replacement fee, otherwise the bigger one
+// of minimum replacement fee and suggested fee
+func bumpFee(name string, given *big.Int, old *big.Int, suggested *big.Int) (*big.Int, error) {
+	min := new(big.Int).Mul(old, big.NewInt(100+replacementBump))
+	min.Add(min, big.NewInt(99))
+	min.Div(min, big.NewInt(100))
+	if !isZero(given) {
+		if given.Cmp(min) < 0 {
+			return nil, fmt.Errorf("%s %s is too low to replace the transaction, it needs at least %s", name, given.String(), min.String())
+		}
+		return given, nil
+	}
+	if suggested != nil && suggested.Cmp(min) > 0 {
+		return new(big.Int).Set(suggested), nil
+	}
+	return min, nil
+}
@@ -3,11 +3,13 @@ package wallet
 import (
 	"fmt"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/tn606024/ethwallet/abi"
 	"github.com/tn606024/ethwallet/conn"
 	"github.com/tn606024/ethwallet/crypto"
 	"github.com/tn606024/ethwallet/types"
 	"github.com/tn606024/ethwallet/utils"
 	"math/big"
+	"time"
 )
 
 type Wallet struct {
@@ -44,12 +46,18 @@ func ImportWallet(auth, path string, config types.Config) (wallet *Wallet, err e
 }
 
 func (w *Wallet) SignTx(tx *types.Transaction) error {
+	tx.ChainId = new(big.Int).Set(w.Network.ChainId)
 	signhash := tx.ToSignHash(w.Network)
 	sig, err := crypto.Sign(signhash, w.Key.PrivateKey)
 	if err != nil {
 		return err
 	}
-	v,r,s := deriveSignature(sig, w.Network)
+	var v, r, s *big.Int
+	if tx.Type == types.LegacyTxType {
+		v, r, s = deriveSignature(sig, w.Network)
+	} else {
+		v, r, s = deriveTypedSignature(sig)
+	}
 	tx.V = v
 	tx.R = r
 	tx.S = s
@@ -72,20 +80,64 @@ func signMessageHash(data []byte) []byte{
 func deriveSignature(sig []byte, network *types.Network) (v, r, s *big.Int){
 	r = new(big.Int).SetBytes(sig[:32])
 	s = new(big.Int).SetBytes(sig[32:64])
-	v = new(big.Int).SetBytes([]byte{sig[64] + 35 + network.ChainId*2})
+	v = new(big.Int).Mul(network.ChainId, big.NewInt(2))
+	v.Add(v, big.NewInt(int64(sig[64]) + 35))
+	return
+}
+
+// deriveTypedSignature returns the y parity as v, which typed transactions use instead of eip155 v
+func deriveTypedSignature(sig []byte) (v, r, s *big.Int){
+	r = new(big.Int).SetBytes(sig[:32])
+	s = new(big.Int).SetBytes(sig[32:64])
+	v = new(big.Int).SetBytes([]byte{sig[64]})
 	return
 }
 
 func VerifyMessage(address common.Address, signature []byte, message string) bool{
 	recoveredPubkey, err := crypto.SigToPub(signMessageHash([]byte(message)), signature)
 	if err != nil || recoveredPubkey == nil {
-		fmt.Errorf("signature verification failed: %s", err)
+		return false
 	}
 	recoveredAddress := crypto.PubkeyToAddress(*recoveredPubkey)
 	success := address == recoveredAddress
 	return success
 }
 
+// SignTypedData signs eip712 typed data like eth_signTypedData_v4, v of the signature is 27 or 28
+func (w *Wallet) SignTypedData(typedData *types.TypedData) (string, error) {
+	hash, err := typedData.SignHash()
+	if err != nil {
+		return "", err
+	}
+	sig, err := crypto.Sign(hash, w.Key.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+	sig[64] += 27
+	return utils.BytesToHexStr(sig), nil
+}
+
+// VerifyTypedData accepts signature which v is 0, 1 or 27, 28
+func VerifyTypedData(address common.Address, signature []byte, typedData *types.TypedData) (bool, error) {
+	if len(signature) != 65 {
+		return false, fmt.Errorf("signature must be 65 bytes, got %d", len(signature))
+	}
+	hash, err := typedData.SignHash()
+	if err != nil {
+		return false, err
+	}
+	sig := make([]byte, 65)
+	copy(sig, signature)
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	recoveredPubkey, err := crypto.SigToPub(hash, sig)
+	if err != nil || recoveredPubkey == nil {
+		return false, nil
+	}
+	return crypto.PubkeyToAddress(*recoveredPubkey) == address, nil
+}
+
 func (w *Wallet) SignTxToRawTx(tx *types.Transaction) (string, error) {
 	err := w.SignTx(tx)
 	if err != nil {
@@ -100,6 +152,7 @@ type EthereumWallet struct {
 	conn      *conn.EthConn
 	Wallet    *Wallet
 	erc20List []*types.Erc20Token
+	nonces    *NonceManager
 }
 
 func NewEthereumWallet(auth, path string, config types.Config) (*EthereumWallet, error) {
@@ -111,6 +164,7 @@ func NewEthereumWallet(auth, path string, config types.Config) (*EthereumWallet,
 		conn:      conn.NewEthConn(config.ServerUrl),
 		Wallet:    wallet,
 		erc20List: config.Erc20List,
+		nonces:    NewNonceManager(config.NonceFile),
 	}, nil
 }
 
@@ -123,6 +177,7 @@ func ImportEthereumWallet(auth, path string, config types.Config) (*EthereumWall
 		conn:      conn.NewEthConn(config.ServerUrl),
 		Wallet:    wallet,
 		erc20List: config.Erc20List,
+		nonces:    NewNonceManager(config.NonceFile),
 	}, nil
 }
 
@@ -139,6 +194,7 @@ func ImportLookupEthereumWallet(address common.Address, config types.Config) *Et
 		conn:      conn.NewEthConn(config.ServerUrl),
 		Wallet:    wallet,
 		erc20List: config.Erc20List,
+		nonces:    NewNonceManager(config.NonceFile),
 	}
 }
 
@@ -175,8 +231,25 @@ func (ew *EthereumWallet) GetGasPrice() (*big.Int, error){
 	return gasPrice, nil
 }
 
+// GetBaseFee returns nil if the node doesn't report a base fee
+func (ew *EthereumWallet) GetBaseFee() (*big.Int, error){
+	baseFee, err := ew.conn.GetBaseFee()
+	if err != nil {
+		return nil, err
+	}
+	return baseFee, nil
+}
+
+func (ew *EthereumWallet) GetMaxPriorityFee() (*big.Int, error){
+	tip, err := ew.conn.GetMaxPriorityFee()
+	if err != nil {
+		return big.NewInt(0), err
+	}
+	return tip, nil
+}
+
 func (ew *EthereumWallet) GetNonce(param types.BlockParam) (uint64, error){
-	nonce, err := ew.conn.GetNonce(ew.Wallet.Key.Address)
+	nonce, err := ew.conn.GetNonce(ew.Wallet.Key.Address, param)
 	if err != nil {
 		return 0, err
 	}
@@ -191,7 +264,7 @@ func (ew *EthereumWallet) GetGasLimit(tx *types.TransactionRequest) (uint64, err
 	return gaslimit, nil
 }
 
-func (ew *EthereumWallet) GetErc20ListBalance() (map[string]*big.Int, error){
+func (ew *EthereumWallet) GetErc20ListBalance() (map[string]*types.Amount, error){
 	list, err := ew.conn.GetErc20Balance(ew.Wallet.Key.Address)
 	if err != nil {
 		return nil, err
@@ -199,6 +272,73 @@ func (ew *EthereumWallet) GetErc20ListBalance() (map[string]*big.Int, error){
 	return list, nil
 }
 
+// GetDiscoveredErc20Balance finds tokens the address received from source instead of erc20_list and returns the held ones
+func (ew *EthereumWallet) GetDiscoveredErc20Balance(source string, fromBlock uint64) ([]types.Erc20Balance, error){
+	balances, err := ew.conn.GetDiscoveredErc20Balance(ew.Wallet.Key.Address, source, fromBlock)
+	if err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+// balancesChunkSize is the number of addresses in a request of GetBalances
+const balancesChunkSize = 1000
+
+// GetBalances gets balances of assets of addresses at block, keyed by address.String(). Addresses are sent
+// balancesChunkSize at a time so a long list doesn't hit the server's limit or the http timeout.
+// A balance missing in the server's answer is an error.
+func (ew *EthereumWallet) GetBalances(addresses []common.Address, assets []string, block string) (types.Balances, error){
+	balances := make(types.Balances, len(addresses))
+	for start := 0; start < len(addresses); start += balancesChunkSize {
+		end := start + balancesChunkSize
+		if end > len(addresses) {
+			end = len(addresses)
+		}
+		req := types.BalancesRequest{Assets: assets, Block: block}
+		for _, address := range addresses[start:end] {
+			req.Addresses = append(req.Addresses, address.String())
+		}
+		chunk, err := ew.conn.GetBalances(req)
+		if err != nil {
+			return nil, err
+		}
+		// a missing balance would be printed as a zero balance
+		for _, address := range req.Addresses {
+			amounts := chunk[address]
+			for _, asset := range assets {
+				if amounts[asset] == nil {
+					return nil, fmt.Errorf("server answered no %s balance of %s", asset, address)
+				}
+			}
+			balances[address] = amounts
+		}
+	}
+	return balances, nil
+}
+
+// ResolveEnsName resolves an ens name like vitalik.eth to its address
+func (ew *EthereumWallet) ResolveEnsName(name string) (common.Address, error){
+	address, err := ew.conn.ResolveEnsName(name)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return address, nil
+}
+
+// LookupEnsAddress returns the primary name of address, or "" if it has none
+func (ew *EthereumWallet) LookupEnsAddress(address common.Address) (string, error){
+	return ew.conn.LookupEnsAddress(address)
+}
+
+// GetApprovals lists live allowances of wallet's address found from Approval logs since fromBlock
+func (ew *EthereumWallet) GetApprovals(fromBlock uint64) ([]types.Erc20Approval, error){
+	approvals, err := ew.conn.GetApprovals(ew.Wallet.Key.Address, fromBlock)
+	if err != nil {
+		return nil, err
+	}
+	return approvals, nil
+}
+
 func (ew *EthereumWallet) GetNormalTransactionHistory() ([]types.EsNormalTransaction, error){
 	txs, err := ew.conn.GetNormalTransactions(ew.Wallet.Key.Address)
 	if err != nil {
@@ -223,6 +363,73 @@ func (ew *EthereumWallet) GetInternalTransactionHistory() ([]types.EsInternalTan
 	return txs, nil
 }
 
+// GetTransactionReceipt returns nil if the transaction is pending or unknown
+func (ew *EthereumWallet) GetTransactionReceipt(txid string) (*types.NodeReceipt, error){
+	receipt, err := ew.conn.GetTransactionReceipt(txid)
+	if err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// WaitForReceipt polls the receipt of txid every interval until the transaction is mined or timeout is reached
+func (ew *EthereumWallet) WaitForReceipt(txid string, interval time.Duration, timeout time.Duration) (*types.NodeReceipt, error){
+	deadline := time.Now().Add(timeout)
+	for {
+		receipt, err := ew.GetTransactionReceipt(txid)
+		if err != nil {
+			return nil, fmt.Errorf("GetTransactionReceipt occured error: %s\n", err)
+		}
+		if receipt != nil {
+			return receipt, nil
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return nil, fmt.Errorf("transaction %s is not mined after %s", txid, timeout.String())
+		}
+		time.Sleep(interval)
+	}
+}
+
+// WaitForConfirmations polls until the block of txid has confirmations blocks on top of it(the block itself counts as 1).
+// If the receipt disappears or moves to another block, the transaction was dropped by a reorg, reorged is called with
+// the old receipt and waiting starts over until timeout is reached.
+func (ew *EthereumWallet) WaitForConfirmations(txid string, confirmations uint64, interval time.Duration, timeout time.Duration, reorged func(receipt *types.NodeReceipt)) (*types.NodeReceipt, error){
+	if confirmations == 0 {
+		confirmations = 1
+	}
+	deadline := time.Now().Add(timeout)
+	var seen *types.NodeReceipt
+	for {
+		receipt, err := ew.GetTransactionReceipt(txid)
+		if err != nil {
+			return nil, fmt.Errorf("GetTransactionReceipt occured error: %s\n", err)
+		}
+		if seen != nil && (receipt == nil || receipt.BlockHash != seen.BlockHash) {
+			if reorged != nil {
+				reorged(seen)
+			}
+			seen = nil
+		}
+		if receipt != nil {
+			seen = receipt
+			head, err := ew.conn.GetBlockNumber()
+			if err != nil {
+				return nil, fmt.Errorf("GetBlockNumber occured error: %s\n", err)
+			}
+			if head.Uint64()+1 >= uint64(receipt.BlockNumber)+confirmations {
+				return receipt, nil
+			}
+		}
+		if time.Now().Add(interval).After(deadline) {
+			if seen != nil {
+				return nil, fmt.Errorf("transaction %s doesn't get %d confirmations after %s", txid, confirmations, timeout.String())
+			}
+			return nil, fmt.Errorf("transaction %s is not mined after %s", txid, timeout.String())
+		}
+		time.Sleep(interval)
+	}
+}
+
 func (ew *EthereumWallet) SendRawTransaction(raw string) (string, error){
 	txid, err := ew.conn.SendRawTransaction(raw)
 	if err != nil {
@@ -232,60 +439,139 @@ func (ew *EthereumWallet) SendRawTransaction(raw string) (string, error){
 }
 
 
-func (ew *EthereumWallet) createNormalTransaction(to *common.Address, value *big.Int, data []byte, gasPrice *big.Int, gasLimit uint64) (*types.Transaction, error){
-	var tx *types.Transaction
-	var err error
-	if  gasPrice == nil ||gasPrice.Cmp(big.NewInt(0)) == 0 {
-		gasPrice, err = ew.GetGasPrice()
+func (ew *EthereumWallet) createNormalTransaction(to *common.Address, value *big.Int, data []byte, gasPrice *big.Int, maxFee *big.Int, tip *big.Int, gasLimit uint64) (*types.Transaction, error){
+	tx, pending, err := ew.buildTransaction(to, value, data, gasPrice, maxFee, tip, gasLimit)
+	if err != nil {
+		return nil, err
+	}
+	// the nonce is acquired last, an error above would leave it reserved and make a gap
+	if ew.nonces != nil {
+		tx.Nonce, err = ew.nonces.Acquire(ew.Wallet.Network.ChainId, ew.Wallet.Key.Address, pending)
 		if err != nil {
-			return nil, fmt.Errorf("GetGasPrice occured error:%s \n", err)
+			return nil, fmt.Errorf("acquire nonce occured error:%s \n", err)
 		}
 	}
-	nonce, err := ew.GetNonce(types.Latest)
-	if err != nil {
-		return nil, fmt.Errorf("GetNonce occured error:%s \n", err)
-	}
+	return tx, nil
+}
+
+// buildTransaction fills fees and gas limit of tx, its nonce is node's pending count which is returned too,
+// the nonce isn't acquired from the nonce manager.
+func (ew *EthereumWallet) buildTransaction(to *common.Address, value *big.Int, data []byte, gasPrice *big.Int, maxFee *big.Int, tip *big.Int, gasLimit uint64) (*types.Transaction, uint64, error){
+	var tx *types.Transaction
+	var err error
 	tx = &types.Transaction{
 		From:	  &ew.Wallet.Key.Address,
 		To:		  to,
-		GasPrice: gasPrice,
-		Nonce:	  nonce,
 		Value:	  value,
 		Data: 	  data,
 	}
+	params, err := ew.conn.GetTransactionParams(*tx.ToTransactionRequest(), types.Pending, gasLimit == 0)
+	if err != nil {
+		return nil, 0, fmt.Errorf("GetTransactionParams occured error:%s \n", err)
+	}
+	tx.Nonce = params.Nonce
+	if isZero(gasPrice) {
+		if !isZero(maxFee) || params.BaseFee != nil {
+			err = ew.fillDynamicFee(tx, params.BaseFee, maxFee, tip, params.MaxPriorityFee)
+			if err != nil {
+				return nil, 0, err
+			}
+		} else if !isZero(tip) {
+			// a legacy tx has no tip, sending it without the tip is not what was asked for
+			return nil, 0, fmt.Errorf("node has no base fee, tip needs maxfee, or use gasprice")
+		} else {
+			gasPrice = params.GasPrice
+		}
+	}
+	if tx.Type == types.LegacyTxType {
+		tx.GasPrice = gasPrice
+	}
 	if gasLimit == 0 {
-		txr := tx.ToTransactionRequest()
-		gasLimit, err = ew.GetGasLimit(txr)
-		if err != nil {
-			return nil, fmt.Errorf("GetGasLimit occured error:%v \n", err)
+		gasLimit = params.GasLimit
+		if len(data) > 0 && to != nil {
+			gasLimit = ew.attachAccessList(tx, gasLimit)
 		}
 	}
 	tx.GasLimit = gasLimit
-	return tx, nil
+	return tx, params.Nonce, nil
+}
+
+// attachAccessList asks the node for the access list of a contract call and attaches it
+// only when it lowers the estimated gas, it returns the gas limit tx should use.
+func (ew *EthereumWallet) attachAccessList(tx *types.Transaction, gasLimit uint64) uint64 {
+	accessList, _, err := ew.conn.CreateAccessList(*tx.ToTransactionRequest())
+	if err != nil || len(accessList) == 0 {
+		return gasLimit
+	}
+	txr := tx.ToTransactionRequest()
+	txr.AccessList = accessList
+	gasWithList, err := ew.GetGasLimit(txr)
+	if err != nil || gasWithList >= gasLimit {
+		return gasLimit
+	}
+	tx.AccessList = accessList
+	if tx.Type == types.LegacyTxType {
+		tx.Type = types.AccessListTxType
+	}
+	return gasWithList
 }
 
-func (ew *EthereumWallet) createErc20Transation(token *types.Erc20Token, value *big.Int, to *common.Address,gasPrice *big.Int, gasLimit uint64) (*types.Transaction, error){
+// fillDynamicFee turns tx into an eip1559 transaction, the tip defaults to the node's suggestion
+// and the max fee defaults to 2 * baseFee + tip, so it stays valid through several full blocks.
+func (ew *EthereumWallet) fillDynamicFee(tx *types.Transaction, baseFee *big.Int, maxFee *big.Int, tip *big.Int, suggestedTip *big.Int) error {
+	var err error
+	if isZero(tip) {
+		tip = suggestedTip
+		if tip == nil {
+			tip, err = ew.GetMaxPriorityFee()
+			if err != nil {
+				return fmt.Errorf("GetMaxPriorityFee occured error:%s \n", err)
+			}
+		}
+		if !isZero(maxFee) && tip.Cmp(maxFee) > 0 {
+			tip = new(big.Int).Set(maxFee)
+		}
+	}
+	if isZero(maxFee) {
+		maxFee = new(big.Int).Mul(baseFee, big.NewInt(2))
+		maxFee.Add(maxFee, tip)
+	}
+	if tip.Cmp(maxFee) > 0 {
+		return fmt.Errorf("tip %s is bigger than maxfee %s", tip.String(), maxFee.String())
+	}
+	tx.Type = types.DynamicFeeTxType
+	tx.MaxFeePerGas = maxFee
+	tx.MaxPriorityFeePerGas = tip
+	return nil
+}
+
+func isZero(i *big.Int) bool {
+	return i == nil || i.Sign() == 0
+}
+
+func (ew *EthereumWallet) createErc20Transation(token *types.Erc20Token, value *big.Int, to *common.Address,gasPrice *big.Int, maxFee *big.Int, tip *big.Int, gasLimit uint64) (*types.Transaction, error){
 	data := token.GenerateTransferData(value, to)
-	tx, err := ew.createNormalTransaction(token.Address, big.NewInt(0), data , gasPrice, gasLimit)
+	tx, err := ew.createNormalTransaction(token.Address, big.NewInt(0), data , gasPrice, maxFee, tip, gasLimit)
 	if err != nil {
 		return nil, err
 	}
 	return tx, nil
 }
 
-func (ew *EthereumWallet) TransferEther(to *common.Address, value *big.Int, data []byte, gasPrice *big.Int, gasLimit uint64) (txid string, err error) {
+func (ew *EthereumWallet) TransferEther(to *common.Address, value *big.Int, data []byte, gasPrice *big.Int, maxFee *big.Int, tip *big.Int, gasLimit uint64) (txid string, err error) {
 	ether, err := ew.GetBalance()
 	if err != nil {
 		return "", fmt.Errorf("get balance occured error: %s\n",err)
 	}
 	// tx, err := ew.createNormalTransaction(to, value, []byte{}, big.NewInt(0), 0)
-	tx, err := ew.createNormalTransaction(to, value, data, gasPrice, gasLimit)
+	tx, err := ew.createNormalTransaction(to, value, data, gasPrice, maxFee, tip, gasLimit)
 	if err != nil {
 		return "",  fmt.Errorf("createNormalTransaction occured error: %s\n",err)
 	}
-	ok := checkValueEnough(tx.Value, tx.GasPrice, tx.GasLimit, ether)
-	if !ok {
-		return "", fmt.Errorf("your transaction's cost is bigger then ethers you own")
+	err = checkValueEnough(tx.Value, tx.FeeCap(), tx.GasLimit, ether)
+	if err != nil {
+		ew.releaseNonce(tx)
+		return "", err
 	}
 	txid, err = ew.signAndPublishTx(tx)
 	if err != nil {
@@ -294,17 +580,79 @@ func (ew *EthereumWallet) TransferEther(to *common.Address, value *big.Int, data
 	return
 }
 
-func checkValueEnough(value *big.Int, gasPrice *big.Int, gasLimit uint64, ether *big.Int) bool{
-	tvalue := big.NewInt(0).Set(value)
-	tgasPrice := big.NewInt(0).Set(gasPrice)
-	if tvalue.Add(tvalue, tgasPrice.Mul(tgasPrice, big.NewInt(int64(gasLimit)))).Cmp(ether) == 1 {
-		return false
+// DeployContract sends a contract creation transaction which data is bytecode followed by abi encoded
+// constructor args, the contract address is predicted from sender and nonce.
+func (ew *EthereumWallet) DeployContract(bytecode []byte, args []byte, value *big.Int, gasPrice *big.Int, maxFee *big.Int, tip *big.Int, gasLimit uint64) (txid string, contract common.Address, err error) {
+	if len(bytecode) == 0 {
+		return "", common.Address{}, fmt.Errorf("bytecode is empty")
+	}
+	ether, err := ew.GetBalance()
+	if err != nil {
+		return "", common.Address{}, fmt.Errorf("get balance occured error: %s\n",err)
+	}
+	data := append(append([]byte{}, bytecode...), args...)
+	tx, err := ew.createNormalTransaction(nil, value, data, gasPrice, maxFee, tip, gasLimit)
+	if err != nil {
+		return "", common.Address{}, fmt.Errorf("createNormalTransaction occured error: %s\n",err)
+	}
+	err = checkValueEnough(tx.Value, tx.FeeCap(), tx.GasLimit, ether)
+	if err != nil {
+		ew.releaseNonce(tx)
+		return "", common.Address{}, err
+	}
+	contract = crypto.CreateAddress(ew.Wallet.Key.Address, tx.Nonce)
+	txid, err = ew.signAndPublishTx(tx)
+	if err != nil {
+		return "", common.Address{}, fmt.Errorf("signAndPublishTx occured error:%s \n", err)
+	}
+	return
+}
+
+// CallContract sends eth_call from wallet's address and decodes the outputs of method
+func (ew *EthereumWallet) CallContract(contract *common.Address, method *abi.Method, args []interface{}) ([]interface{}, error) {
+	data, err := method.Pack(args...)
+	if err != nil {
+		return nil, err
+	}
+	txr := &types.TransactionRequest{
+		To:   contract.String(),
+		Data: utils.BytesToHexStr(data),
+	}
+	if ew.Wallet.Key.Address != (common.Address{}) {
+		txr.From = ew.Wallet.Key.Address.String()
 	}
-	return true
+	res, err := ew.conn.Call(*txr)
+	if err != nil {
+		return nil, fmt.Errorf("call %s occured error: %s", method.Signature, err)
+	}
+	return method.Unpack(utils.HexStrToBytes(res))
+}
+
+// ExecContract sends a transaction calling method of contract, value is only allowed for payable method
+func (ew *EthereumWallet) ExecContract(contract *common.Address, method *abi.Method, args []interface{}, value *big.Int, gasPrice *big.Int, maxFee *big.Int, tip *big.Int, gasLimit uint64) (txid string, err error) {
+	if value.Sign() > 0 && !method.IsPayable() {
+		return "", fmt.Errorf("%s is not payable", method.Signature)
+	}
+	data, err := method.Pack(args...)
+	if err != nil {
+		return "", err
+	}
+	return ew.TransferEther(contract, value, data, gasPrice, maxFee, tip, gasLimit)
 }
 
-func (ew *EthereumWallet) TransferErc20(token *types.Erc20Token, value *big.Int, to *common.Address, gasPrice *big.Int, gasLimit uint64) (txid string, err error){
-	tx, err := ew.createErc20Transation(token, value, to, gasPrice,gasLimit)
+// checkValueEnough returns an error showing the cost and balance in ether if value and max fees are more than ether
+func checkValueEnough(value *big.Int, gasPrice *big.Int, gasLimit uint64, ether *big.Int) error{
+	cost := types.NewAmount(value, 18)
+	cost.Value.Add(cost.Value, new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(gasLimit)))
+	balance := types.NewAmount(ether, 18)
+	if cost.Cmp(balance) > 0 {
+		return fmt.Errorf("your transaction's cost %s ether is bigger then %s ethers you own", cost.String(), balance.String())
+	}
+	return nil
+}
+
+func (ew *EthereumWallet) TransferErc20(token *types.Erc20Token, value *big.Int, to *common.Address, gasPrice *big.Int, maxFee *big.Int, tip *big.Int, gasLimit uint64) (txid string, err error){
+	tx, err := ew.createErc20Transation(token, value, to, gasPrice, maxFee, tip, gasLimit)
 	//tx, err := ew.createErc20Transation(token, value, to, big.NewInt(0),0)
 	if err != nil {
 		return "",err
@@ -316,15 +664,31 @@ func (ew *EthereumWallet) TransferErc20(token *types.Erc20Token, value *big.Int,
 	return
 }
 
+// signAndPublishTx gives the nonce of tx back to nonce manager if tx is not sent
 func (ew *EthereumWallet) signAndPublishTx(tx *types.Transaction) (txid string, err error){
 	rawTx, err := ew.Wallet.SignTxToRawTx(tx)
 	if err != nil {
+		ew.releaseNonce(tx)
 		return "", fmt.Errorf("SignTxToRawTx occured error:%s \n", err)
 	}
 	txid, err = ew.SendRawTransaction(rawTx)
 	if err != nil {
+		ew.releaseNonce(tx)
 		return "", fmt.Errorf("SendRawTransaction occured error:%s \n", err)
 	}
 	return
 }
 
+func (ew *EthereumWallet) reserveNonce(nonce uint64) error {
+	if ew.nonces != nil {
+		return ew.nonces.Reserve(ew.Wallet.Network.ChainId, ew.Wallet.Key.Address, nonce)
+	}
+	return nil
+}
+
+func (ew *EthereumWallet) releaseNonce(tx *types.Transaction) {
+	if ew.nonces != nil {
+		ew.nonces.Release(ew.Wallet.Network.ChainId, ew.Wallet.Key.Address, tx.Nonce)
+	}
+}
+